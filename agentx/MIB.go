@@ -0,0 +1,227 @@
+package agentx
+
+import (
+	"net"
+	"sort"
+	"time"
+	"vrrp-go/vrrp"
+)
+
+// the objects of VRRPV3-MIB, RFC 6527
+var (
+	VRRPv3MIB        = OID{1, 3, 6, 1, 2, 1, 207}
+	operationsEntry  = VRRPv3MIB.Append(1, 1, 1, 1)
+	routerStatistics = VRRPv3MIB.Append(1, 2)
+	statisticsEntry  = routerStatistics.Append(5, 1)
+)
+
+// columns of vrrpv3OperationsEntry
+const (
+	operationsMasterIPAddr = 3 + iota
+	operationsPrimaryIPAddr
+	operationsVirtualMACAddr
+	operationsStatus
+	operationsPriority
+	operationsAddrCount
+	operationsAdvInterval
+	operationsPreemptMode
+	operationsAcceptMode
+	operationsUpTime
+	operationsRowStatus
+)
+
+// columns of vrrpv3StatisticsEntry
+const (
+	statisticsMasterTransitions = 1 + iota
+	statisticsNewMasterReason
+	statisticsRcvdAdvertisements
+	statisticsAdvIntervalErrors
+	statisticsIPTTLErrors
+	statisticsProtoErrReason
+	statisticsRcvdPriZeroPackets
+	statisticsSentPriZeroPackets
+	statisticsRcvdInvalidTypePackets
+	statisticsAddressListErrors
+	statisticsPacketLengthErrors
+	statisticsRowDiscontinuityTime
+	statisticsRefreshRate
+)
+
+// scalars of vrrpv3Statistics
+const (
+	routerChecksumErrors = 1 + iota
+	routerVersionErrors
+	routerVrIDErrors
+	globalStatisticsDiscontinuityTime
+)
+
+// object is an instance of the view, column is the OID of its column or of its scalar
+type object struct {
+	VarBind
+	column OID
+}
+
+// view is a snapshot of VRRPV3-MIB sorted by OID
+type view []object
+
+// snapshot take the view of the virtual routers of source, the time stamps count the centiseconds since epoch
+func snapshot(source vrrp.RouterSource, epoch time.Time) view {
+	var objects view
+	var add = func(column OID, index OID, t VarType, value interface{}) {
+		objects = append(objects, object{VarBind: VarBind{Type: t, Name: column.Append(index...), Value: value}, column: column})
+	}
+	var stamp = func(t time.Time) uint32 {
+		return timeTicks(epoch, t)
+	}
+	for _, vr := range source.Routers() {
+		var status, statistics = vr.Status(), vr.Statistics()
+		var addrType, mac = uint32(1), net.HardwareAddr{0, 0, 0x5e, 0, 1, status.VRID}
+		if vr.IPvX() == vrrp.IPv6 {
+			addrType, mac[4] = 2, 2
+		}
+		//the rows are indexed by ifIndex, VRID and address type, a virtual router working on a caller
+		//supplied transport has no interface and is served with ifIndex 0
+		var ifIndex uint32
+		if nif := vr.NetInterface(); nif != nil {
+			ifIndex = uint32(nif.Index)
+		}
+		var index = OID{ifIndex, uint32(status.VRID), addrType}
+		var operation = func(column uint32, t VarType, value interface{}) {
+			add(operationsEntry.Append(column), index, t, value)
+		}
+		var statistic = func(column uint32, t VarType, value interface{}) {
+			add(statisticsEntry.Append(column), index, t, value)
+		}
+		operation(operationsMasterIPAddr, OctetString, inetAddress(status.MasterIP, vr.IPvX()))
+		operation(operationsPrimaryIPAddr, OctetString, inetAddress(status.SourceIP, vr.IPvX()))
+		operation(operationsVirtualMACAddr, OctetString, []byte(mac))
+		operation(operationsStatus, Integer, operationsStatusOf(status.State))
+		operation(operationsPriority, Gauge32, uint32(status.Priority))
+		operation(operationsAddrCount, Integer, int32(len(status.Addresses)))
+		operation(operationsAdvInterval, Integer, int32(status.AdvertisementInterval/(10*time.Millisecond)))
+		operation(operationsPreemptMode, Integer, truthValue(status.Preempt))
+		operation(operationsAcceptMode, Integer, truthValue(status.AcceptMode))
+		operation(operationsUpTime, TimeTicks, stamp(status.UpSince))
+		//the rows are created by the configuration of the daemon, they are always active
+		operation(operationsRowStatus, Integer, int32(1))
+
+		statistic(statisticsMasterTransitions, Counter32, uint32(statistics.MasterTransitions))
+		statistic(statisticsNewMasterReason, Integer, int32(statistics.NewMasterReason))
+		statistic(statisticsRcvdAdvertisements, Counter64, statistics.AdvertisementsReceived)
+		statistic(statisticsAdvIntervalErrors, Counter64, statistics.AdvertisementIntervalErrors)
+		statistic(statisticsIPTTLErrors, Counter64, statistics.TTLErrors)
+		statistic(statisticsProtoErrReason, Integer, int32(statistics.ProtocolError))
+		statistic(statisticsRcvdPriZeroPackets, Counter64, statistics.PriorityZeroReceived)
+		statistic(statisticsSentPriZeroPackets, Counter64, statistics.PriorityZeroSent)
+		statistic(statisticsRcvdInvalidTypePackets, Counter64, statistics.InvalidTypeErrors)
+		statistic(statisticsAddressListErrors, Counter64, statistics.AddressListErrors)
+		statistic(statisticsPacketLengthErrors, Counter64, statistics.PacketLengthErrors)
+		statistic(statisticsRowDiscontinuityTime, TimeTicks, stamp(statistics.Discontinuity))
+		statistic(statisticsRefreshRate, Gauge32, uint32(statistics.RefreshRate/time.Millisecond))
+	}
+	var errs = vrrp.GlobalReceiveErrors()
+	add(routerStatistics.Append(routerChecksumErrors), OID{0}, Counter64, errs.ChecksumErrors)
+	add(routerStatistics.Append(routerVersionErrors), OID{0}, Counter64, errs.VersionErrors)
+	add(routerStatistics.Append(routerVrIDErrors), OID{0}, Counter64, errs.VRIDErrors)
+	add(routerStatistics.Append(globalStatisticsDiscontinuityTime), OID{0}, TimeTicks, stamp(errs.Discontinuity))
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name.Compare(objects[j].Name) < 0
+	})
+	return objects
+}
+
+// get return the instance name, noSuchInstance is returned if its object exists and noSuchObject otherwise
+func (v view) get(name OID) VarBind {
+	var index = sort.Search(len(v), func(i int) bool {
+		return v[i].Name.Compare(name) >= 0
+	})
+	if index < len(v) && v[index].Name.Compare(name) == 0 {
+		return v[index].VarBind
+	}
+	for _, o := range v {
+		if name.HasPrefix(o.column) {
+			return VarBind{Type: NoSuchInstance, Name: name}
+		}
+	}
+	return VarBind{Type: NoSuchObject, Name: name}
+}
+
+// next return the first instance in r, endOfMibView is returned if there is none
+func (v view) next(r SearchRange) VarBind {
+	var index = sort.Search(len(v), func(i int) bool {
+		var order = v[i].Name.Compare(r.Start)
+		return order > 0 || (order == 0 && r.Include)
+	})
+	if index == len(v) || (len(r.End) != 0 && v[index].Name.Compare(r.End) >= 0) {
+		return VarBind{Type: EndOfMIBView, Name: r.Start}
+	}
+	return v[index].VarBind
+}
+
+// bulk answer a GetBulk, the repeated ranges go on from the instances found by the previous repetition
+func (v view) bulk(ranges []SearchRange, nonRepeaters, maxRepetitions int) []VarBind {
+	if nonRepeaters > len(ranges) {
+		nonRepeaters = len(ranges)
+	}
+	var binds []VarBind
+	for _, r := range ranges[:nonRepeaters] {
+		binds = append(binds, v.next(r))
+	}
+	var repeated = append([]SearchRange(nil), ranges[nonRepeaters:]...)
+	for repetition := 0; repetition < maxRepetitions && len(repeated) > 0; repetition++ {
+		var ended = true
+		for index := range repeated {
+			var bind = v.next(repeated[index])
+			binds = append(binds, bind)
+			if bind.Type != EndOfMIBView {
+				ended = false
+				repeated[index].Start, repeated[index].Include = bind.Name, false
+			}
+		}
+		if ended {
+			break
+		}
+	}
+	return binds
+}
+
+// timeTicks return the centiseconds from epoch to t, 0 if t is zero or before epoch
+func timeTicks(epoch, t time.Time) uint32 {
+	if t.IsZero() || t.Before(epoch) {
+		return 0
+	}
+	return uint32(t.Sub(epoch) / (10 * time.Millisecond))
+}
+
+// inetAddress encode ip as InetAddress of the family, an unknown address is all zeros
+func inetAddress(ip net.IP, IPvX byte) []byte {
+	if IPvX == vrrp.IPv6 {
+		if ip16 := ip.To16(); ip16 != nil {
+			return []byte(ip16)
+		}
+		return make([]byte, net.IPv6len)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return []byte(ip4)
+	}
+	return make([]byte, net.IPv4len)
+}
+
+// operationsStatusOf map the state to vrrpv3OperationsStatus, FAULT neither advertises nor listens like INIT
+func operationsStatusOf(state vrrp.State) int32 {
+	switch state {
+	case vrrp.BACKUP:
+		return 2
+	case vrrp.MASTER:
+		return 3
+	default:
+		return 1
+	}
+}
+
+func truthValue(flag bool) int32 {
+	if flag {
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,537 @@
+// Package agentx implements the AgentX protocol of RFC 2741 and a subagent serving the VRRPV3-MIB of RFC 6527
+package agentx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PDUType is the type of an AgentX PDU
+type PDUType byte
+
+const (
+	OpenPDU PDUType = 1 + iota
+	ClosePDU
+	RegisterPDU
+	UnregisterPDU
+	GetPDU
+	GetNextPDU
+	GetBulkPDU
+	TestSetPDU
+	CommitSetPDU
+	UndoSetPDU
+	CleanupSetPDU
+	NotifyPDU
+	PingPDU
+	IndexAllocatePDU
+	IndexDeallocatePDU
+	AddAgentCapsPDU
+	RemoveAgentCapsPDU
+	ResponsePDU
+)
+
+func (t PDUType) String() string {
+	var names = [...]string{"Open", "Close", "Register", "Unregister", "Get", "GetNext", "GetBulk", "TestSet",
+		"CommitSet", "UndoSet", "CleanupSet", "Notify", "Ping", "IndexAllocate", "IndexDeallocate",
+		"AddAgentCaps", "RemoveAgentCaps", "Response"}
+	if t < OpenPDU || t > ResponsePDU {
+		return "PDU type " + strconv.Itoa(int(t))
+	}
+	return names[t-OpenPDU]
+}
+
+// flags of the PDU header
+const (
+	FlagInstanceRegistration byte = 0x01
+	FlagNewIndex             byte = 0x02
+	FlagAnyIndex             byte = 0x04
+	FlagNonDefaultContext    byte = 0x08
+	FlagNetworkByteOrder     byte = 0x10
+)
+
+// reasons of the Close PDU
+const (
+	ReasonOther         byte = 1
+	ReasonParseError    byte = 2
+	ReasonProtocolError byte = 3
+	ReasonTimeouts      byte = 4
+	ReasonShutdown      byte = 5
+	ReasonByManager     byte = 6
+)
+
+// ErrorStatus is the error of the Response PDU, the AgentX errors and the SNMP errors share it
+type ErrorStatus uint16
+
+const (
+	NoError               ErrorStatus = 0
+	GenErr                ErrorStatus = 5
+	NotWritable           ErrorStatus = 17
+	OpenFailed            ErrorStatus = 256
+	NotOpen               ErrorStatus = 257
+	DuplicateRegistration ErrorStatus = 263
+	UnknownRegistration   ErrorStatus = 264
+	ParseError            ErrorStatus = 266
+	RequestDenied         ErrorStatus = 267
+	ProcessingError       ErrorStatus = 268
+)
+
+// VarType is the type of the value of a VarBind
+type VarType uint16
+
+const (
+	Integer          VarType = 2
+	OctetString      VarType = 4
+	Null             VarType = 5
+	ObjectIdentifier VarType = 6
+	IPAddress        VarType = 64
+	Counter32        VarType = 65
+	Gauge32          VarType = 66
+	TimeTicks        VarType = 67
+	Opaque           VarType = 68
+	Counter64        VarType = 70
+	NoSuchObject     VarType = 128
+	NoSuchInstance   VarType = 129
+	EndOfMIBView     VarType = 130
+)
+
+// headerLength is the length of the PDU header, the payload follows it
+const headerLength = 20
+
+// ErrParse is wrapped by ReadPDU for malformed PDUs
+var ErrParse = errors.New("malformed AgentX PDU")
+
+// OID is an object identifier
+type OID []uint32
+
+// internet is the prefix 1.3.6.1 compressed by the encoding of OIDs
+var internet = OID{1, 3, 6, 1}
+
+// ParseOID parse the dotted form of an object identifier
+func ParseOID(s string) (OID, error) {
+	var oid OID
+	for _, field := range strings.Split(strings.TrimPrefix(s, "."), ".") {
+		var subid, errOfParse = strconv.ParseUint(field, 10, 32)
+		if errOfParse != nil {
+			return nil, fmt.Errorf("ParseOID: %v", errOfParse)
+		}
+		oid = append(oid, uint32(subid))
+	}
+	return oid, nil
+}
+
+func (o OID) String() string {
+	var fields = make([]string, len(o))
+	for index, subid := range o {
+		fields[index] = strconv.FormatUint(uint64(subid), 10)
+	}
+	return strings.Join(fields, ".")
+}
+
+// Compare return -1, 0 or 1 if o sorts before, equal to or after other in lexicographical order
+func (o OID) Compare(other OID) int {
+	for index := 0; index < len(o) && index < len(other); index++ {
+		switch {
+		case o[index] < other[index]:
+			return -1
+		case o[index] > other[index]:
+			return 1
+		}
+	}
+	switch {
+	case len(o) < len(other):
+		return -1
+	case len(o) > len(other):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// HasPrefix report whether o is prefix or below it
+func (o OID) HasPrefix(prefix OID) bool {
+	return len(o) >= len(prefix) && o[:len(prefix)].Compare(prefix) == 0
+}
+
+// Append return a new OID made of o followed by subids
+func (o OID) Append(subids ...uint32) OID {
+	return append(append(make(OID, 0, len(o)+len(subids)), o...), subids...)
+}
+
+// VarBind is a variable binding, Value is an int32 for Integer, an uint32 for Counter32, Gauge32 and TimeTicks,
+// an uint64 for Counter64, a []byte for OctetString, IPAddress and Opaque, an OID for ObjectIdentifier and nil otherwise
+type VarBind struct {
+	Type  VarType
+	Name  OID
+	Value interface{}
+}
+
+// SearchRange is the range of the OIDs a Get, GetNext or GetBulk is about, End is empty if the range is unbounded
+type SearchRange struct {
+	Start   OID
+	End     OID
+	Include bool
+}
+
+// PDU is an AgentX protocol data unit, the fields which don't apply to its type are ignored
+type PDU struct {
+	Type          PDUType
+	Flags         byte
+	SessionID     uint32
+	TransactionID uint32
+	PacketID      uint32
+	// Context is carried if Flags has FlagNonDefaultContext
+	Context []byte
+
+	// Timeout, ID and Description are carried by Open, Timeout by Register as well
+	Timeout     byte
+	ID          OID
+	Description string
+	// Reason is carried by Close
+	Reason byte
+	// Priority, Subtree, RangeSubID and UpperBound are carried by Register and Unregister
+	Priority   byte
+	Subtree    OID
+	RangeSubID byte
+	UpperBound uint32
+	// Ranges are carried by Get, GetNext and GetBulk, NonRepeaters and MaxRepetitions by GetBulk
+	Ranges         []SearchRange
+	NonRepeaters   uint16
+	MaxRepetitions uint16
+	// SysUpTime, Error and Index are carried by Response
+	SysUpTime uint32
+	Error     ErrorStatus
+	Index     uint16
+	// VarBinds are carried by Response, TestSet and Notify
+	VarBinds []VarBind
+}
+
+// hasContext report whether the context is carried by the type of the PDU
+func (p *PDU) hasContext() bool {
+	switch p.Type {
+	case OpenPDU, ClosePDU, ResponsePDU, CommitSetPDU, UndoSetPDU, CleanupSetPDU:
+		return false
+	default:
+		return p.Flags&FlagNonDefaultContext != 0
+	}
+}
+
+// MarshalBinary encode the PDU in the byte order given by FlagNetworkByteOrder
+func (p *PDU) MarshalBinary() ([]byte, error) {
+	var e = encoder{order: byteOrder(binary.LittleEndian)}
+	if p.Flags&FlagNetworkByteOrder != 0 {
+		e.order = binary.BigEndian
+	}
+	e.buf = append(e.buf, 1, byte(p.Type), p.Flags, 0)
+	e.u32(p.SessionID)
+	e.u32(p.TransactionID)
+	e.u32(p.PacketID)
+	e.u32(0)
+	if p.hasContext() {
+		e.octets(p.Context)
+	}
+	switch p.Type {
+	case OpenPDU:
+		e.buf = append(e.buf, p.Timeout, 0, 0, 0)
+		e.oid(p.ID, false)
+		e.octets([]byte(p.Description))
+	case ClosePDU:
+		e.buf = append(e.buf, p.Reason, 0, 0, 0)
+	case RegisterPDU, UnregisterPDU:
+		var timeout = p.Timeout
+		if p.Type == UnregisterPDU {
+			timeout = 0
+		}
+		e.buf = append(e.buf, timeout, p.Priority, p.RangeSubID, 0)
+		e.oid(p.Subtree, false)
+		if p.RangeSubID != 0 {
+			e.u32(p.UpperBound)
+		}
+	case GetPDU, GetNextPDU, GetBulkPDU:
+		if p.Type == GetBulkPDU {
+			e.u16(p.NonRepeaters)
+			e.u16(p.MaxRepetitions)
+		}
+		for _, r := range p.Ranges {
+			e.oid(r.Start, r.Include)
+			e.oid(r.End, false)
+		}
+	case ResponsePDU:
+		e.u32(p.SysUpTime)
+		e.u16(uint16(p.Error))
+		e.u16(p.Index)
+		fallthrough
+	case TestSetPDU, NotifyPDU:
+		for _, vb := range p.VarBinds {
+			if errOfVarBind := e.varBind(vb); errOfVarBind != nil {
+				return nil, fmt.Errorf("PDU.MarshalBinary: %v", errOfVarBind)
+			}
+		}
+	case CommitSetPDU, UndoSetPDU, CleanupSetPDU, PingPDU:
+	default:
+		return nil, fmt.Errorf("PDU.MarshalBinary: %v not supported", p.Type)
+	}
+	e.order.PutUint32(e.buf[16:], uint32(len(e.buf)-headerLength))
+	return e.buf, nil
+}
+
+// ReadPDU read one PDU from r, ErrParse is wrapped if it's malformed
+func ReadPDU(r io.Reader) (*PDU, error) {
+	var header [headerLength]byte
+	if _, errOfRead := io.ReadFull(r, header[:]); errOfRead != nil {
+		return nil, fmt.Errorf("ReadPDU: %w", errOfRead)
+	}
+	if header[0] != 1 {
+		return nil, fmt.Errorf("ReadPDU: %w: version %v", ErrParse, header[0])
+	}
+	var p = &PDU{Type: PDUType(header[1]), Flags: header[2]}
+	var d = decoder{order: byteOrder(binary.LittleEndian)}
+	if p.Flags&FlagNetworkByteOrder != 0 {
+		d.order = binary.BigEndian
+	}
+	p.SessionID = d.order.Uint32(header[4:])
+	p.TransactionID = d.order.Uint32(header[8:])
+	p.PacketID = d.order.Uint32(header[12:])
+	var length = d.order.Uint32(header[16:])
+	if length%4 != 0 {
+		return nil, fmt.Errorf("ReadPDU: %w: payload length %v", ErrParse, length)
+	}
+	d.buf = make([]byte, length)
+	if _, errOfRead := io.ReadFull(r, d.buf); errOfRead != nil {
+		return nil, fmt.Errorf("ReadPDU: %w", errOfRead)
+	}
+	if p.hasContext() {
+		p.Context = d.octets()
+	}
+	switch p.Type {
+	case OpenPDU:
+		p.Timeout = d.u8()
+		d.skip(3)
+		p.ID, _ = d.oid()
+		p.Description = string(d.octets())
+	case ClosePDU:
+		p.Reason = d.u8()
+		d.skip(3)
+	case RegisterPDU, UnregisterPDU:
+		p.Timeout, p.Priority, p.RangeSubID = d.u8(), d.u8(), d.u8()
+		d.skip(1)
+		p.Subtree, _ = d.oid()
+		if p.RangeSubID != 0 {
+			p.UpperBound = d.u32()
+		}
+	case GetPDU, GetNextPDU, GetBulkPDU:
+		if p.Type == GetBulkPDU {
+			p.NonRepeaters, p.MaxRepetitions = d.u16(), d.u16()
+		}
+		for d.err == nil && len(d.buf) > 0 {
+			var r SearchRange
+			r.Start, r.Include = d.oid()
+			r.End, _ = d.oid()
+			p.Ranges = append(p.Ranges, r)
+		}
+	case ResponsePDU:
+		p.SysUpTime = d.u32()
+		p.Error = ErrorStatus(d.u16())
+		p.Index = d.u16()
+		fallthrough
+	case TestSetPDU, NotifyPDU:
+		for d.err == nil && len(d.buf) > 0 {
+			p.VarBinds = append(p.VarBinds, d.varBind())
+		}
+	case CommitSetPDU, UndoSetPDU, CleanupSetPDU, PingPDU:
+	default:
+		return nil, fmt.Errorf("ReadPDU: %w: %v not supported", ErrParse, p.Type)
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("ReadPDU: %w: %v: %v", ErrParse, p.Type, d.err)
+	}
+	if len(d.buf) != 0 {
+		return nil, fmt.Errorf("ReadPDU: %w: %v bytes left over in %v", ErrParse, len(d.buf), p.Type)
+	}
+	return p, nil
+}
+
+// byteOrder is the byte order of a PDU, given by FlagNetworkByteOrder
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// encoder append the fields of a PDU to buf
+type encoder struct {
+	order byteOrder
+	buf   []byte
+}
+
+func (e *encoder) u16(v uint16) {
+	e.buf = e.order.AppendUint16(e.buf, v)
+}
+
+func (e *encoder) u32(v uint32) {
+	e.buf = e.order.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) u64(v uint64) {
+	e.buf = e.order.AppendUint64(e.buf, v)
+}
+
+// octets append an octet string padded to a multiple of 4 bytes
+func (e *encoder) octets(data []byte) {
+	e.u32(uint32(len(data)))
+	e.buf = append(e.buf, data...)
+	for len(e.buf)%4 != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+// oid append an object identifier, the prefix 1.3.6.1.x is compressed
+func (e *encoder) oid(oid OID, include bool) {
+	var prefix byte
+	if len(oid) > len(internet) && oid.HasPrefix(internet) && oid[4] > 0 && oid[4] < 256 {
+		prefix, oid = byte(oid[4]), oid[5:]
+	}
+	var flag byte
+	if include {
+		flag = 1
+	}
+	e.buf = append(e.buf, byte(len(oid)), prefix, flag, 0)
+	for _, subid := range oid {
+		e.u32(subid)
+	}
+}
+
+func (e *encoder) varBind(vb VarBind) error {
+	e.u16(uint16(vb.Type))
+	e.u16(0)
+	e.oid(vb.Name, false)
+	var ok = true
+	switch vb.Type {
+	case Integer:
+		var v, isInt = vb.Value.(int32)
+		e.u32(uint32(v))
+		ok = isInt
+	case Counter32, Gauge32, TimeTicks:
+		var v, isUint = vb.Value.(uint32)
+		e.u32(v)
+		ok = isUint
+	case Counter64:
+		var v, isUint = vb.Value.(uint64)
+		e.u64(v)
+		ok = isUint
+	case OctetString, IPAddress, Opaque:
+		var v, isBytes = vb.Value.([]byte)
+		e.octets(v)
+		ok = isBytes
+	case ObjectIdentifier:
+		var v, isOID = vb.Value.(OID)
+		e.oid(v, false)
+		ok = isOID
+	case Null, NoSuchObject, NoSuchInstance, EndOfMIBView:
+	default:
+		return fmt.Errorf("unknown type %v of %v", vb.Type, vb.Name)
+	}
+	if !ok {
+		return fmt.Errorf("value %T of %v doesn't match type %v", vb.Value, vb.Name, vb.Type)
+	}
+	return nil
+}
+
+// decoder consume the fields of a PDU from buf, the first error sticks and zero values are returned after it
+type decoder struct {
+	order byteOrder
+	buf   []byte
+	err   error
+}
+
+// take consume n bytes, nil is returned if fewer are left
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.buf) {
+		d.err = fmt.Errorf("%v bytes wanted, %v left", n, len(d.buf))
+		return nil
+	}
+	var data = d.buf[:n]
+	d.buf = d.buf[n:]
+	return data
+}
+
+func (d *decoder) skip(n int) {
+	d.take(n)
+}
+
+func (d *decoder) u8() byte {
+	if data := d.take(1); data != nil {
+		return data[0]
+	}
+	return 0
+}
+
+func (d *decoder) u16() uint16 {
+	if data := d.take(2); data != nil {
+		return d.order.Uint16(data)
+	}
+	return 0
+}
+
+func (d *decoder) u32() uint32 {
+	if data := d.take(4); data != nil {
+		return d.order.Uint32(data)
+	}
+	return 0
+}
+
+func (d *decoder) u64() uint64 {
+	if data := d.take(8); data != nil {
+		return d.order.Uint64(data)
+	}
+	return 0
+}
+
+func (d *decoder) octets() []byte {
+	var length = int(d.u32())
+	var data = d.take(length)
+	d.skip((4 - length%4) % 4)
+	return append([]byte(nil), data...)
+}
+
+func (d *decoder) oid() (OID, bool) {
+	var count, prefix, include = int(d.u8()), d.u8(), d.u8()
+	d.skip(1)
+	var oid OID
+	if prefix != 0 {
+		oid = internet.Append(uint32(prefix))
+	}
+	for index := 0; index < count && d.err == nil; index++ {
+		oid = append(oid, d.u32())
+	}
+	return oid, include != 0
+}
+
+func (d *decoder) varBind() VarBind {
+	var vb = VarBind{Type: VarType(d.u16())}
+	d.skip(2)
+	vb.Name, _ = d.oid()
+	switch vb.Type {
+	case Integer:
+		vb.Value = int32(d.u32())
+	case Counter32, Gauge32, TimeTicks:
+		vb.Value = d.u32()
+	case Counter64:
+		vb.Value = d.u64()
+	case OctetString, IPAddress, Opaque:
+		vb.Value = d.octets()
+	case ObjectIdentifier:
+		vb.Value, _ = d.oid()
+	case Null, NoSuchObject, NoSuchInstance, EndOfMIBView:
+	default:
+		if d.err == nil {
+			d.err = fmt.Errorf("unknown type %v of %v", vb.Type, vb.Name)
+		}
+	}
+	return vb
+}
@@ -0,0 +1,90 @@
+package agentx_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"vrrp-go/agentx"
+)
+
+func TestPDURoundTrip(t *testing.T) {
+	var name = agentx.OID{1, 3, 6, 1, 2, 1, 207, 1, 1, 1, 1, 6, 1, 1, 0}
+	var pdus = []*agentx.PDU{
+		{Type: agentx.OpenPDU, Timeout: 5, ID: agentx.VRRPv3MIB, Description: "vrrp"},
+		{Type: agentx.ClosePDU, SessionID: 7, Reason: agentx.ReasonShutdown},
+		{Type: agentx.RegisterPDU, SessionID: 7, PacketID: 2, Priority: 127, Subtree: agentx.VRRPv3MIB},
+		{Type: agentx.GetPDU, Flags: agentx.FlagNonDefaultContext, Context: []byte("ctx"), Ranges: []agentx.SearchRange{{Start: name}}},
+		{Type: agentx.GetNextPDU, Ranges: []agentx.SearchRange{{Start: name, End: agentx.OID{1, 3, 6, 1, 2, 1, 208}, Include: true}}},
+		{Type: agentx.GetBulkPDU, NonRepeaters: 1, MaxRepetitions: 10, Ranges: []agentx.SearchRange{{Start: name}, {Start: agentx.OID{2, 1}}}},
+		{Type: agentx.CommitSetPDU, TransactionID: 3},
+		{Type: agentx.ResponsePDU, SysUpTime: 1234, Error: agentx.NotWritable, Index: 1, VarBinds: []agentx.VarBind{
+			{Type: agentx.Integer, Name: name, Value: int32(-3)},
+			{Type: agentx.Gauge32, Name: name, Value: uint32(100)},
+			{Type: agentx.Counter64, Name: name, Value: uint64(1) << 40},
+			{Type: agentx.OctetString, Name: name, Value: []byte{0, 0, 0x5e, 0, 1, 1}},
+			{Type: agentx.ObjectIdentifier, Name: name, Value: agentx.OID{1, 3, 6, 1, 4, 1}},
+			{Type: agentx.NoSuchInstance, Name: name},
+		}},
+	}
+	for _, flags := range []byte{0, agentx.FlagNetworkByteOrder} {
+		for _, pdu := range pdus {
+			pdu.Flags = pdu.Flags&agentx.FlagNonDefaultContext | flags
+			var data, err = pdu.MarshalBinary()
+			if err != nil {
+				t.Fatalf("%v: %v", pdu.Type, err)
+			}
+			var decoded, errOfRead = agentx.ReadPDU(bytes.NewReader(data))
+			if errOfRead != nil {
+				t.Fatalf("%v: %v", pdu.Type, errOfRead)
+			}
+			if !reflect.DeepEqual(decoded, pdu) {
+				t.Fatalf("%+v decoded as %+v", pdu, decoded)
+			}
+		}
+	}
+}
+
+func TestOIDEncoding(t *testing.T) {
+	var pdu = &agentx.PDU{Type: agentx.RegisterPDU, Flags: agentx.FlagNetworkByteOrder, Subtree: agentx.VRRPv3MIB}
+	var data, _ = pdu.MarshalBinary()
+	//1.3.6.1.2.1.207 is encoded as the prefix 2 followed by 1.207
+	var want = []byte{2, 2, 0, 0, 0, 0, 0, 1, 0, 0, 0, 207}
+	if payload := data[24:]; !bytes.Equal(payload, want) {
+		t.Fatalf("subtree encoded as %v", payload)
+	}
+	if _, err := agentx.ReadPDU(bytes.NewReader(data[:len(data)-4])); err == nil {
+		t.Fatal("truncated PDU read")
+	}
+	data[0] = 2
+	if _, err := agentx.ReadPDU(bytes.NewReader(data)); !errors.Is(err, agentx.ErrParse) {
+		t.Fatalf("error for version 2 = %v", err)
+	}
+}
+
+func TestOID(t *testing.T) {
+	var oid, err = agentx.ParseOID(".1.3.6.1.2.1.207")
+	if err != nil || oid.String() != "1.3.6.1.2.1.207" {
+		t.Fatalf("ParseOID = %v, %v", oid, err)
+	}
+	if _, err = agentx.ParseOID("1.3.x"); err == nil {
+		t.Fatal("1.3.x parsed")
+	}
+	var cases = []struct {
+		a, b agentx.OID
+		want int
+	}{
+		{agentx.OID{1, 3}, agentx.OID{1, 3}, 0},
+		{agentx.OID{1, 3}, agentx.OID{1, 3, 1}, -1},
+		{agentx.OID{1, 4}, agentx.OID{1, 3, 1}, 1},
+	}
+	for _, c := range cases {
+		if order := c.a.Compare(c.b); order != c.want {
+			t.Fatalf("%v compared with %v = %v", c.a, c.b, order)
+		}
+	}
+	if !oid.Append(1, 1).HasPrefix(agentx.VRRPv3MIB) || agentx.VRRPv3MIB.HasPrefix(oid.Append(1)) {
+		t.Fatal("HasPrefix")
+	}
+}
@@ -0,0 +1,182 @@
+package agentx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+	"vrrp-go/logger"
+	"vrrp-go/vrrp"
+)
+
+// DefaultAddress is the unix socket the master agent of net-snmp listens on
+const DefaultAddress = "/var/agentx/master"
+
+// ErrClosedByMaster is returned by Serve when the master agent closes the session
+var ErrClosedByMaster = errors.New("session closed by the master agent")
+
+// Subagent serves VRRPV3-MIB to an AgentX master agent, the MIB is read-only
+type Subagent struct {
+	source    vrrp.RouterSource
+	conn      net.Conn
+	reader    *bufio.Reader
+	sessionID uint32
+	//started is the origin of sysUpTime and of the time stamps of the MIB
+	started    time.Time
+	writeMutex sync.Mutex
+	packetID   uint32
+	closeOnce  sync.Once
+	closed     chan struct{}
+}
+
+// Dial open a session with the master agent at address and register VRRPV3-MIB for the virtual routers of source
+func Dial(network, address string, source vrrp.RouterSource) (*Subagent, error) {
+	var conn, errOfDial = net.Dial(network, address)
+	if errOfDial != nil {
+		return nil, fmt.Errorf("agentx.Dial: %v", errOfDial)
+	}
+	var s = &Subagent{
+		source:  source,
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		started: time.Now(),
+		closed:  make(chan struct{}),
+	}
+	var response, errOfOpen = s.request(&PDU{Type: OpenPDU, ID: VRRPv3MIB, Description: "vrrp-go VRRPV3-MIB subagent"})
+	if errOfOpen != nil {
+		conn.Close()
+		return nil, fmt.Errorf("agentx.Dial: %v", errOfOpen)
+	}
+	s.sessionID = response.SessionID
+	if _, errOfRegister := s.request(&PDU{Type: RegisterPDU, Priority: 127, Subtree: VRRPv3MIB}); errOfRegister != nil {
+		conn.Close()
+		return nil, fmt.Errorf("agentx.Dial: %v", errOfRegister)
+	}
+	logger.GLoger.Printf(logger.INFO, "AgentX session %v opened with %v", s.sessionID, address)
+	return s, nil
+}
+
+// Serve answer the requests of the master agent until the subagent or the session is closed,
+// nil is returned after Close and ErrClosedByMaster if the master agent closes the session
+func (s *Subagent) Serve() error {
+	for {
+		var request, errOfRead = ReadPDU(s.reader)
+		if errOfRead != nil {
+			select {
+			case <-s.closed:
+				return nil
+			default:
+			}
+			if errors.Is(errOfRead, ErrParse) {
+				//the stream can't be resynchronized after a malformed PDU
+				s.close(ReasonParseError)
+			}
+			return fmt.Errorf("Subagent.Serve: %w", errOfRead)
+		}
+		if request.Type == ClosePDU {
+			s.closeOnce.Do(func() {
+				close(s.closed)
+				s.conn.Close()
+			})
+			logger.GLoger.Printf(logger.INFO, "AgentX session %v closed by the master agent", s.sessionID)
+			return ErrClosedByMaster
+		}
+		var response, ok = s.answer(request)
+		if !ok {
+			continue
+		}
+		if errOfWrite := s.write(response); errOfWrite != nil {
+			return fmt.Errorf("Subagent.Serve: %w", errOfWrite)
+		}
+	}
+}
+
+// Close close the session and the connection, Serve returns nil then
+func (s *Subagent) Close() error {
+	return s.close(ReasonShutdown)
+}
+
+func (s *Subagent) close(reason byte) error {
+	var errOfClose error
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		if errOfWrite := s.write(&PDU{Type: ClosePDU, SessionID: s.sessionID, Reason: reason}); errOfWrite != nil {
+			logger.GLoger.Printf(logger.ERROR, "Subagent.Close: %v", errOfWrite)
+		}
+		errOfClose = s.conn.Close()
+	})
+	return errOfClose
+}
+
+// answer build the response to request, false is returned if no response is due
+func (s *Subagent) answer(request *PDU) (*PDU, bool) {
+	var response = &PDU{
+		Type:          ResponsePDU,
+		SessionID:     request.SessionID,
+		TransactionID: request.TransactionID,
+		PacketID:      request.PacketID,
+		SysUpTime:     timeTicks(s.started, time.Now()),
+	}
+	switch request.Type {
+	case GetPDU:
+		var v = snapshot(s.source, s.started)
+		for _, r := range request.Ranges {
+			response.VarBinds = append(response.VarBinds, v.get(r.Start))
+		}
+	case GetNextPDU:
+		var v = snapshot(s.source, s.started)
+		for _, r := range request.Ranges {
+			response.VarBinds = append(response.VarBinds, v.next(r))
+		}
+	case GetBulkPDU:
+		response.VarBinds = snapshot(s.source, s.started).bulk(request.Ranges, int(request.NonRepeaters), int(request.MaxRepetitions))
+	case TestSetPDU:
+		response.Error, response.Index = NotWritable, 1
+	case CommitSetPDU, UndoSetPDU, CleanupSetPDU:
+		//nothing was accepted by TestSet
+	case ResponsePDU:
+		//the responses to the pings of the master agent need no answer
+		return nil, false
+	default:
+		response.Error = ProcessingError
+	}
+	return response, true
+}
+
+// request send p and read its response, it's only used before Serve starts
+func (s *Subagent) request(p *PDU) (*PDU, error) {
+	p.SessionID = s.sessionID
+	if errOfWrite := s.write(p); errOfWrite != nil {
+		return nil, errOfWrite
+	}
+	var response, errOfRead = ReadPDU(s.reader)
+	if errOfRead != nil {
+		return nil, errOfRead
+	}
+	if response.Type != ResponsePDU || response.PacketID != p.PacketID {
+		return nil, fmt.Errorf("%v of packet %v received for %v of packet %v", response.Type, response.PacketID, p.Type, p.PacketID)
+	}
+	if response.Error != NoError {
+		return nil, fmt.Errorf("%v refused with error %v", p.Type, response.Error)
+	}
+	return response, nil
+}
+
+// write send p in network byte order, the packet ID is assigned to the PDUs sent by the subagent
+func (s *Subagent) write(p *PDU) error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	p.Flags |= FlagNetworkByteOrder
+	if p.Type != ResponsePDU {
+		s.packetID++
+		p.PacketID = s.packetID
+	}
+	var data, errOfMarshal = p.MarshalBinary()
+	if errOfMarshal != nil {
+		return errOfMarshal
+	}
+	_, errOfWrite := s.conn.Write(data)
+	return errOfWrite
+}
@@ -0,0 +1,233 @@
+package agentx_test
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"vrrp-go/agentx"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+	"vrrp-go/vrrptest"
+)
+
+// master is a stub AgentX master agent accepting one subagent
+type master struct {
+	t        *testing.T
+	conn     net.Conn
+	packetID uint32
+}
+
+// acceptSubagent accept the session of a subagent and its registration
+func acceptSubagent(t *testing.T, listener net.Listener) *master {
+	t.Helper()
+	var conn, err = listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	var m = &master{t: t, conn: conn}
+	var open = m.read()
+	if open.Type != agentx.OpenPDU || open.ID.Compare(agentx.VRRPv3MIB) != 0 {
+		t.Fatalf("first PDU = %+v", open)
+	}
+	m.write(&agentx.PDU{Type: agentx.ResponsePDU, SessionID: 7, PacketID: open.PacketID})
+	var register = m.read()
+	if register.Type != agentx.RegisterPDU || register.SessionID != 7 || register.Subtree.Compare(agentx.VRRPv3MIB) != 0 {
+		t.Fatalf("registration = %+v", register)
+	}
+	m.write(&agentx.PDU{Type: agentx.ResponsePDU, SessionID: 7, PacketID: register.PacketID})
+	return m
+}
+
+func (m *master) read() *agentx.PDU {
+	m.t.Helper()
+	m.conn.SetReadDeadline(time.Now().Add(time.Second))
+	var pdu, err = agentx.ReadPDU(m.conn)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return pdu
+}
+
+func (m *master) write(pdu *agentx.PDU) {
+	m.t.Helper()
+	var data, err = pdu.MarshalBinary()
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	if _, err = m.conn.Write(data); err != nil {
+		m.t.Fatal(err)
+	}
+}
+
+// query send the request in host byte order, as net-snmp does, and return the response
+func (m *master) query(request *agentx.PDU) *agentx.PDU {
+	m.t.Helper()
+	m.packetID++
+	request.SessionID, request.TransactionID, request.PacketID = 7, m.packetID, m.packetID
+	m.write(request)
+	var response = m.read()
+	if response.Type != agentx.ResponsePDU || response.PacketID != m.packetID || response.SessionID != 7 {
+		m.t.Fatalf("response to %v = %+v", request.Type, response)
+	}
+	return response
+}
+
+func TestSubagent(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var first = vrrptest.StartRouter(t, seg, 1, "10.0.0.2", 200)
+	var second = vrrptest.StartRouter(t, seg, 2, "10.0.0.3", 100)
+	vrrptest.AwaitState(t, first, vrrp.MASTER)
+	vrrptest.AwaitState(t, second, vrrp.MASTER)
+
+	var address = filepath.Join(t.TempDir(), "master")
+	var listener, err = net.Listen("unix", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	var dialed = make(chan *agentx.Subagent)
+	go func() {
+		var subagent, errOfDial = agentx.Dial("unix", address, vrrp.RouterList{first, second})
+		if errOfDial != nil {
+			t.Error(errOfDial)
+		}
+		dialed <- subagent
+	}()
+	var m = acceptSubagent(t, listener)
+	var subagent = <-dialed
+	if subagent == nil {
+		t.FailNow()
+	}
+	var served = make(chan error, 1)
+	go func() { served <- subagent.Serve() }()
+
+	var operations = agentx.VRRPv3MIB.Append(1, 1, 1, 1)
+	var statistics = agentx.VRRPv3MIB.Append(1, 2)
+	var response = m.query(&agentx.PDU{Type: agentx.GetPDU, Ranges: []agentx.SearchRange{
+		{Start: operations.Append(6, 0, 1, 1)},
+		{Start: operations.Append(7, 0, 2, 1)},
+		{Start: operations.Append(5, 0, 1, 1)},
+		{Start: statistics.Append(5, 1, 1, 0, 1, 1)},
+		{Start: operations.Append(6, 0, 3, 1)},
+		{Start: agentx.VRRPv3MIB.Append(9)},
+	}})
+	var want = []agentx.VarBind{
+		{Type: agentx.Integer, Name: operations.Append(6, 0, 1, 1), Value: int32(3)},
+		{Type: agentx.Gauge32, Name: operations.Append(7, 0, 2, 1), Value: uint32(100)},
+		{Type: agentx.OctetString, Name: operations.Append(5, 0, 1, 1), Value: []byte{0, 0, 0x5e, 0, 1, 1}},
+		{Type: agentx.Counter32, Name: statistics.Append(5, 1, 1, 0, 1, 1), Value: uint32(1)},
+		{Type: agentx.NoSuchInstance, Name: operations.Append(6, 0, 3, 1)},
+		{Type: agentx.NoSuchObject, Name: agentx.VRRPv3MIB.Append(9)},
+	}
+	if len(response.VarBinds) != len(want) {
+		t.Fatalf("Get answered %+v", response.VarBinds)
+	}
+	for index, vb := range response.VarBinds {
+		if vb.Type != want[index].Type || vb.Name.Compare(want[index].Name) != 0 || !equalValues(vb.Value, want[index].Value) {
+			t.Fatalf("Get answered %+v for %+v", vb, want[index])
+		}
+	}
+
+	//the walk of the MIB visits 11 columns of operations and 13 of statistics for both routers, then the 4 scalars
+	var walked []agentx.VarBind
+	for start := agentx.VRRPv3MIB; ; {
+		response = m.query(&agentx.PDU{Type: agentx.GetNextPDU, Ranges: []agentx.SearchRange{{Start: start}}})
+		var vb = response.VarBinds[0]
+		if vb.Type == agentx.EndOfMIBView {
+			break
+		}
+		if vb.Name.Compare(start) <= 0 {
+			t.Fatalf("GetNext of %v answered %v", start, vb.Name)
+		}
+		walked, start = append(walked, vb), vb.Name
+	}
+	if len(walked) != 2*11+4+2*13 {
+		t.Fatalf("%v instances walked", len(walked))
+	}
+	if primary := walked[2]; primary.Name.Compare(operations.Append(4, 0, 1, 1)) != 0 || !equalValues(primary.Value, []byte{10, 0, 0, 2}) {
+		t.Fatalf("third instance = %+v", primary)
+	}
+	if scalar := walked[22]; scalar.Name.Compare(statistics.Append(1, 0)) != 0 || scalar.Type != agentx.Counter64 {
+		t.Fatalf("first scalar = %+v", scalar)
+	}
+
+	//GetBulk goes on with the repeated ranges from the instances found
+	response = m.query(&agentx.PDU{Type: agentx.GetBulkPDU, NonRepeaters: 1, MaxRepetitions: 3, Ranges: []agentx.SearchRange{
+		{Start: statistics},
+		{Start: operations.Append(6)},
+	}})
+	var names []agentx.OID
+	for _, vb := range response.VarBinds {
+		names = append(names, vb.Name)
+	}
+	var wantNames = []agentx.OID{
+		statistics.Append(1, 0),
+		operations.Append(6, 0, 1, 1),
+		operations.Append(6, 0, 2, 1),
+		operations.Append(7, 0, 1, 1),
+	}
+	if len(names) != len(wantNames) {
+		t.Fatalf("GetBulk answered %v", names)
+	}
+	for index := range names {
+		if names[index].Compare(wantNames[index]) != 0 {
+			t.Fatalf("GetBulk answered %v", names)
+		}
+	}
+
+	//the MIB is read-only
+	response = m.query(&agentx.PDU{Type: agentx.TestSetPDU, VarBinds: []agentx.VarBind{
+		{Type: agentx.Gauge32, Name: operations.Append(7, 0, 1, 1), Value: uint32(50)},
+	}})
+	if response.Error != agentx.NotWritable || response.Index != 1 {
+		t.Fatalf("TestSet answered %+v", response)
+	}
+	if response = m.query(&agentx.PDU{Type: agentx.CleanupSetPDU}); response.Error != agentx.NoError {
+		t.Fatalf("CleanupSet answered %+v", response)
+	}
+
+	if err = subagent.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if closing := m.read(); closing.Type != agentx.ClosePDU || closing.Reason != agentx.ReasonShutdown || closing.SessionID != 7 {
+		t.Fatalf("last PDU = %+v", closing)
+	}
+	if err = <-served; err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+}
+
+func TestSubagentClosedByMaster(t *testing.T) {
+	var address = filepath.Join(t.TempDir(), "master")
+	var listener, err = net.Listen("unix", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	var served = make(chan error, 1)
+	go func() {
+		var subagent, errOfDial = agentx.Dial("unix", address, vrrp.RouterList{})
+		if errOfDial != nil {
+			served <- errOfDial
+			return
+		}
+		served <- subagent.Serve()
+	}()
+	var m = acceptSubagent(t, listener)
+	m.write(&agentx.PDU{Type: agentx.ClosePDU, SessionID: 7, Reason: agentx.ReasonByManager})
+	if err = <-served; !errors.Is(err, agentx.ErrClosedByMaster) {
+		t.Fatalf("Serve returned %v", err)
+	}
+}
+
+func equalValues(a, b interface{}) bool {
+	if x, ok := a.([]byte); ok {
+		var y, _ = b.([]byte)
+		return string(x) == string(y)
+	}
+	return a == b
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+	"vrrp-go/vrrp"
+)
+
+// Duration is a time.Duration encoded as a string like "1.5s" in JSON
+type Duration time.Duration
+
+// MarshalJSON encode the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decode a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if errOfUnmarshal := json.Unmarshal(data, &s); errOfUnmarshal != nil {
+		return errOfUnmarshal
+	}
+	var duration, errOfParse = time.ParseDuration(s)
+	if errOfParse != nil {
+		return errOfParse
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Track is the health of a track of a virtual router
+type Track struct {
+	Name    string `json:"name"`
+	Weight  int    `json:"weight"`
+	Healthy bool   `json:"healthy"`
+}
+
+// Router is the status of a virtual router, see vrrp.Status
+type Router struct {
+	VRID      byte   `json:"vrid"`
+	Family    string `json:"family"`
+	Interface string `json:"interface,omitempty"`
+	State     string `json:"state"`
+	// Priority is the priority the virtual router advertises with, BasePriority the configured one
+	Priority                    byte       `json:"priority"`
+	BasePriority                byte       `json:"base_priority"`
+	Preempt                     bool       `json:"preempt"`
+	AcceptMode                  bool       `json:"accept_mode"`
+	SourceIP                    net.IP     `json:"source_ip,omitempty"`
+	MasterIP                    net.IP     `json:"master_ip,omitempty"`
+	MasterPriority              byte       `json:"master_priority"`
+	AdvertisementInterval       Duration   `json:"advertisement_interval"`
+	MasterAdvertisementInterval Duration   `json:"master_advertisement_interval"`
+	SkewTime                    Duration   `json:"skew_time"`
+	MasterDownInterval          Duration   `json:"master_down_interval"`
+	LastTransition              *time.Time `json:"last_transition,omitempty"`
+	UpSince                     *time.Time `json:"up_since,omitempty"`
+	Addresses                   []net.IP   `json:"addresses"`
+	Tracks                      []Track    `json:"tracks,omitempty"`
+	SyncGroup                   string     `json:"sync_group,omitempty"`
+}
+
+// Update is the body of PATCH /routers/{vrid}, the fields left out are not changed
+type Update struct {
+	// Priority must be in [1, 254], the priority of the owner can't be changed
+	Priority *int  `json:"priority,omitempty"`
+	Preempt  *bool `json:"preempt,omitempty"`
+}
+
+// Address is the body of POST /routers/{vrid}/addresses
+type Address struct {
+	Address net.IP `json:"address"`
+}
+
+// Event is a state transition streamed by /events
+type Event struct {
+	VRID       byte   `json:"vrid"`
+	Family     string `json:"family"`
+	Interface  string `json:"interface,omitempty"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Transition string `json:"transition"`
+	Reason     string `json:"reason"`
+	// MasterIP is the master after the transition, it's left out if the master is unknown
+	MasterIP net.IP    `json:"master_ip,omitempty"`
+	Priority byte      `json:"priority"`
+	Time     time.Time `json:"time"`
+}
+
+// Error is the body of the responses to the failed requests
+type Error struct {
+	Error string `json:"error"`
+}
+
+// familyOf return the name of the address family of vr
+func familyOf(vr *vrrp.VirtualRouter) string {
+	if vr.IPvX() == vrrp.IPv6 {
+		return "ipv6"
+	}
+	return "ipv4"
+}
+
+// interfaceOf return the name of the interface of vr, it's empty if vr has none
+func interfaceOf(vr *vrrp.VirtualRouter) string {
+	if nif := vr.NetInterface(); nif != nil {
+		return nif.Name
+	}
+	return ""
+}
+
+// routerOf take the status of vr
+func routerOf(vr *vrrp.VirtualRouter) Router {
+	var status = vr.Status()
+	var router = Router{
+		VRID:                        status.VRID,
+		Family:                      familyOf(vr),
+		Interface:                   interfaceOf(vr),
+		State:                       status.State.String(),
+		Priority:                    status.Priority,
+		BasePriority:                status.BasePriority,
+		Preempt:                     status.Preempt,
+		AcceptMode:                  status.AcceptMode,
+		SourceIP:                    status.SourceIP,
+		MasterIP:                    status.MasterIP,
+		MasterPriority:              status.MasterPriority,
+		AdvertisementInterval:       Duration(status.AdvertisementInterval),
+		MasterAdvertisementInterval: Duration(status.MasterAdvertisementInterval),
+		SkewTime:                    Duration(status.SkewTime),
+		MasterDownInterval:          Duration(status.MasterDownInterval),
+		LastTransition:              timeOrNil(status.LastTransition),
+		UpSince:                     timeOrNil(status.UpSince),
+		Addresses:                   status.Addresses,
+		SyncGroup:                   status.SyncGroup,
+	}
+	if router.Addresses == nil {
+		router.Addresses = []net.IP{}
+	}
+	for _, track := range status.Tracks {
+		router.Tracks = append(router.Tracks, Track{Name: track.Name, Weight: track.Weight, Healthy: track.Healthy})
+	}
+	return router
+}
+
+// eventOf convert the transition event of vr
+func eventOf(vr *vrrp.VirtualRouter, event vrrp.TransitionEvent) Event {
+	return Event{
+		VRID:       event.VRID,
+		Family:     familyOf(vr),
+		Interface:  interfaceOf(vr),
+		From:       event.From.String(),
+		To:         event.To.String(),
+		Transition: event.Transition.String(),
+		Reason:     event.Reason.String(),
+		MasterIP:   event.MasterIP,
+		Priority:   event.Priority,
+		Time:       event.Time,
+	}
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
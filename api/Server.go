@@ -0,0 +1,374 @@
+// Package api serves an HTTP/JSON API to query and reconfigure virtual routers at runtime.
+//
+//	GET    /routers                          list the virtual routers
+//	GET    /routers/{vrid}                   status of a virtual router
+//	PATCH  /routers/{vrid}                   change the priority or the preempt mode, see Update
+//	POST   /routers/{vrid}/addresses         protect one more address, see Address
+//	DELETE /routers/{vrid}/addresses/{ip}    stop protecting an address
+//	POST   /routers/{vrid}/handoff           resign as MASTER and become BACKUP
+//	POST   /routers/{vrid}/stop              shut the virtual router down, see Handler
+//	GET    /events                           stream the state transitions as Server-Sent Events
+//
+// The same VRID may be used by an IPv4 and an IPv6 virtual router or on several interfaces,
+// the query parameters family (ipv4 or ipv6) and interface select one of them then.
+// /events takes the same parameters and vrid to stream the transitions of some virtual routers only.
+//
+// A virtual router handed off doesn't preempt the master which takes over, even with a higher priority,
+// preemption applies again once that master resigns or times out, or another master is seen.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"vrrp-go/logger"
+	"vrrp-go/vrrp"
+)
+
+// keepAliveInterval is the interval of the comments sent on idle event streams,
+// so that proxies don't close them
+const keepAliveInterval = 30 * time.Second
+
+// errors answered with the status code they are mapped to by statusOf
+var (
+	errNotFound   = errors.New("not found")
+	errAmbiguous  = errors.New("ambiguous virtual router")
+	errBadRequest = errors.New("bad request")
+	errConflict   = errors.New("conflict")
+)
+
+// server handles the requests for the virtual routers listed by source
+type server struct {
+	source vrrp.RouterSource
+}
+
+// stopper is implemented by the RouterSource running the virtual routers it lists, like vrrp.Manager
+type stopper interface {
+	Stop(vr *vrrp.VirtualRouter) error
+}
+
+// Handler serve the API for the virtual routers listed by source. A virtual router is stopped through
+// source if it has a Stop(*vrrp.VirtualRouter) error method like vrrp.Manager, the stop is answered
+// once it completes then. Otherwise the stop is only accepted while the virtual router runs.
+func Handler(source vrrp.RouterSource) http.Handler {
+	var s = &server{source: source}
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/routers", s.handleRouters)
+	mux.HandleFunc("/routers/", s.handleRouter)
+	mux.HandleFunc("/events", s.handleEvents)
+	return mux
+}
+
+// NewServer create the HTTP server serving the API for the virtual routers listed by source at addr
+func NewServer(addr string, source vrrp.RouterSource) *http.Server {
+	return &http.Server{Addr: addr, Handler: Handler(source)}
+}
+
+// handleRouters list the virtual routers
+func (s *server) handleRouters(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	var routers = []Router{}
+	for _, vr := range s.source.Routers() {
+		routers = append(routers, routerOf(vr))
+	}
+	writeJSON(w, http.StatusOK, routers)
+}
+
+// handleRouter dispatch the requests for one virtual router
+func (s *server) handleRouter(w http.ResponseWriter, req *http.Request) {
+	var parts = strings.Split(strings.TrimPrefix(req.URL.Path, "/routers/"), "/")
+	var vr, errOfFind = s.find(parts[0], req)
+	if errOfFind != nil {
+		writeError(w, errOfFind)
+		return
+	}
+	switch {
+	case len(parts) == 1:
+		switch req.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, routerOf(vr))
+		case http.MethodPatch:
+			s.update(w, req, vr)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPatch)
+		}
+	case len(parts) == 2 && parts[1] == "addresses":
+		if req.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		s.addAddress(w, req, vr)
+	case len(parts) == 3 && parts[1] == "addresses":
+		if req.Method != http.MethodDelete {
+			methodNotAllowed(w, http.MethodDelete)
+			return
+		}
+		s.removeAddress(w, parts[2], vr)
+	case len(parts) == 2 && parts[1] == "handoff":
+		if req.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		if errOfHandoff := vr.Handoff(); errOfHandoff != nil {
+			writeError(w, fmt.Errorf("%w: %v", errConflict, errOfHandoff))
+			return
+		}
+		logger.GLoger.Printf(logger.INFO, "virtual router %v handed off by %v", vr.VRID(), req.RemoteAddr)
+		writeJSON(w, http.StatusOK, routerOf(vr))
+	case len(parts) == 2 && parts[1] == "stop":
+		if req.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		s.stop(w, req, vr)
+	default:
+		writeError(w, fmt.Errorf("%w: %v", errNotFound, req.URL.Path))
+	}
+}
+
+// stop shut vr down through the source running it if possible
+func (s *server) stop(w http.ResponseWriter, req *http.Request, vr *vrrp.VirtualRouter) {
+	if owner, ok := s.source.(stopper); ok {
+		if errOfStop := owner.Stop(vr); errOfStop != nil {
+			writeError(w, fmt.Errorf("%w: %v", errConflict, errOfStop))
+			return
+		}
+		logger.GLoger.Printf(logger.INFO, "virtual router %v stopped by %v", vr.VRID(), req.RemoteAddr)
+		writeJSON(w, http.StatusOK, routerOf(vr))
+		return
+	}
+	//a Stop of a virtual router which doesn't run does nothing
+	if vr.Status().State == vrrp.INIT {
+		writeError(w, fmt.Errorf("%w: virtual router %v is not running", errConflict, vr.VRID()))
+		return
+	}
+	logger.GLoger.Printf(logger.INFO, "virtual router %v stopped by %v", vr.VRID(), req.RemoteAddr)
+	vr.Stop()
+	//the shutdown completes on the event loop
+	writeJSON(w, http.StatusAccepted, routerOf(vr))
+}
+
+// update apply the changes of the body to vr
+func (s *server) update(w http.ResponseWriter, req *http.Request, vr *vrrp.VirtualRouter) {
+	var update Update
+	if errOfDecode := decodeJSON(req, &update); errOfDecode != nil {
+		writeError(w, errOfDecode)
+		return
+	}
+	if update.Priority != nil {
+		if *update.Priority < 1 || *update.Priority > 254 {
+			writeError(w, fmt.Errorf("%w: priority %v is not in [1, 254]", errBadRequest, *update.Priority))
+			return
+		}
+		if vr.Status().BasePriority == 255 {
+			writeError(w, fmt.Errorf("%w: the priority of the address owner can't be changed", errConflict))
+			return
+		}
+	}
+	if update.Priority != nil {
+		vr.SetPriority(byte(*update.Priority))
+	}
+	if update.Preempt != nil {
+		vr.SetPreemptMode(*update.Preempt)
+	}
+	logger.GLoger.Printf(logger.INFO, "virtual router %v updated by %v", vr.VRID(), req.RemoteAddr)
+	writeJSON(w, http.StatusOK, routerOf(vr))
+}
+
+// addAddress protect the address of the body with vr
+func (s *server) addAddress(w http.ResponseWriter, req *http.Request, vr *vrrp.VirtualRouter) {
+	var address Address
+	if errOfDecode := decodeJSON(req, &address); errOfDecode != nil {
+		writeError(w, errOfDecode)
+		return
+	}
+	if errOfCheck := checkFamily(address.Address, vr); errOfCheck != nil {
+		writeError(w, errOfCheck)
+		return
+	}
+	if protects(vr, address.Address) {
+		writeError(w, fmt.Errorf("%w: %v is protected already", errConflict, address.Address))
+		return
+	}
+	vr.AddIPvXAddr(address.Address)
+	logger.GLoger.Printf(logger.INFO, "address %v added to virtual router %v by %v", address.Address, vr.VRID(), req.RemoteAddr)
+	writeJSON(w, http.StatusCreated, routerOf(vr))
+}
+
+// removeAddress stop protecting the address of the path with vr
+func (s *server) removeAddress(w http.ResponseWriter, addr string, vr *vrrp.VirtualRouter) {
+	var ip = net.ParseIP(addr)
+	if errOfCheck := checkFamily(ip, vr); errOfCheck != nil {
+		writeError(w, errOfCheck)
+		return
+	}
+	if !protects(vr, ip) {
+		writeError(w, fmt.Errorf("%w: %v is not protected", errNotFound, addr))
+		return
+	}
+	vr.RemoveIPvXAddr(ip)
+	logger.GLoger.Printf(logger.INFO, "address %v removed from virtual router %v", ip, vr.VRID())
+	writeJSON(w, http.StatusOK, routerOf(vr))
+}
+
+// handleEvents stream the transitions of the selected virtual routers until the client goes away
+func (s *server) handleEvents(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	var flusher, ok = w.(http.Flusher)
+	if !ok {
+		writeError(w, errors.New("streaming not supported"))
+		return
+	}
+	var routers, errOfSelect = s.selectRouters(req.URL.Query().Get("vrid"), req)
+	if errOfSelect != nil {
+		writeError(w, errOfSelect)
+		return
+	}
+	//the subscriptions are taken before the headers are sent, so no event is missed once the stream is open
+	var events = make(chan Event)
+	for _, vr := range routers {
+		var vr, subscription = vr, vr.Subscribe(0, vrrp.DropOldest)
+		defer subscription.Close()
+		go func() {
+			for event := range subscription.C {
+				select {
+				case events <- eventOf(vr, event):
+				case <-req.Context().Done():
+					return
+				}
+			}
+		}()
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	var keepAlive = time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event := <-events:
+			var data, _ = json.Marshal(event)
+			fmt.Fprintf(w, "event: transition\ndata: %s\n\n", data)
+		}
+		flusher.Flush()
+	}
+}
+
+// find return the virtual router of VRID selected by the query of req
+func (s *server) find(VRID string, req *http.Request) (*vrrp.VirtualRouter, error) {
+	var routers, errOfSelect = s.selectRouters(VRID, req)
+	if errOfSelect != nil {
+		return nil, errOfSelect
+	}
+	switch len(routers) {
+	case 0:
+		return nil, fmt.Errorf("%w: virtual router %v", errNotFound, VRID)
+	case 1:
+		return routers[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %v virtual routers of VRID %v, select one with family and interface", errAmbiguous, len(routers), VRID)
+	}
+}
+
+// selectRouters return the virtual routers matching VRID and the family and interface of the query,
+// an empty VRID matches all
+func (s *server) selectRouters(VRID string, req *http.Request) ([]*vrrp.VirtualRouter, error) {
+	var id = -1
+	if VRID != "" {
+		var value, errOfParse = strconv.ParseUint(VRID, 10, 8)
+		if errOfParse != nil || value == 0 {
+			return nil, fmt.Errorf("%w: invalid VRID %q", errBadRequest, VRID)
+		}
+		id = int(value)
+	}
+	var query = req.URL.Query()
+	var family, nif = query.Get("family"), query.Get("interface")
+	if family != "" && family != "ipv4" && family != "ipv6" {
+		return nil, fmt.Errorf("%w: invalid family %q", errBadRequest, family)
+	}
+	var routers []*vrrp.VirtualRouter
+	for _, vr := range s.source.Routers() {
+		if (id < 0 || int(vr.VRID()) == id) && (family == "" || familyOf(vr) == family) && (nif == "" || interfaceOf(vr) == nif) {
+			routers = append(routers, vr)
+		}
+	}
+	return routers, nil
+}
+
+// checkFamily make sure ip is an address of the family of vr
+func checkFamily(ip net.IP, vr *vrrp.VirtualRouter) error {
+	if ip == nil || ip.IsUnspecified() {
+		return fmt.Errorf("%w: invalid address", errBadRequest)
+	}
+	if (ip.To4() != nil) != (vr.IPvX() == vrrp.IPv4) {
+		return fmt.Errorf("%w: %v is not an address of %v", errBadRequest, ip, familyOf(vr))
+	}
+	return nil
+}
+
+// protects report whether ip is protected by vr
+func protects(vr *vrrp.VirtualRouter, ip net.IP) bool {
+	for _, protected := range vr.ProtectedIPaddrs() {
+		if protected.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeJSON decode the body of req into v, unknown fields are rejected
+func decodeJSON(req *http.Request, v interface{}) error {
+	var decoder = json.NewDecoder(http.MaxBytesReader(nil, req.Body, 1<<16))
+	decoder.DisallowUnknownFields()
+	if errOfDecode := decoder.Decode(v); errOfDecode != nil {
+		return fmt.Errorf("%w: %v", errBadRequest, errOfDecode)
+	}
+	return nil
+}
+
+// statusOf map err to the status code of the response
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, errNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, errAmbiguous), errors.Is(err, errConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusOf(err), Error{Error: err.Error()})
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, Error{Error: "method not allowed"})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if errOfEncode := json.NewEncoder(w).Encode(v); errOfEncode != nil {
+		logger.GLoger.Printf(logger.ERROR, "api.writeJSON: %v", errOfEncode)
+	}
+}
@@ -0,0 +1,161 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"vrrp-go/api"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+	"vrrp-go/vrrptest"
+)
+
+// call send the request and decode the response into v, the status code is returned
+func call(t *testing.T, server *httptest.Server, method, path, body string, v interface{}) int {
+	t.Helper()
+	var request, err = http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response *http.Response
+	if response, err = server.Client().Do(request); err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if v != nil {
+		if err = json.NewDecoder(response.Body).Decode(v); err != nil {
+			t.Fatalf("%v %v: %v", method, path, err)
+		}
+	}
+	return response.StatusCode
+}
+
+func TestAPI(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = vrrptest.StartRouter(t, seg, 1, "10.0.0.2", 200)
+	var backup = vrrptest.StartRouter(t, seg, 1, "10.0.0.1", 100)
+	var other = vrrptest.StartRouter(t, seg, 2, "10.0.0.3", 100)
+	vrrptest.AwaitState(t, master, vrrp.MASTER)
+	vrrptest.AwaitState(t, backup, vrrp.BACKUP)
+	var server = httptest.NewServer(api.NewServer("", vrrp.RouterList{master, other}).Handler)
+	defer server.Close()
+
+	var routers []api.Router
+	if code := call(t, server, http.MethodGet, "/routers", "", &routers); code != http.StatusOK || len(routers) != 2 {
+		t.Fatalf("GET /routers = %v %+v", code, routers)
+	}
+	var router api.Router
+	if code := call(t, server, http.MethodGet, "/routers/1?family=ipv4", "", &router); code != http.StatusOK {
+		t.Fatalf("GET /routers/1 = %v", code)
+	}
+	if router.State != "MASTER" || router.Priority != 200 || !router.MasterIP.Equal(net.ParseIP("10.0.0.2")) ||
+		router.AdvertisementInterval != api.Duration(100*time.Millisecond) || len(router.Addresses) != 1 || router.UpSince == nil {
+		t.Fatalf("GET /routers/1 = %+v", router)
+	}
+	var failure api.Error
+	for path, want := range map[string]int{"/routers/3": http.StatusNotFound, "/routers/x": http.StatusBadRequest,
+		"/routers/1?family=ipv6": http.StatusNotFound, "/routers/1/unknown": http.StatusNotFound} {
+		if code := call(t, server, http.MethodGet, path, "", &failure); code != want || failure.Error == "" {
+			t.Fatalf("GET %v = %v %+v", path, code, failure)
+		}
+	}
+
+	if code := call(t, server, http.MethodPatch, "/routers/2", `{"priority": 150, "preempt": false}`, &router); code != http.StatusOK {
+		t.Fatalf("PATCH /routers/2 = %v", code)
+	}
+	if router.BasePriority != 150 || router.Preempt {
+		t.Fatalf("PATCH /routers/2 = %+v", router)
+	}
+	for _, body := range []string{`{"priority": 255}`, `{"priority": "high"}`, `{"weight": 1}`} {
+		if code := call(t, server, http.MethodPatch, "/routers/2", body, &failure); code != http.StatusBadRequest {
+			t.Fatalf("PATCH /routers/2 with %v = %v", body, code)
+		}
+	}
+
+	if code := call(t, server, http.MethodPost, "/routers/2/addresses", `{"address": "192.168.1.1"}`, &router); code != http.StatusCreated || len(router.Addresses) != 2 {
+		t.Fatalf("POST /routers/2/addresses = %v %+v", code, router)
+	}
+	if code := call(t, server, http.MethodPost, "/routers/2/addresses", `{"address": "192.168.1.1"}`, &failure); code != http.StatusConflict {
+		t.Fatalf("POST of a protected address = %v", code)
+	}
+	if code := call(t, server, http.MethodPost, "/routers/2/addresses", `{"address": "fe80::1"}`, &failure); code != http.StatusBadRequest {
+		t.Fatalf("POST of an IPv6 address = %v", code)
+	}
+	if code := call(t, server, http.MethodDelete, "/routers/2/addresses/192.168.1.254", "", &router); code != http.StatusOK ||
+		len(router.Addresses) != 1 || !router.Addresses[0].Equal(net.ParseIP("192.168.1.1")) {
+		t.Fatalf("DELETE /routers/2/addresses = %v %+v", code, router)
+	}
+	if code := call(t, server, http.MethodDelete, "/routers/2/addresses/192.168.1.254", "", &failure); code != http.StatusNotFound {
+		t.Fatalf("DELETE of an unprotected address = %v", code)
+	}
+	if code := call(t, server, http.MethodPut, "/routers/2", "", &failure); code != http.StatusMethodNotAllowed {
+		t.Fatalf("PUT /routers/2 = %v", code)
+	}
+
+	//the events of the handoff are streamed
+	var request, _ = http.NewRequest(http.MethodGet, server.URL+"/events?vrid=1", nil)
+	var response, err = server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("content type of /events = %v", contentType)
+	}
+	if code := call(t, server, http.MethodPost, "/routers/1/handoff", "", &router); code != http.StatusOK || router.State != "BACKUP" {
+		t.Fatalf("POST /routers/1/handoff = %v %+v", code, router)
+	}
+	var stream = bufio.NewScanner(response.Body)
+	var event api.Event
+	for stream.Scan() {
+		if data, ok := strings.CutPrefix(stream.Text(), "data: "); ok {
+			if err = json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+	if event.VRID != 1 || event.From != "MASTER" || event.To != "BACKUP" || event.Reason != "handoff" || event.Family != "ipv4" {
+		t.Fatalf("event = %+v", event)
+	}
+	vrrptest.AwaitState(t, backup, vrrp.MASTER)
+	if code := call(t, server, http.MethodPost, "/routers/1/handoff", "", &failure); code != http.StatusConflict {
+		t.Fatalf("handoff of a backup = %v", code)
+	}
+
+	if code := call(t, server, http.MethodPost, "/routers/2/stop", "", &router); code != http.StatusAccepted {
+		t.Fatalf("POST /routers/2/stop = %v", code)
+	}
+	vrrptest.AwaitState(t, other, vrrp.INIT)
+	if code := call(t, server, http.MethodPost, "/routers/2/stop", "", &failure); code != http.StatusConflict {
+		t.Fatalf("POST /routers/2/stop of a stopped virtual router = %v", code)
+	}
+}
+
+func TestStopThroughManager(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var manager = vrrp.NewManager(vrrptest.Transport(seg))
+	defer manager.Close()
+	var vr, err = manager.Add(&vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.0.0.1"), AdvertisementInterval: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vrrptest.AwaitState(t, vr, vrrp.MASTER)
+	var server = httptest.NewServer(api.Handler(manager))
+	defer server.Close()
+	var router api.Router
+	if code := call(t, server, http.MethodPost, "/routers/1/stop", "", &router); code != http.StatusOK || router.State != "INIT" {
+		t.Fatalf("POST /routers/1/stop = %v %+v", code, router)
+	}
+	//the manager starts the virtual router again
+	if err = manager.Start(vr); err != nil {
+		t.Fatal(err)
+	}
+	vrrptest.AwaitState(t, vr, vrrp.MASTER)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+	"vrrp-go/vrrp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of the configuration file of vrrpd
+type Config struct {
+	// LogLevel is one of debug, info, error, info is used if empty
+	LogLevel string         `yaml:"log_level"`
+	Routers  []RouterConfig `yaml:"routers"`
+}
+
+// RouterConfig describes one virtual router, it's identified by its interface, family and VRID
+type RouterConfig struct {
+	Interface string `yaml:"interface"`
+	VRID      int    `yaml:"vrid"`
+	// Family is ipv4 or ipv6, ipv4 is used if empty
+	Family string `yaml:"family"`
+	// Priority is in [1, 254], 100 is used if zero, it's ignored for the owner
+	Priority int  `yaml:"priority"`
+	Owner    bool `yaml:"owner"`
+	// Version is 2 or 3, VRRPv3 is used if zero
+	Version int `yaml:"version"`
+	// AdvertisementInterval is 1 second if zero
+	AdvertisementInterval time.Duration `yaml:"advertisement_interval"`
+	// Preempt is true if unset
+	Preempt      *bool         `yaml:"preempt"`
+	PreemptDelay time.Duration `yaml:"preempt_delay"`
+	StartupDelay time.Duration `yaml:"startup_delay"`
+	AcceptMode   bool          `yaml:"accept_mode"`
+	VirtualMAC   bool          `yaml:"virtual_mac"`
+	// SourceIP is the source address of the advertisements, it's found on the interface if empty
+	SourceIP string `yaml:"source_ip"`
+	// Peers switches the virtual router to unicast mode
+	Peers     []string `yaml:"peers"`
+	Addresses []string `yaml:"addresses"`
+	// Install configures the addresses on the interface while the virtual router is MASTER,
+	// they are only announced otherwise
+	Install      bool          `yaml:"install"`
+	PrefixLength int           `yaml:"prefix_length"`
+	Tracks       []TrackConfig `yaml:"tracks"`
+	Notify       NotifyConfig  `yaml:"notify"`
+}
+
+// TrackConfig describes a track, exactly one of the trackers must be set
+type TrackConfig struct {
+	// Name is the kind of the tracker followed by its target if empty
+	Name     string        `yaml:"name"`
+	Weight   int           `yaml:"weight"`
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+	// Link is the interface which must be up
+	Link string `yaml:"link"`
+	// Address is the address which must be configured on Interface
+	Address   string `yaml:"address"`
+	Interface string `yaml:"interface"`
+	// Route is the destination prefix which must be routed
+	Route string `yaml:"route"`
+	// TCP is the host:port which must accept connections
+	TCP string `yaml:"tcp"`
+	// HTTP is the URL which must answer with a 2xx status
+	HTTP string `yaml:"http"`
+	// Script must exit with 0
+	Script Command `yaml:"script"`
+}
+
+// NotifyConfig is the commands run when the virtual router enters a state, Any is run on every transition
+// after the command of the new state. The transition is described by the VRRP_* environment variables.
+type NotifyConfig struct {
+	Master Command `yaml:"master"`
+	Backup Command `yaml:"backup"`
+	Fault  Command `yaml:"fault"`
+	Init   Command `yaml:"init"`
+	Any    Command `yaml:"any"`
+	// Timeout of one command, 30 seconds is used if zero
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Command is a path followed by its arguments, it's written as a single path or as a list
+type Command []string
+
+// UnmarshalYAML accept a path or a list made of the path and the arguments
+func (c *Command) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*c = Command{node.Value}
+		return nil
+	}
+	var command []string
+	if errOfDecode := node.Decode(&command); errOfDecode != nil {
+		return errOfDecode
+	}
+	*c = command
+	return nil
+}
+
+// routerKey identifies a virtual router like the transports shared by vrrp.Manager
+type routerKey struct {
+	nif  string
+	ipvX byte
+	VRID byte
+}
+
+func (k routerKey) String() string {
+	return fmt.Sprintf("%v/%v/%v", k.nif, familyName(k.ipvX), k.VRID)
+}
+
+func familyName(IPvX byte) string {
+	if IPvX == vrrp.IPv6 {
+		return "ipv6"
+	}
+	return "ipv4"
+}
+
+// LoadConfig read and validate the configuration file at path
+func LoadConfig(path string) (*Config, error) {
+	var content, errOfRead = os.ReadFile(path)
+	if errOfRead != nil {
+		return nil, errOfRead
+	}
+	var cfg, errOfParse = ParseConfig(content)
+	if errOfParse != nil {
+		return nil, fmt.Errorf("%v: %w", path, errOfParse)
+	}
+	return cfg, nil
+}
+
+// ParseConfig decode a YAML configuration and validate it, unknown fields are rejected
+// and all the problems found are reported at once
+func ParseConfig(content []byte) (*Config, error) {
+	var decoder = yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	var cfg Config
+	if errOfDecode := decoder.Decode(&cfg); errOfDecode != nil && !errors.Is(errOfDecode, io.EOF) {
+		return nil, errOfDecode
+	}
+	if errOfValidate := cfg.Validate(); errOfValidate != nil {
+		return nil, errOfValidate
+	}
+	return &cfg, nil
+}
+
+// Validate check the configuration, every problem is reported on a line of the returned error
+func (cfg *Config) Validate() error {
+	var problems []error
+	switch cfg.LogLevel {
+	case "", "debug", "info", "error":
+	default:
+		problems = append(problems, fmt.Errorf("log_level %q must be debug, info or error", cfg.LogLevel))
+	}
+	if len(cfg.Routers) == 0 {
+		problems = append(problems, errors.New("no virtual router is configured"))
+	}
+	var keys = make(map[routerKey]int)
+	var sources = make(map[routerKey]string)
+	for i := range cfg.Routers {
+		var router = &cfg.Routers[i]
+		var errOfRouter = router.validate()
+		if errOfRouter == nil {
+			var key = router.key()
+			if first, ok := keys[key]; ok {
+				errOfRouter = fmt.Errorf("VRID %v is already used by routers[%v] on %v", key.VRID, first, key.nif)
+			}
+			keys[key] = i
+			//the virtual routers of a family on an interface share the source address
+			var shared = routerKey{nif: key.nif, ipvX: key.ipvX}
+			if source, ok := sources[shared]; ok && source != router.SourceIP && errOfRouter == nil {
+				errOfRouter = fmt.Errorf("source_ip %q differs from %q used by another virtual router on %v",
+					router.SourceIP, source, key.nif)
+			}
+			sources[shared] = router.SourceIP
+		}
+		if errOfRouter != nil {
+			problems = append(problems, fmt.Errorf("routers[%v]: %w", i, errOfRouter))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// ipvX return the address family of the virtual router, validate must have succeeded
+func (router *RouterConfig) ipvX() byte {
+	if router.Family == "ipv6" {
+		return vrrp.IPv6
+	}
+	return vrrp.IPv4
+}
+
+func (router *RouterConfig) key() routerKey {
+	return routerKey{nif: router.Interface, ipvX: router.ipvX(), VRID: byte(router.VRID)}
+}
+
+// validate check the fields of one virtual router
+func (router *RouterConfig) validate() error {
+	var problems []string
+	var fail = func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+	if router.Interface == "" {
+		fail("interface is missing")
+	}
+	if router.VRID < 1 || router.VRID > 255 {
+		fail("vrid %v is out of [1, 255]", router.VRID)
+	}
+	switch router.Family {
+	case "", "ipv4", "ipv6":
+	default:
+		fail("family %q must be ipv4 or ipv6", router.Family)
+	}
+	if router.Priority != 0 && (router.Priority < 1 || router.Priority > 254) {
+		fail("priority %v is out of [1, 254]", router.Priority)
+	}
+	switch router.Version {
+	case 0, 3:
+	case 2:
+		if router.ipvX() != vrrp.IPv4 {
+			fail("version 2 only works with ipv4")
+		}
+	default:
+		fail("version %v must be 2 or 3", router.Version)
+	}
+	//VRRPv2 carries the advertisement interval in whole seconds
+	switch interval := router.AdvertisementInterval; {
+	case interval == 0:
+	case router.Version == 2:
+		if interval < time.Second || interval > 255*time.Second || interval%time.Second != 0 {
+			fail("advertisement_interval %v must be whole seconds in [1s, 255s] with version 2", interval)
+		}
+	case interval < 10*time.Millisecond || interval > 40950*time.Millisecond:
+		fail("advertisement_interval %v is out of [10ms, 40.95s]", interval)
+	}
+	if router.PreemptDelay < 0 {
+		fail("preempt_delay %v is negative", router.PreemptDelay)
+	}
+	if router.StartupDelay < 0 {
+		fail("startup_delay %v is negative", router.StartupDelay)
+	}
+	if len(router.Addresses) == 0 {
+		fail("addresses are missing")
+	}
+	for _, address := range router.Addresses {
+		if errOfParse := router.checkAddress(address); errOfParse != nil {
+			fail("address %v", errOfParse)
+		}
+	}
+	if router.SourceIP != "" {
+		if errOfParse := router.checkAddress(router.SourceIP); errOfParse != nil {
+			fail("source_ip %v", errOfParse)
+		}
+	}
+	for _, peer := range router.Peers {
+		if errOfParse := router.checkAddress(peer); errOfParse != nil {
+			fail("peer %v", errOfParse)
+		}
+	}
+	var bits = 32
+	if router.ipvX() == vrrp.IPv6 {
+		bits = 128
+	}
+	if router.PrefixLength < 0 || router.PrefixLength > bits {
+		fail("prefix_length %v is out of [0, %v]", router.PrefixLength, bits)
+	}
+	for i := range router.Tracks {
+		if errOfTrack := router.Tracks[i].validate(); errOfTrack != nil {
+			fail("tracks[%v]: %v", i, errOfTrack)
+		}
+	}
+	if errOfNotify := router.Notify.validate(); errOfNotify != nil {
+		fail("notify: %v", errOfNotify)
+	}
+	if len(problems) != 0 {
+		return errors.New(strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// checkAddress check address is an IP address of the family of the virtual router
+func (router *RouterConfig) checkAddress(address string) error {
+	var ip = net.ParseIP(address)
+	if ip == nil {
+		return fmt.Errorf("%q is not an IP address", address)
+	}
+	if (ip.To4() != nil) != (router.ipvX() == vrrp.IPv4) {
+		return fmt.Errorf("%v is not an %v address", address, familyName(router.ipvX()))
+	}
+	return nil
+}
+
+// validate check exactly one tracker is set
+func (track *TrackConfig) validate() error {
+	var kinds = 0
+	for _, set := range []bool{track.Link != "", track.Address != "", track.Route != "", track.TCP != "", track.HTTP != "", len(track.Script) != 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return errors.New("exactly one of link, address, route, tcp, http and script must be set")
+	}
+	if track.Interval < 0 || track.Timeout < 0 {
+		return errors.New("interval and timeout can't be negative")
+	}
+	switch {
+	case track.Address != "":
+		if net.ParseIP(track.Address) == nil {
+			return fmt.Errorf("address %q is not an IP address", track.Address)
+		}
+		if track.Interface == "" {
+			return errors.New("interface of the address is missing")
+		}
+	case track.Route != "":
+		if _, _, errOfParse := net.ParseCIDR(track.Route); errOfParse != nil {
+			return fmt.Errorf("route %q is not a prefix", track.Route)
+		}
+	case track.TCP != "":
+		if _, _, errOfSplit := net.SplitHostPort(track.TCP); errOfSplit != nil {
+			return fmt.Errorf("tcp %q is not a host:port", track.TCP)
+		}
+	case len(track.Script) != 0 && track.Script[0] == "":
+		return errors.New("path of the script is empty")
+	}
+	if track.Interface != "" && track.Address == "" {
+		return errors.New("interface is only used with address")
+	}
+	return nil
+}
+
+// validate check the commands have a path
+func (notify *NotifyConfig) validate() error {
+	for i, command := range []Command{notify.Master, notify.Backup, notify.Fault, notify.Init, notify.Any} {
+		if command != nil && (len(command) == 0 || command[0] == "") {
+			return fmt.Errorf("path of the %v command is empty", [...]string{"master", "backup", "fault", "init", "any"}[i])
+		}
+	}
+	if notify.Timeout < 0 {
+		return fmt.Errorf("timeout %v is negative", notify.Timeout)
+	}
+	return nil
+}
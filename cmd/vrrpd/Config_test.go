@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const exampleConfig = `
+log_level: debug
+routers:
+  - interface: eth0
+    vrid: 51
+    priority: 150
+    advertisement_interval: 500ms
+    preempt: false
+    addresses: [192.168.1.254, 192.168.1.253]
+    install: true
+    prefix_length: 24
+    tracks:
+      - link: eth1
+        weight: -60
+      - name: web
+        http: http://127.0.0.1/health
+        interval: 2s
+    notify:
+      master: /etc/vrrpd/master.sh
+      any: [/usr/bin/logger, -t, vrrpd]
+  - interface: eth0
+    vrid: 51
+    family: ipv6
+    addresses: ["2001:db8::1"]
+`
+
+func TestParseConfig(t *testing.T) {
+	var cfg, err = ParseConfig([]byte(exampleConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != "debug" || len(cfg.Routers) != 2 {
+		t.Fatalf("config = %+v", cfg)
+	}
+	var router = cfg.Routers[0]
+	if router.key() != (routerKey{nif: "eth0", ipvX: 4, VRID: 51}) || router.AdvertisementInterval != 500*time.Millisecond ||
+		preemptOf(&router) || len(router.Addresses) != 2 || len(router.Tracks) != 2 {
+		t.Fatalf("router = %+v", router)
+	}
+	if !reflect.DeepEqual(router.Notify.Master, Command{"/etc/vrrpd/master.sh"}) ||
+		!reflect.DeepEqual(router.Notify.Any, Command{"/usr/bin/logger", "-t", "vrrpd"}) || router.Notify.Backup != nil {
+		t.Fatalf("notify = %+v", router.Notify)
+	}
+	var vrrpConfig, errOfConfig = configOf(&router)
+	if errOfConfig != nil {
+		t.Fatal(errOfConfig)
+	}
+	if vrrpConfig.Priority != 150 || !vrrpConfig.NoPreempt || vrrpConfig.Tracks[0].Name != "link eth1" || vrrpConfig.Tracks[1].Name != "web" {
+		t.Fatalf("vrrp config = %+v", vrrpConfig)
+	}
+	//the defaults apply to the second virtual router
+	router = cfg.Routers[1]
+	if vrrpConfig, errOfConfig = configOf(&router); errOfConfig != nil {
+		t.Fatal(errOfConfig)
+	}
+	if vrrpConfig.IPvX != 6 || vrrpConfig.Priority != 100 || vrrpConfig.AdvertisementInterval != time.Second || vrrpConfig.NoPreempt {
+		t.Fatalf("vrrp config = %+v", vrrpConfig)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for content, want := range map[string][]string{
+		``:                                      {"no virtual router is configured"},
+		`log_level: verbose`:                    {`log_level "verbose" must be debug, info or error`},
+		`routers: [{interface: eth0, vird: 1}]`: {"field vird not found"},
+		`routers: [{interface: eth0, vrid: 1, advertisement_interval: 100}]`: {"line 1: cannot unmarshal !!int", "into time.Duration"},
+		`routers: [{vrid: 0, priority: 255, family: ipx, addresses: [10.0.0.1]}]`: {"routers[0]: interface is missing",
+			"vrid 0 is out of [1, 255]", "priority 255 is out of [1, 254]", `family "ipx" must be ipv4 or ipv6`},
+		`routers: [{interface: eth0, vrid: 1, version: 2, family: ipv6, addresses: ["2001:db8::1"]}]`: {"version 2 only works with ipv4"},
+		`routers: [{interface: eth0, vrid: 1, version: 2, advertisement_interval: 1500ms, addresses: [10.0.0.1]}]`: {
+			"advertisement_interval 1.5s must be whole seconds in [1s, 255s] with version 2"},
+		`routers: [{interface: eth0, vrid: 1, version: 2, advertisement_interval: 256s, addresses: [10.0.0.1]}]`: {
+			"advertisement_interval 4m16s must be whole seconds in [1s, 255s] with version 2"},
+		`routers: [{interface: eth0, vrid: 1, advertisement_interval: 255s, addresses: [10.0.0.1]}]`: {
+			"advertisement_interval 4m15s is out of [10ms, 40.95s]"},
+		`routers: [{interface: eth0, vrid: 1}]`: {"addresses are missing"},
+		`routers: [{interface: eth0, vrid: 1, addresses: [10.0.0.1, "2001:db8::1", x]}]`: {"2001:db8::1 is not an ipv4 address",
+			`"x" is not an IP address`},
+		`routers: [{interface: eth0, vrid: 1, addresses: [10.0.0.1]}, {interface: eth0, vrid: 1, addresses: [10.0.0.2]}]`: {
+			"routers[1]: VRID 1 is already used by routers[0] on eth0"},
+		`routers: [{interface: eth0, vrid: 1, addresses: [10.0.0.1], source_ip: 10.0.0.2}, {interface: eth0, vrid: 2, addresses: [10.0.0.3]}]`: {
+			`routers[1]: source_ip "" differs from "10.0.0.2"`},
+		`routers: [{interface: eth0, vrid: 1, addresses: [10.0.0.1], tracks: [{link: eth1, tcp: "a:1"}, {route: x}, {}]}]`: {
+			"tracks[0]: exactly one of", `tracks[1]: route "x" is not a prefix`, "tracks[2]: exactly one of"},
+		`routers: [{interface: eth0, vrid: 1, addresses: [10.0.0.1], notify: {master: ""}}]`: {"notify: path of the master command is empty"},
+	} {
+		var _, err = ParseConfig([]byte(content))
+		if err == nil {
+			t.Fatalf("%v is accepted", content)
+		}
+		for _, message := range want {
+			if !strings.Contains(err.Error(), message) {
+				t.Errorf("error of %v = %v, want %v", content, err, message)
+			}
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "vrrpd.yaml")
+	if err := os.WriteFile(path, []byte(`routers: [{interface: eth0, vrid: 300, addresses: [10.0.0.1]}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.HasPrefix(err.Error(), path+": routers[0]: vrid 300") {
+		t.Fatalf("LoadConfig returned %v", err)
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"vrrp-go/logger"
+	"vrrp-go/vrrp"
+)
+
+const defaultNotifyTimeout = 30 * time.Second
+
+// Daemon runs the virtual routers of a configuration with a vrrp.Manager, Apply and Close must be
+// called from a single goroutine
+type Daemon struct {
+	manager   *vrrp.Manager
+	instances map[routerKey]*instance
+}
+
+// instance is a virtual router run by Daemon and the configuration it's running with
+type instance struct {
+	key          routerKey
+	config       RouterConfig
+	vr           *vrrp.VirtualRouter
+	installer    *vrrp.AddressManager
+	cancel       context.CancelFunc
+	subscription *vrrp.Subscription
+	// notify is replaced on reload while the subscription reads it
+	notify atomic.Pointer[NotifyConfig]
+}
+
+// NewDaemon create a Daemon opening transports with factory, raw sockets are used if factory is nil
+func NewDaemon(factory vrrp.TransportFactory) *Daemon {
+	return &Daemon{manager: vrrp.NewManager(factory), instances: make(map[routerKey]*instance)}
+}
+
+// Apply make the running virtual routers match cfg with minimal disruption: the virtual routers left out
+// of cfg are removed, the new ones are started, and the others are reconfigured at runtime. A virtual router
+// is only recreated if a field without a runtime setter changes. Apply goes on after a failure,
+// all the failures are returned.
+func (d *Daemon) Apply(cfg *Config) error {
+	var wanted = make(map[routerKey]*RouterConfig, len(cfg.Routers))
+	for i := range cfg.Routers {
+		wanted[cfg.Routers[i].key()] = &cfg.Routers[i]
+	}
+	var problems []error
+	//remove first, so that a recreated virtual router finds its VRID and its transport free
+	for key, inst := range d.instances {
+		var router, ok = wanted[key]
+		if ok && reconfigurable(&inst.config, router) {
+			continue
+		}
+		if errOfRemove := d.remove(key); errOfRemove != nil {
+			problems = append(problems, errOfRemove)
+		}
+		if ok {
+			logger.GLoger.Printf(logger.INFO, "vrrpd: recreating virtual router %v", key)
+		} else {
+			logger.GLoger.Printf(logger.INFO, "vrrpd: virtual router %v removed", key)
+		}
+	}
+	for i := range cfg.Routers {
+		var router = &cfg.Routers[i]
+		var key = router.key()
+		if inst, ok := d.instances[key]; ok {
+			if errOfUpdate := inst.update(router); errOfUpdate != nil {
+				problems = append(problems, fmt.Errorf("%v: %w", key, errOfUpdate))
+			}
+			continue
+		}
+		if errOfCreate := d.create(router); errOfCreate != nil {
+			problems = append(problems, fmt.Errorf("%v: %w", key, errOfCreate))
+			continue
+		}
+		logger.GLoger.Printf(logger.INFO, "vrrpd: virtual router %v started", key)
+	}
+	return errors.Join(problems...)
+}
+
+// Routers return the running virtual routers
+func (d *Daemon) Routers() []*vrrp.VirtualRouter {
+	return d.manager.Routers()
+}
+
+// Close stop all the virtual routers, a MASTER resigns with an advertisement of priority 0
+func (d *Daemon) Close() error {
+	var problems []error
+	for key := range d.instances {
+		if errOfRemove := d.remove(key); errOfRemove != nil {
+			problems = append(problems, errOfRemove)
+		}
+	}
+	if errOfClose := d.manager.Close(); errOfClose != nil {
+		problems = append(problems, errOfClose)
+	}
+	return errors.Join(problems...)
+}
+
+// create start the virtual router described by router, the notify commands see its first transition
+func (d *Daemon) create(router *RouterConfig) error {
+	var cfg, errOfConfig = configOf(router)
+	if errOfConfig != nil {
+		return errOfConfig
+	}
+	var inst = &instance{key: router.key(), config: *router}
+	if router.Install {
+		var errOfInstaller error
+		inst.installer, errOfInstaller = vrrp.NewAddressManager(router.Interface, vrrp.AddressOptions{PrefixLength: router.PrefixLength})
+		if errOfInstaller != nil {
+			return errOfInstaller
+		}
+		cfg.AddrInstaller = inst.installer
+	}
+	var vr, errOfCreate = d.manager.Create(cfg)
+	if errOfCreate != nil {
+		if inst.installer != nil {
+			inst.installer.Close()
+		}
+		return errOfCreate
+	}
+	inst.vr = vr
+	var notify = router.Notify
+	inst.notify.Store(&notify)
+	inst.subscription = vr.SubscribeFunc(0, vrrp.DropOldest, inst.notifyTransition)
+	if inst.installer != nil {
+		var ctx context.Context
+		ctx, inst.cancel = context.WithCancel(context.Background())
+		go inst.installer.Watch(ctx)
+	}
+	if errOfStart := d.manager.Start(vr); errOfStart != nil {
+		//the virtual router is created again by the next reload
+		if errOfRelease := d.release(inst); errOfRelease != nil {
+			logger.GLoger.Printf(logger.ERROR, "vrrpd: %v: %v", inst.key, errOfRelease)
+		}
+		return errOfStart
+	}
+	d.instances[inst.key] = inst
+	return nil
+}
+
+// remove stop the virtual router of key and release what it uses
+func (d *Daemon) remove(key routerKey) error {
+	var inst = d.instances[key]
+	delete(d.instances, key)
+	if errOfRemove := d.release(inst); errOfRemove != nil {
+		return fmt.Errorf("%v: %w", key, errOfRemove)
+	}
+	return nil
+}
+
+// release remove the virtual router of inst from the manager and release what it uses
+func (d *Daemon) release(inst *instance) error {
+	var errOfRemove = d.manager.Remove(inst.vr)
+	inst.subscription.Close()
+	if inst.installer != nil {
+		inst.cancel()
+		inst.installer.Close()
+	}
+	return errOfRemove
+}
+
+// reconfigurable report whether the virtual router running with old can be turned into router at runtime,
+// only the fields with a runtime setter may differ
+func reconfigurable(old, router *RouterConfig) bool {
+	var a, b = *old, *router
+	for _, c := range []*RouterConfig{&a, &b} {
+		c.Priority, c.Preempt, c.PreemptDelay, c.StartupDelay = 0, nil, 0, 0
+		c.AcceptMode, c.AdvertisementInterval, c.Addresses, c.Notify = false, 0, nil, NotifyConfig{}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// update reconfigure the running virtual router with router, reconfigurable must have returned true
+func (inst *instance) update(router *RouterConfig) error {
+	var old = &inst.config
+	var vr = inst.vr
+	if router.Priority != old.Priority {
+		vr.SetPriority(priorityOf(router))
+	}
+	if preemptOf(router) != preemptOf(old) {
+		vr.SetPreemptMode(preemptOf(router))
+	}
+	if router.PreemptDelay != old.PreemptDelay {
+		vr.SetPreemptDelay(router.PreemptDelay)
+	}
+	if router.StartupDelay != old.StartupDelay {
+		vr.SetStartupDelay(router.StartupDelay)
+	}
+	if router.AcceptMode != old.AcceptMode {
+		vr.SetAcceptMode(router.AcceptMode)
+	}
+	if router.AdvertisementInterval != old.AdvertisementInterval {
+		if errOfUpdate := vr.UpdateAdvInterval(intervalOf(router)); errOfUpdate != nil {
+			return errOfUpdate
+		}
+	}
+	var kept = make(map[string]bool, len(router.Addresses))
+	for _, address := range router.Addresses {
+		kept[net.ParseIP(address).String()] = true
+	}
+	var existing = make(map[string]bool, len(old.Addresses))
+	for _, address := range old.Addresses {
+		var ip = net.ParseIP(address)
+		existing[ip.String()] = true
+		if !kept[ip.String()] {
+			vr.RemoveIPvXAddr(ip)
+		}
+	}
+	for address := range kept {
+		if !existing[address] {
+			vr.AddIPvXAddr(net.ParseIP(address))
+		}
+	}
+	var notify = router.Notify
+	inst.notify.Store(&notify)
+	inst.config = *router
+	return nil
+}
+
+// configOf build the configuration of the virtual router, router must be valid
+func configOf(router *RouterConfig) (*vrrp.Config, error) {
+	var cfg = &vrrp.Config{
+		VRID:                  byte(router.VRID),
+		Owner:                 router.Owner,
+		IPvX:                  router.ipvX(),
+		Interface:             router.Interface,
+		SourceIP:              net.ParseIP(router.SourceIP),
+		Priority:              priorityOf(router),
+		AdvertisementInterval: intervalOf(router),
+		Version:               vrrp.VRRPVersion(router.Version),
+		VirtualMAC:            router.VirtualMAC,
+		AcceptMode:            router.AcceptMode,
+		NoPreempt:             !preemptOf(router),
+		PreemptDelay:          router.PreemptDelay,
+		StartupDelay:          router.StartupDelay,
+	}
+	for _, address := range router.Addresses {
+		cfg.Addresses = append(cfg.Addresses, net.ParseIP(address))
+	}
+	for _, peer := range router.Peers {
+		cfg.Peers = append(cfg.Peers, net.ParseIP(peer))
+	}
+	for i := range router.Tracks {
+		var track, errOfTrack = trackOf(&router.Tracks[i])
+		if errOfTrack != nil {
+			return nil, fmt.Errorf("tracks[%v]: %w", i, errOfTrack)
+		}
+		cfg.Tracks = append(cfg.Tracks, track)
+	}
+	return cfg, nil
+}
+
+func priorityOf(router *RouterConfig) byte {
+	if router.Priority == 0 {
+		return 100
+	}
+	return byte(router.Priority)
+}
+
+func intervalOf(router *RouterConfig) time.Duration {
+	if router.AdvertisementInterval == 0 {
+		return time.Second
+	}
+	return router.AdvertisementInterval
+}
+
+func preemptOf(router *RouterConfig) bool {
+	return router.Preempt == nil || *router.Preempt
+}
+
+// trackOf build the track described by track, track must be valid
+func trackOf(track *TrackConfig) (vrrp.Track, error) {
+	var result = vrrp.Track{Name: track.Name, Weight: track.Weight, Interval: track.Interval, Timeout: track.Timeout}
+	var name string
+	switch {
+	case track.Link != "":
+		result.Tracker, name = &vrrp.LinkTracker{Interface: track.Link}, "link "+track.Link
+	case track.Address != "":
+		result.Tracker = &vrrp.AddressTracker{Interface: track.Interface, Address: net.ParseIP(track.Address)}
+		name = "address " + track.Address + " on " + track.Interface
+	case track.Route != "":
+		var _, destination, errOfParse = net.ParseCIDR(track.Route)
+		if errOfParse != nil {
+			return result, errOfParse
+		}
+		result.Tracker, name = &vrrp.RouteTracker{Destination: destination}, "route "+track.Route
+	case track.TCP != "":
+		result.Tracker, name = &vrrp.TCPTracker{Address: track.TCP}, "tcp "+track.TCP
+	case track.HTTP != "":
+		result.Tracker, name = &vrrp.HTTPTracker{URL: track.HTTP}, "http "+track.HTTP
+	default:
+		result.Tracker, name = &vrrp.ScriptTracker{Path: track.Script[0], Args: track.Script[1:]}, "script "+track.Script[0]
+	}
+	if result.Name == "" {
+		result.Name = name
+	}
+	return result, nil
+}
+
+// notifyTransition run the command of the new state and then the Any command, one at a time
+// on the goroutine of the subscription so that they run in the order of the transitions
+func (inst *instance) notifyTransition(event vrrp.TransitionEvent) {
+	var notify = inst.notify.Load()
+	var command Command
+	switch event.To {
+	case vrrp.MASTER:
+		command = notify.Master
+	case vrrp.BACKUP:
+		command = notify.Backup
+	case vrrp.FAULT:
+		command = notify.Fault
+	case vrrp.INIT:
+		command = notify.Init
+	}
+	var timeout = notify.Timeout
+	if timeout == 0 {
+		timeout = defaultNotifyTimeout
+	}
+	var environment = append(os.Environ(),
+		"VRRP_INTERFACE="+inst.key.nif,
+		"VRRP_FAMILY="+familyName(inst.key.ipvX),
+		"VRRP_VRID="+strconv.Itoa(int(event.VRID)),
+		"VRRP_STATE="+event.To.String(),
+		"VRRP_FROM="+event.From.String(),
+		"VRRP_REASON="+event.Reason.String(),
+		"VRRP_PRIORITY="+strconv.Itoa(int(event.Priority)),
+		"VRRP_MASTER_IP="+ipString(event.MasterIP),
+	)
+	for _, command := range []Command{command, notify.Any} {
+		if len(command) == 0 {
+			continue
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), timeout)
+		var cmd = exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.Env = environment
+		var output, errOfRun = cmd.CombinedOutput()
+		cancel()
+		if errOfRun != nil {
+			logger.GLoger.Printf(logger.ERROR, "vrrpd: notify command %v of virtual router %v failed: %v: %s",
+				command[0], event.VRID, errOfRun, output)
+		}
+	}
+}
+
+// ipString return the text form of ip, it's empty if ip is nil
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+	"vrrp-go/vrrptest"
+)
+
+const testInterval = 100 * time.Millisecond
+
+// routerConfig return a virtual router without interface, the simulated transport carries its advertisements
+func routerConfig(VRID, priority int, source string, addresses ...string) RouterConfig {
+	return RouterConfig{VRID: VRID, Priority: priority, SourceIP: source, AdvertisementInterval: testInterval, Addresses: addresses}
+}
+
+// router return the virtual router of VRID run by d
+func router(t *testing.T, d *Daemon, VRID byte) *vrrp.VirtualRouter {
+	t.Helper()
+	for _, vr := range d.Routers() {
+		if vr.VRID() == VRID {
+			return vr
+		}
+	}
+	t.Fatalf("virtual router %v is not running", VRID)
+	return nil
+}
+
+func TestDaemon(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var local, peer = NewDaemon(vrrptest.Transport(seg)), NewDaemon(vrrptest.Transport(seg))
+	defer local.Close()
+	defer peer.Close()
+	var notified = filepath.Join(t.TempDir(), "notified")
+	var master = routerConfig(1, 200, "10.0.0.2", "192.168.1.254")
+	master.Notify.Master = Command{"/bin/sh", "-c", `echo "$VRRP_VRID $VRRP_FROM $VRRP_STATE" >> ` + notified}
+	if err := local.Apply(&Config{Routers: []RouterConfig{master}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := peer.Apply(&Config{Routers: []RouterConfig{routerConfig(1, 100, "10.0.0.1", "192.168.1.254")}}); err != nil {
+		t.Fatal(err)
+	}
+	var vr, backup = router(t, local, 1), router(t, peer, 1)
+	vrrptest.AwaitState(t, vr, vrrp.MASTER)
+	vrrptest.AwaitState(t, backup, vrrp.BACKUP)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if content, _ := os.ReadFile(notified); string(content) == "1 BACKUP MASTER\n" {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("notified %q", content)
+		}
+	}
+
+	//the fields with a runtime setter are changed on the running virtual router
+	var reloaded = master
+	reloaded.Priority, reloaded.Addresses = 50, []string{"192.168.1.253", "192.168.1.254"}
+	if err := local.Apply(&Config{Routers: []RouterConfig{reloaded}}); err != nil {
+		t.Fatal(err)
+	}
+	if router(t, local, 1) != vr {
+		t.Fatal("virtual router recreated by a priority change")
+	}
+	if status := vr.Status(); status.BasePriority != 50 || len(status.Addresses) != 2 {
+		t.Fatalf("status after reload = %+v", status)
+	}
+	vrrptest.AwaitState(t, backup, vrrp.MASTER)
+	vrrptest.AwaitState(t, vr, vrrp.BACKUP)
+
+	//a change of the tracks recreates the virtual router, a new one is started
+	reloaded.Tracks = []TrackConfig{{TCP: "127.0.0.1:1", Weight: 10}}
+	if err := local.Apply(&Config{Routers: []RouterConfig{reloaded, routerConfig(2, 100, "10.0.0.2", "192.168.2.254")}}); err != nil {
+		t.Fatal(err)
+	}
+	if vr = router(t, local, 1); vr == nil || len(vr.Status().Tracks) != 1 {
+		t.Fatal("virtual router not recreated by a change of the tracks")
+	}
+	vrrptest.AwaitState(t, vr, vrrp.BACKUP)
+	vrrptest.AwaitState(t, router(t, local, 2), vrrp.MASTER)
+
+	//a failure is reported without stopping the other virtual routers
+	var conflict = routerConfig(3, 100, "10.0.0.9", "192.168.3.254")
+	if err := local.Apply(&Config{Routers: []RouterConfig{reloaded, conflict}}); err == nil ||
+		!strings.Contains(err.Error(), "source address conflicts") {
+		t.Fatalf("Apply of a conflicting source returned %v", err)
+	}
+	if routers := local.Routers(); len(routers) != 1 || routers[0] != vr {
+		t.Fatalf("%v virtual routers left", len(routers))
+	}
+
+	//the master resigns with priority 0 when the daemon is closed
+	reloaded.Priority = 150
+	if err := local.Apply(&Config{Routers: []RouterConfig{reloaded}}); err != nil {
+		t.Fatal(err)
+	}
+	vrrptest.AwaitState(t, vr, vrrp.MASTER)
+	vrrptest.AwaitState(t, backup, vrrp.BACKUP)
+	var events = backup.Subscribe(0, vrrp.DropNewest)
+	defer events.Close()
+	if err := local.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-events.C:
+		if event.To != vrrp.MASTER || event.Reason != vrrp.ReasonPriorityZero {
+			t.Fatalf("peer event after Close = %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("peer didn't take over")
+	}
+}
@@ -0,0 +1,79 @@
+// Command vrrpd runs the virtual routers described by a YAML configuration file.
+//
+// SIGTERM and SIGINT stop all the virtual routers, a MASTER resigns with an advertisement of priority 0
+// so that a backup takes over at once. SIGHUP reloads the configuration file: the virtual routers which
+// are left out are stopped, the new ones are started and the others are reconfigured at runtime, only a
+// change of a field without a runtime setter restarts a virtual router. An invalid file is rejected
+// and the running configuration is kept.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"vrrp-go/logger"
+)
+
+const defaultConfigPath = "/etc/vrrpd/vrrpd.yaml"
+
+func main() {
+	var path = flag.String("config", defaultConfigPath, "path of the configuration file")
+	var check = flag.Bool("check", false, "validate the configuration file and exit")
+	flag.Parse()
+	var cfg, errOfLoad = LoadConfig(*path)
+	if errOfLoad != nil {
+		fmt.Fprintf(os.Stderr, "vrrpd: invalid configuration: %v\n", errOfLoad)
+		os.Exit(2)
+	}
+	if *check {
+		fmt.Printf("vrrpd: %v is valid, %v virtual routers\n", *path, len(cfg.Routers))
+		return
+	}
+	setLogLevel(cfg.LogLevel)
+
+	//subscribe before starting, so that no signal is lost while the virtual routers start
+	var signals = make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	var daemon = NewDaemon(nil)
+	if errOfApply := daemon.Apply(cfg); errOfApply != nil {
+		daemon.Close()
+		fmt.Fprintf(os.Stderr, "vrrpd: failed to start: %v\n", errOfApply)
+		os.Exit(1)
+	}
+	logger.GLoger.Printf(logger.INFO, "vrrpd: %v virtual routers started from %v", len(cfg.Routers), *path)
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			logger.GLoger.Printf(logger.INFO, "vrrpd: %v received, shutting down", sig)
+			break
+		}
+		var reloaded, errOfReload = LoadConfig(*path)
+		if errOfReload != nil {
+			logger.GLoger.Printf(logger.ERROR, "vrrpd: reload rejected, the running configuration is kept: %v", errOfReload)
+			continue
+		}
+		setLogLevel(reloaded.LogLevel)
+		if errOfApply := daemon.Apply(reloaded); errOfApply != nil {
+			logger.GLoger.Printf(logger.ERROR, "vrrpd: reload partially failed: %v", errOfApply)
+			continue
+		}
+		logger.GLoger.Printf(logger.INFO, "vrrpd: configuration reloaded from %v", *path)
+	}
+	if errOfClose := daemon.Close(); errOfClose != nil {
+		fmt.Fprintf(os.Stderr, "vrrpd: %v\n", errOfClose)
+		os.Exit(1)
+	}
+}
+
+// setLogLevel apply the log level of the configuration, it must be valid
+func setLogLevel(level string) {
+	switch level {
+	case "debug":
+		logger.GLoger.SetLevel(logger.DEBUG)
+	case "error":
+		logger.GLoger.SetLevel(logger.ERROR)
+	default:
+		logger.GLoger.SetLevel(logger.INFO)
+	}
+}
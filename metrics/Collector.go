@@ -0,0 +1,144 @@
+// Package metrics exports the state and the protocol counters of virtual routers to Prometheus
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"vrrp-go/vrrp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var routerLabels = []string{"vrid", "family", "interface"}
+
+var (
+	stateDesc = prometheus.NewDesc("vrrp_state",
+		"State of the virtual router: 0 INIT, 1 MASTER, 2 BACKUP, 3 FAULT.", routerLabels, nil)
+	priorityDesc = prometheus.NewDesc("vrrp_priority",
+		"Priority the virtual router advertises with, after the tracks are applied.", routerLabels, nil)
+	masterPriorityDesc = prometheus.NewDesc("vrrp_master_priority",
+		"Priority advertised by the current master, 0 if the master is unknown.", routerLabels, nil)
+	advertisementIntervalDesc = prometheus.NewDesc("vrrp_advertisement_interval_seconds",
+		"Interval the virtual router advertises at as MASTER.", routerLabels, nil)
+	advertisementsSentDesc = prometheus.NewDesc("vrrp_advertisements_sent_total",
+		"Advertisements sent by the virtual router.", routerLabels, nil)
+	advertisementsReceivedDesc = prometheus.NewDesc("vrrp_advertisements_received_total",
+		"Valid advertisements received by the virtual router.", routerLabels, nil)
+	transitionsDesc = prometheus.NewDesc("vrrp_transitions_total",
+		"State transitions of the virtual router by type.", append(routerLabels, "transition"), nil)
+	checksumErrorsDesc = prometheus.NewDesc("vrrp_checksum_errors_total",
+		"Advertisements discarded by the virtual router for an invalid check sum.", routerLabels, nil)
+	ttlErrorsDesc = prometheus.NewDesc("vrrp_ttl_errors_total",
+		"Advertisements discarded by the virtual router for a TTL or hop limit other than 255.", routerLabels, nil)
+	versionErrorsDesc = prometheus.NewDesc("vrrp_version_errors_total",
+		"Advertisements discarded by the virtual router for a version it doesn't run.", routerLabels, nil)
+	invalidTypeErrorsDesc = prometheus.NewDesc("vrrp_invalid_type_errors_total",
+		"Packets discarded by the virtual router since they aren't advertisements.", routerLabels, nil)
+	packetLengthErrorsDesc = prometheus.NewDesc("vrrp_packet_length_errors_total",
+		"Packets discarded by the virtual router since they are truncated.", routerLabels, nil)
+	addressListErrorsDesc = prometheus.NewDesc("vrrp_address_list_errors_total",
+		"Advertisements whose address list doesn't match the protected addresses.", routerLabels, nil)
+	intervalErrorsDesc = prometheus.NewDesc("vrrp_advertisement_interval_errors_total",
+		"Advertisements with an interval other than the configured one.", routerLabels, nil)
+	unknownPeerErrorsDesc = prometheus.NewDesc("vrrp_unknown_peer_errors_total",
+		"Advertisements discarded in unicast mode since they aren't sent by a peer.", routerLabels, nil)
+	priorityZeroReceivedDesc = prometheus.NewDesc("vrrp_priority_zero_received_total",
+		"Advertisements of priority 0 received by the virtual router.", routerLabels, nil)
+	priorityZeroSentDesc = prometheus.NewDesc("vrrp_priority_zero_sent_total",
+		"Advertisements of priority 0 sent by the virtual router.", routerLabels, nil)
+	announcementsDesc = prometheus.NewDesc("vrrp_announcements_total",
+		"Gratuitous ARP and unsolicited NA sent for the protected addresses.", routerLabels, nil)
+	invalidAdvertisementsDesc = prometheus.NewDesc("vrrp_invalid_advertisements_total",
+		"Invalid advertisements received by the process, including those of the shared connections.", []string{"reason"}, nil)
+)
+
+// Collector collect the metrics of the virtual routers listed by its source at every scrape
+type Collector struct {
+	source vrrp.RouterSource
+}
+
+// NewCollector create the collector of the virtual routers listed by source
+func NewCollector(source vrrp.RouterSource) *Collector {
+	return &Collector{source: source}
+}
+
+// Describe send the descriptions of all the metrics
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		stateDesc, priorityDesc, masterPriorityDesc, advertisementIntervalDesc,
+		advertisementsSentDesc, advertisementsReceivedDesc, transitionsDesc,
+		checksumErrorsDesc, ttlErrorsDesc, versionErrorsDesc, invalidTypeErrorsDesc, packetLengthErrorsDesc,
+		addressListErrorsDesc, intervalErrorsDesc, unknownPeerErrorsDesc, priorityZeroReceivedDesc, priorityZeroSentDesc,
+		announcementsDesc, invalidAdvertisementsDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect send the metrics of the virtual routers and of the process
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, vr := range c.source.Routers() {
+		collectRouter(ch, vr)
+	}
+	var errs = vrrp.GlobalReceiveErrors()
+	ch <- prometheus.MustNewConstMetric(invalidAdvertisementsDesc, prometheus.CounterValue, float64(errs.ChecksumErrors), "checksum")
+	ch <- prometheus.MustNewConstMetric(invalidAdvertisementsDesc, prometheus.CounterValue, float64(errs.TTLErrors), "ttl")
+	ch <- prometheus.MustNewConstMetric(invalidAdvertisementsDesc, prometheus.CounterValue, float64(errs.VersionErrors), "version")
+	ch <- prometheus.MustNewConstMetric(invalidAdvertisementsDesc, prometheus.CounterValue, float64(errs.VRIDErrors), "vrid")
+}
+
+// collectRouter send the metrics of vr
+func collectRouter(ch chan<- prometheus.Metric, vr *vrrp.VirtualRouter) {
+	var status, statistics = vr.Status(), vr.Statistics()
+	var labels = []string{strconv.Itoa(int(status.VRID)), "ipv4", ""}
+	if vr.IPvX() == vrrp.IPv6 {
+		labels[1] = "ipv6"
+	}
+	if nif := vr.NetInterface(); nif != nil {
+		labels[2] = nif.Name
+	}
+	var gauge = func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	}
+	var counter = func(desc *prometheus.Desc, value uint64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value), labels...)
+	}
+	gauge(stateDesc, float64(status.State))
+	gauge(priorityDesc, float64(status.Priority))
+	gauge(masterPriorityDesc, float64(status.MasterPriority))
+	gauge(advertisementIntervalDesc, status.AdvertisementInterval.Seconds())
+	counter(advertisementsSentDesc, statistics.AdvertisementsSent)
+	counter(advertisementsReceivedDesc, statistics.AdvertisementsReceived)
+	counter(checksumErrorsDesc, statistics.ChecksumErrors)
+	counter(ttlErrorsDesc, statistics.TTLErrors)
+	counter(versionErrorsDesc, statistics.VersionErrors)
+	counter(invalidTypeErrorsDesc, statistics.InvalidTypeErrors)
+	counter(packetLengthErrorsDesc, statistics.PacketLengthErrors)
+	counter(addressListErrorsDesc, statistics.AddressListErrors)
+	counter(intervalErrorsDesc, statistics.AdvertisementIntervalErrors)
+	counter(unknownPeerErrorsDesc, statistics.UnknownPeerErrors)
+	counter(priorityZeroReceivedDesc, statistics.PriorityZeroReceived)
+	counter(priorityZeroSentDesc, statistics.PriorityZeroSent)
+	counter(announcementsDesc, statistics.Announcements)
+	//every transition is exported, so that the series exist before the first transition of their type
+	for t := vrrp.Master2Backup; t <= vrrp.Fault2Init; t++ {
+		ch <- prometheus.MustNewConstMetric(transitionsDesc, prometheus.CounterValue, float64(statistics.Transitions[t]),
+			append(labels, strings.ReplaceAll(t.String(), " ", "_"))...)
+	}
+}
+
+// Handler serve the metrics of the virtual routers listed by source in the Prometheus text format
+func Handler(source vrrp.RouterSource) http.Handler {
+	var registry = prometheus.NewRegistry()
+	registry.MustRegister(NewCollector(source))
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// NewServer create the HTTP server serving the metrics of the virtual routers listed by source on /metrics at addr
+func NewServer(addr string, source vrrp.RouterSource) *http.Server {
+	var mux = http.NewServeMux()
+	mux.Handle("/metrics", Handler(source))
+	return &http.Server{Addr: addr, Handler: mux}
+}
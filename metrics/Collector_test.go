@@ -0,0 +1,71 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"vrrp-go/metrics"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+	"vrrp-go/vrrptest"
+)
+
+func scrape(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	var response, err = server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var body, _ = io.ReadAll(response.Body)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = vrrptest.StartRouter(t, seg, 1, "10.0.0.2", 200)
+	var backup = vrrptest.StartRouter(t, seg, 1, "10.0.0.1", 100)
+	var other = vrrptest.StartRouter(t, seg, 2, "10.0.0.3", 100)
+	var server = httptest.NewServer(metrics.NewServer("", vrrp.RouterList{master, other}).Handler)
+	defer server.Close()
+
+	var want = []string{
+		`vrrp_state{family="ipv4",interface="",vrid="1"} 1`,
+		`vrrp_priority{family="ipv4",interface="",vrid="1"} 200`,
+		`vrrp_master_priority{family="ipv4",interface="",vrid="1"} 200`,
+		`vrrp_advertisement_interval_seconds{family="ipv4",interface="",vrid="1"} 0.1`,
+		`vrrp_transitions_total{family="ipv4",interface="",transition="backup_to_master",vrid="1"} 1`,
+		`vrrp_transitions_total{family="ipv4",interface="",transition="master_to_backup",vrid="1"} 0`,
+		`vrrp_announcements_total{family="ipv4",interface="",vrid="1"} 1`,
+		`vrrp_state{family="ipv4",interface="",vrid="2"} 1`,
+		`vrrp_invalid_advertisements_total{reason="checksum"}`,
+	}
+	var body string
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		body = scrape(t, server)
+		var missing string
+		for _, line := range want {
+			if !strings.Contains(body, line) {
+				missing = line
+				break
+			}
+		}
+		if missing == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%v missing from\n%v", missing, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var sent = `vrrp_advertisements_sent_total{family="ipv4",interface="",vrid="1"} `
+	if !strings.Contains(body, sent) || strings.Contains(body, sent+"0\n") {
+		t.Fatalf("no advertisement sent in\n%v", body)
+	}
+	if status := backup.Status(); status.State != vrrp.BACKUP {
+		t.Fatalf("status of the backup = %+v", status)
+	}
+}
@@ -0,0 +1,442 @@
+// Package rpc serves the VirtualRouters gRPC service of vrrp.proto, it creates, controls and watches
+// the virtual routers of a vrrp.Manager
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative vrrp.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"vrrp-go/logger"
+	"vrrp-go/vrrp"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Service implements VirtualRoutersServer on the virtual routers of a vrrp.Manager
+type Service struct {
+	UnimplementedVirtualRoutersServer
+	manager *vrrp.Manager
+	// Configure completes the configuration of the virtual routers before Create adds them,
+	// with AddrInstaller or Tracks for instance. Create fails with its error.
+	Configure func(cfg *vrrp.Config) error
+}
+
+// NewService create the service of the virtual routers of manager
+func NewService(manager *vrrp.Manager) *Service {
+	return &Service{manager: manager}
+}
+
+// NewServer create a gRPC server serving service
+func NewServer(service *Service, opts ...grpc.ServerOption) *grpc.Server {
+	var server = grpc.NewServer(opts...)
+	RegisterVirtualRoutersServer(server, service)
+	return server
+}
+
+// Create add the virtual router of the request to the manager, it's started at once
+func (s *Service) Create(ctx context.Context, request *CreateRequest) (*Router, error) {
+	var cfg, errOfConfig = configOf(request.GetConfig())
+	if errOfConfig != nil {
+		return nil, errOfConfig
+	}
+	if _, errOfFind := s.find(request.GetConfig().GetKey()); errOfFind == nil {
+		return nil, status.Errorf(codes.AlreadyExists, "virtual router %v exists", describe(request.GetConfig().GetKey()))
+	}
+	if s.Configure != nil {
+		if errOfConfigure := s.Configure(cfg); errOfConfigure != nil {
+			return nil, statusOf(errOfConfigure)
+		}
+	}
+	var vr, errOfAdd = s.manager.Add(cfg)
+	if errOfAdd != nil {
+		return nil, statusOf(errOfAdd)
+	}
+	logger.GLoger.Printf(logger.INFO, "virtual router %v created", describe(request.GetConfig().GetKey()))
+	return routerOf(vr), nil
+}
+
+// Start run the stopped virtual router again
+func (s *Service) Start(ctx context.Context, request *RouterRequest) (*Router, error) {
+	var vr, errOfFind = s.find(request.GetKey())
+	if errOfFind != nil {
+		return nil, errOfFind
+	}
+	if errOfStart := s.manager.Start(vr); errOfStart != nil {
+		return nil, statusOf(errOfStart)
+	}
+	return routerOf(vr), nil
+}
+
+// Stop shut the virtual router down and wait until it's stopped
+func (s *Service) Stop(ctx context.Context, request *RouterRequest) (*Router, error) {
+	var vr, errOfFind = s.find(request.GetKey())
+	if errOfFind != nil {
+		return nil, errOfFind
+	}
+	if errOfStop := s.manager.Stop(vr); errOfStop != nil {
+		return nil, statusOf(errOfStop)
+	}
+	return routerOf(vr), nil
+}
+
+// Delete stop the virtual router and remove it from the manager
+func (s *Service) Delete(ctx context.Context, request *RouterRequest) (*emptypb.Empty, error) {
+	var vr, errOfFind = s.find(request.GetKey())
+	if errOfFind != nil {
+		return nil, errOfFind
+	}
+	if errOfRemove := s.manager.Remove(vr); errOfRemove != nil {
+		return nil, statusOf(errOfRemove)
+	}
+	logger.GLoger.Printf(logger.INFO, "virtual router %v deleted", describe(request.GetKey()))
+	return &emptypb.Empty{}, nil
+}
+
+// Update apply the fields set in the request, they are all checked before any is applied
+func (s *Service) Update(ctx context.Context, request *UpdateRequest) (*Router, error) {
+	var vr, errOfFind = s.find(request.GetKey())
+	if errOfFind != nil {
+		return nil, errOfFind
+	}
+	if request.Priority != nil {
+		if request.GetPriority() < 1 || request.GetPriority() > 254 {
+			return nil, status.Errorf(codes.InvalidArgument, "priority %v is not in [1, 254]", request.GetPriority())
+		}
+		if vr.Status().BasePriority == 255 {
+			return nil, status.Error(codes.FailedPrecondition, "the priority of the address owner can't be changed")
+		}
+	}
+	var added, errOfAdded = parseAddresses(request.GetAddAddresses(), vr.IPvX())
+	if errOfAdded != nil {
+		return nil, errOfAdded
+	}
+	var removed, errOfRemoved = parseAddresses(request.GetRemoveAddresses(), vr.IPvX())
+	if errOfRemoved != nil {
+		return nil, errOfRemoved
+	}
+	//the interval is the only change the virtual router may reject, so it goes first
+	if request.AdvertisementInterval != nil {
+		if errOfInterval := vr.UpdateAdvInterval(request.GetAdvertisementInterval().AsDuration()); errOfInterval != nil {
+			return nil, statusOf(errOfInterval)
+		}
+	}
+	if request.Priority != nil {
+		vr.SetPriority(byte(request.GetPriority()))
+	}
+	if request.Preempt != nil {
+		vr.SetPreemptMode(request.GetPreempt())
+	}
+	if request.AcceptMode != nil {
+		vr.SetAcceptMode(request.GetAcceptMode())
+	}
+	if request.PreemptDelay != nil {
+		vr.SetPreemptDelay(request.GetPreemptDelay().AsDuration())
+	}
+	for _, ip := range added {
+		vr.AddIPvXAddr(ip)
+	}
+	for _, ip := range removed {
+		vr.RemoveIPvXAddr(ip)
+	}
+	logger.GLoger.Printf(logger.INFO, "virtual router %v updated", describe(request.GetKey()))
+	return routerOf(vr), nil
+}
+
+// Get return the status of the virtual router
+func (s *Service) Get(ctx context.Context, request *RouterRequest) (*Router, error) {
+	var vr, errOfFind = s.find(request.GetKey())
+	if errOfFind != nil {
+		return nil, errOfFind
+	}
+	return routerOf(vr), nil
+}
+
+// List return the status of all the virtual routers
+func (s *Service) List(ctx context.Context, request *ListRequest) (*ListResponse, error) {
+	var response = &ListResponse{}
+	for _, vr := range s.manager.Routers() {
+		response.Routers = append(response.Routers, routerOf(vr))
+	}
+	return response, nil
+}
+
+// Watch stream the transitions and the advertisements of the selected virtual routers until the call is done,
+// the stream ends with ResourceExhausted when the client is too slow to get every transition
+func (s *Service) Watch(request *WatchRequest, stream VirtualRouters_WatchServer) error {
+	var routers = s.manager.Routers()
+	if len(request.GetKeys()) != 0 {
+		routers = routers[:0:0]
+		for _, key := range request.GetKeys() {
+			var vr, errOfFind = s.find(key)
+			if errOfFind != nil {
+				return errOfFind
+			}
+			routers = append(routers, vr)
+		}
+	}
+	var ctx = stream.Context()
+	var events = make(chan *WatchEvent)
+	var forward = func(event *WatchEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	//the stream ends when a transition is lost, the advertisements are only sampled
+	var overflow = make(chan *RouterKey, len(routers))
+	for _, vr := range routers {
+		var key = keyOf(vr)
+		var transitions = vr.Subscribe(0, vrrp.DropNewest)
+		defer transitions.Close()
+		var advertisements = vr.SubscribeAdvertisements(0, vrrp.DropOldest)
+		defer advertisements.Close()
+		go func() {
+			for {
+				//the overflow is reported as soon as it happens, ahead of the transitions still queued
+				select {
+				case <-transitions.Overflow():
+					overflow <- key
+					return
+				default:
+				}
+				select {
+				case <-transitions.Overflow():
+					overflow <- key
+					return
+				case event, ok := <-transitions.C:
+					if !ok || !forward(&WatchEvent{Key: key, Event: &WatchEvent_Transition{Transition: transitionOf(event)}}) {
+						return
+					}
+				}
+			}
+		}()
+		go func() {
+			for event := range advertisements.C {
+				if !forward(&WatchEvent{Key: key, Event: &WatchEvent_Advertisement{Advertisement: advertisementOf(event)}}) {
+					return
+				}
+			}
+		}()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case key := <-overflow:
+			return status.Errorf(codes.ResourceExhausted, "watch fell behind the transitions of %v", describe(key))
+		case event := <-events:
+			if errOfSend := stream.Send(event); errOfSend != nil {
+				return errOfSend
+			}
+		}
+	}
+}
+
+// find return the virtual router of key
+func (s *Service) find(key *RouterKey) (*vrrp.VirtualRouter, error) {
+	if key.GetVrid() < 1 || key.GetVrid() > 255 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid VRID %v", key.GetVrid())
+	}
+	if key.GetFamily() != Family_FAMILY_IPV4 && key.GetFamily() != Family_FAMILY_IPV6 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid family %v", key.GetFamily())
+	}
+	for _, vr := range s.manager.Routers() {
+		var other = keyOf(vr)
+		if other.Vrid == key.GetVrid() && other.Family == key.GetFamily() && other.Interface == key.GetInterface() {
+			return vr, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "virtual router %v not found", describe(key))
+}
+
+// configOf convert the configuration of the request
+func configOf(config *RouterConfig) (*vrrp.Config, error) {
+	var key = config.GetKey()
+	if key.GetVrid() < 1 || key.GetVrid() > 255 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid VRID %v", key.GetVrid())
+	}
+	if config.GetPriority() > 254 {
+		return nil, status.Errorf(codes.InvalidArgument, "priority %v is not in [1, 254], use owner instead of 255", config.GetPriority())
+	}
+	var cfg = &vrrp.Config{
+		VRID:                  byte(key.GetVrid()),
+		Interface:             key.GetInterface(),
+		Owner:                 config.GetOwner(),
+		Priority:              byte(config.GetPriority()),
+		Version:               vrrp.VRRPVersion(config.GetVersion()),
+		AdvertisementInterval: config.GetAdvertisementInterval().AsDuration(),
+		PreemptDelay:          config.GetPreemptDelay().AsDuration(),
+		StartupDelay:          config.GetStartupDelay().AsDuration(),
+		AcceptMode:            config.GetAcceptMode(),
+		VirtualMAC:            config.GetVirtualMac(),
+	}
+	switch key.GetFamily() {
+	case Family_FAMILY_IPV4:
+		cfg.IPvX = vrrp.IPv4
+	case Family_FAMILY_IPV6:
+		cfg.IPvX = vrrp.IPv6
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid family %v", key.GetFamily())
+	}
+	var errOfParse error
+	if cfg.Addresses, errOfParse = parseAddresses(config.GetAddresses(), cfg.IPvX); errOfParse != nil {
+		return nil, errOfParse
+	}
+	if cfg.Peers, errOfParse = parseAddresses(config.GetPeers(), cfg.IPvX); errOfParse != nil {
+		return nil, errOfParse
+	}
+	if config.GetSourceIp() != "" {
+		var source, errOfSource = parseAddresses([]string{config.GetSourceIp()}, cfg.IPvX)
+		if errOfSource != nil {
+			return nil, errOfSource
+		}
+		cfg.SourceIP = source[0]
+	}
+	cfg.NoPreempt = config.Preempt != nil && !config.GetPreempt()
+	return cfg, nil
+}
+
+// parseAddresses parse the addresses of the family IPvX
+func parseAddresses(addrs []string, IPvX byte) ([]net.IP, error) {
+	var ips []net.IP
+	for _, addr := range addrs {
+		var ip = net.ParseIP(addr)
+		if ip == nil || ip.IsUnspecified() || (ip.To4() != nil) != (IPvX == vrrp.IPv4) {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not an IPv%v address", addr, IPvX)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// statusOf map the errors of the vrrp package to the gRPC status codes
+func statusOf(err error) error {
+	var code = codes.Internal
+	switch {
+	case errors.Is(err, vrrp.ErrUnknownRouter):
+		code = codes.NotFound
+	case errors.Is(err, vrrp.ErrDuplicateVRID):
+		code = codes.AlreadyExists
+	case errors.Is(err, vrrp.ErrRouterRunning), errors.Is(err, vrrp.ErrSourceAddressConflict), errors.Is(err, vrrp.ErrNotMaster):
+		code = codes.FailedPrecondition
+	case errors.Is(err, vrrp.ErrManagerClosed):
+		code = codes.Unavailable
+	case errors.Is(err, vrrp.ErrInvalidInterval), errors.Is(err, vrrp.ErrInvalidAddressFamily), errors.Is(err, vrrp.ErrInvalidVersion),
+		errors.Is(err, vrrp.ErrNoPeer), errors.Is(err, vrrp.ErrInterfaceNotFound), errors.Is(err, vrrp.ErrNoSourceAddress):
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, err.Error())
+}
+
+// keyOf return the key of vr
+func keyOf(vr *vrrp.VirtualRouter) *RouterKey {
+	var key = &RouterKey{Family: Family_FAMILY_IPV4, Vrid: uint32(vr.VRID())}
+	if vr.IPvX() == vrrp.IPv6 {
+		key.Family = Family_FAMILY_IPV6
+	}
+	if nif := vr.NetInterface(); nif != nil {
+		key.Interface = nif.Name
+	}
+	return key
+}
+
+// routerOf take the status of vr
+func routerOf(vr *vrrp.VirtualRouter) *Router {
+	var s = vr.Status()
+	var router = &Router{
+		Key:                         keyOf(vr),
+		State:                       stateOf(s.State),
+		Priority:                    uint32(s.Priority),
+		BasePriority:                uint32(s.BasePriority),
+		Preempt:                     s.Preempt,
+		AcceptMode:                  s.AcceptMode,
+		SourceIp:                    ipString(s.SourceIP),
+		MasterIp:                    ipString(s.MasterIP),
+		MasterPriority:              uint32(s.MasterPriority),
+		AdvertisementInterval:       durationpb.New(s.AdvertisementInterval),
+		MasterAdvertisementInterval: durationpb.New(s.MasterAdvertisementInterval),
+		SkewTime:                    durationpb.New(s.SkewTime),
+		MasterDownInterval:          durationpb.New(s.MasterDownInterval),
+		Addresses:                   ipStrings(s.Addresses),
+		SyncGroup:                   s.SyncGroup,
+	}
+	if !s.LastTransition.IsZero() {
+		router.LastTransition = timestamppb.New(s.LastTransition)
+	}
+	if !s.UpSince.IsZero() {
+		router.UpSince = timestamppb.New(s.UpSince)
+	}
+	for _, track := range s.Tracks {
+		router.Tracks = append(router.Tracks, &Track{Name: track.Name, Weight: int32(track.Weight), Healthy: track.Healthy})
+	}
+	return router
+}
+
+func transitionOf(event vrrp.TransitionEvent) *Transition {
+	return &Transition{
+		From:       stateOf(event.From),
+		To:         stateOf(event.To),
+		Transition: event.Transition.String(),
+		Reason:     event.Reason.String(),
+		MasterIp:   ipString(event.MasterIP),
+		Priority:   uint32(event.Priority),
+		Time:       timestamppb.New(event.Time),
+	}
+}
+
+func advertisementOf(event vrrp.AdvertisementEvent) *Advertisement {
+	return &Advertisement{
+		SourceIp:              ipString(event.Source),
+		Version:               uint32(event.Version),
+		Priority:              uint32(event.Priority),
+		AdvertisementInterval: durationpb.New(event.Interval),
+		Addresses:             ipStrings(event.Addresses),
+		Time:                  timestamppb.New(event.Time),
+	}
+}
+
+func stateOf(state vrrp.State) State {
+	switch state {
+	case vrrp.INIT:
+		return State_STATE_INIT
+	case vrrp.MASTER:
+		return State_STATE_MASTER
+	case vrrp.BACKUP:
+		return State_STATE_BACKUP
+	case vrrp.FAULT:
+		return State_STATE_FAULT
+	default:
+		return State_STATE_UNSPECIFIED
+	}
+}
+
+// ipString format ip, nil is formatted as an empty string
+func ipString(ip net.IP) string {
+	if len(ip) == 0 {
+		return ""
+	}
+	return ip.String()
+}
+
+func ipStrings(ips []net.IP) []string {
+	var addrs = make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, ip.String())
+	}
+	return addrs
+}
+
+// describe format key as interface/family/VRID for the messages
+func describe(key *RouterKey) string {
+	return fmt.Sprintf("%v/%v/%v", key.GetInterface(), key.GetFamily(), key.GetVrid())
+}
@@ -0,0 +1,233 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"vrrp-go/rpc"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+	"vrrp-go/vrrptest"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// startService serve the virtual routers of a manager on seg and return a client
+func startService(t *testing.T, seg *simnet.Segment) rpc.VirtualRoutersClient {
+	t.Helper()
+	var manager = vrrp.NewManager(vrrptest.Transport(seg))
+	t.Cleanup(func() { manager.Close() })
+	var listener = bufconn.Listen(1 << 16)
+	var server = rpc.NewServer(rpc.NewService(manager))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	var conn, err = grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return rpc.NewVirtualRoutersClient(conn)
+}
+
+func awaitState(t *testing.T, client rpc.VirtualRoutersClient, key *rpc.RouterKey, state rpc.State) *rpc.Router {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		var router, err = client.Get(context.Background(), &rpc.RouterRequest{Key: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if router.State == state {
+			return router
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("router = %v", router)
+		}
+	}
+}
+
+func code(err error) codes.Code {
+	return status.Code(err)
+}
+
+func TestService(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var client, peer = startService(t, seg), startService(t, seg)
+	var ctx = context.Background()
+	var key = &rpc.RouterKey{Family: rpc.Family_FAMILY_IPV4, Vrid: 1}
+	var config = &rpc.RouterConfig{
+		Key:                   key,
+		Priority:              200,
+		AdvertisementInterval: durationpb.New(100 * time.Millisecond),
+		Addresses:             []string{"192.168.1.254"},
+		SourceIp:              "10.0.0.2",
+	}
+	var router, err = client.Create(ctx, &rpc.CreateRequest{Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if router.BasePriority != 200 || router.SourceIp != "10.0.0.2" || len(router.Addresses) != 1 {
+		t.Fatalf("created %v", router)
+	}
+	if _, err = client.Create(ctx, &rpc.CreateRequest{Config: config}); code(err) != codes.AlreadyExists {
+		t.Fatalf("second Create returned %v", err)
+	}
+	var invalid = proto.Clone(config).(*rpc.RouterConfig)
+	invalid.Addresses = []string{"fe80::1"}
+	if _, err = client.Create(ctx, &rpc.CreateRequest{Config: invalid}); code(err) != codes.InvalidArgument {
+		t.Fatalf("Create with an IPv6 address returned %v", err)
+	}
+	awaitState(t, client, key, rpc.State_STATE_MASTER)
+
+	//the watch sees the backup on the peer start and the advertisements of the master
+	var watchCtx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	var backup = proto.Clone(config).(*rpc.RouterConfig)
+	backup.Priority, backup.SourceIp = 100, "10.0.0.1"
+	if _, err = peer.Create(ctx, &rpc.CreateRequest{Config: backup}); err != nil {
+		t.Fatal(err)
+	}
+	awaitState(t, peer, key, rpc.State_STATE_BACKUP)
+	var stream rpc.VirtualRouters_WatchClient
+	if stream, err = peer.Watch(watchCtx, &rpc.WatchRequest{Keys: []*rpc.RouterKey{key}}); err != nil {
+		t.Fatal(err)
+	}
+	var advertisement *rpc.Advertisement
+	for advertisement == nil {
+		var event, errOfRecv = stream.Recv()
+		if errOfRecv != nil {
+			t.Fatal(errOfRecv)
+		}
+		advertisement = event.GetAdvertisement()
+	}
+	if advertisement.SourceIp != "10.0.0.2" || advertisement.Priority != 200 || advertisement.Version != 3 ||
+		advertisement.AdvertisementInterval.AsDuration() != 100*time.Millisecond {
+		t.Fatalf("advertisement = %v", advertisement)
+	}
+
+	//the master lowers its priority and is preempted by the backup
+	var lower = uint32(50)
+	if router, err = client.Update(ctx, &rpc.UpdateRequest{Key: key, Priority: &lower, AddAddresses: []string{"192.168.1.253"}}); err != nil {
+		t.Fatal(err)
+	}
+	if router.BasePriority != 50 || len(router.Addresses) != 2 {
+		t.Fatalf("updated %v", router)
+	}
+	var transition *rpc.Transition
+	for transition == nil {
+		var event, errOfRecv = stream.Recv()
+		if errOfRecv != nil {
+			t.Fatal(errOfRecv)
+		}
+		if proto.Equal(event.Key, key) {
+			transition = event.GetTransition()
+		}
+	}
+	if transition.From != rpc.State_STATE_BACKUP || transition.To != rpc.State_STATE_MASTER || transition.Time == nil {
+		t.Fatalf("transition = %v", transition)
+	}
+	var invalidPriority = uint32(255)
+	if _, err = client.Update(ctx, &rpc.UpdateRequest{Key: key, Priority: &invalidPriority}); code(err) != codes.InvalidArgument {
+		t.Fatalf("Update to priority 255 returned %v", err)
+	}
+	if _, err = client.Update(ctx, &rpc.UpdateRequest{Key: key, AdvertisementInterval: durationpb.New(time.Minute)}); code(err) != codes.InvalidArgument {
+		t.Fatalf("Update to an interval of a minute returned %v", err)
+	}
+
+	var list *rpc.ListResponse
+	if list, err = client.List(ctx, &rpc.ListRequest{}); err != nil || len(list.Routers) != 1 || list.Routers[0].State != rpc.State_STATE_BACKUP {
+		t.Fatalf("List returned %v, %v", list, err)
+	}
+
+	if router, err = client.Stop(ctx, &rpc.RouterRequest{Key: key}); err != nil || router.State != rpc.State_STATE_INIT {
+		t.Fatalf("Stop returned %v, %v", router, err)
+	}
+	if _, err = client.Start(ctx, &rpc.RouterRequest{Key: key}); err != nil {
+		t.Fatal(err)
+	}
+	awaitState(t, client, key, rpc.State_STATE_BACKUP)
+	if _, err = client.Start(ctx, &rpc.RouterRequest{Key: key}); code(err) != codes.FailedPrecondition {
+		t.Fatalf("Start of a running router returned %v", err)
+	}
+
+	if _, err = client.Delete(ctx, &rpc.RouterRequest{Key: key}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Get(ctx, &rpc.RouterRequest{Key: key}); code(err) != codes.NotFound {
+		t.Fatalf("Get after Delete returned %v", err)
+	}
+	if _, err = client.Get(ctx, &rpc.RouterRequest{Key: &rpc.RouterKey{Vrid: 1}}); code(err) != codes.InvalidArgument {
+		t.Fatalf("Get without family returned %v", err)
+	}
+}
+
+// stalledStream is the Watch stream of a client which stops reading, Send blocks until release is closed
+type stalledStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	release chan struct{}
+}
+
+func (s *stalledStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *stalledStream) Send(*rpc.WatchEvent) error {
+	<-s.release
+	return nil
+}
+
+func TestWatchEndsWhenTransitionsAreLost(t *testing.T) {
+	var manager = vrrp.NewManager(vrrptest.Transport(simnet.NewSegment()))
+	defer manager.Close()
+	var service = rpc.NewService(manager)
+	var ctx = context.Background()
+	var key = &rpc.RouterKey{Family: rpc.Family_FAMILY_IPV4, Vrid: 1}
+	var config = &rpc.RouterConfig{Key: key, Owner: true, Addresses: []string{"192.168.1.254"}, SourceIp: "192.168.1.254"}
+	if _, err := service.Create(ctx, &rpc.CreateRequest{Config: config}); err != nil {
+		t.Fatal(err)
+	}
+	var awaitState = func(state rpc.State) {
+		t.Helper()
+		for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+			if router, err := service.Get(ctx, &rpc.RouterRequest{Key: key}); err != nil || router.State == state {
+				return
+			} else if time.Now().After(deadline) {
+				t.Fatalf("router = %v", router)
+			}
+		}
+	}
+	awaitState(rpc.State_STATE_MASTER)
+
+	var stream = &stalledStream{ctx: ctx, release: make(chan struct{})}
+	var watched = make(chan error, 1)
+	go func() { watched <- service.Watch(&rpc.WatchRequest{}, stream) }()
+	//each cycle makes two transitions, more than the queue of a subscription holds
+	for cycle := 0; cycle < vrrp.TRANSITIONQUEUESIZE; cycle++ {
+		if _, err := service.Stop(ctx, &rpc.RouterRequest{Key: key}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Start(ctx, &rpc.RouterRequest{Key: key}); err != nil {
+			t.Fatal(err)
+		}
+		awaitState(rpc.State_STATE_MASTER)
+	}
+	close(stream.release)
+	select {
+	case err := <-watched:
+		if code(err) != codes.ResourceExhausted {
+			t.Fatalf("Watch returned %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch goes on after losing transitions")
+	}
+}
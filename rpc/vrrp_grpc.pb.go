@@ -0,0 +1,433 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: vrrp.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	VirtualRouters_Create_FullMethodName = "/vrrp.v1.VirtualRouters/Create"
+	VirtualRouters_Start_FullMethodName  = "/vrrp.v1.VirtualRouters/Start"
+	VirtualRouters_Stop_FullMethodName   = "/vrrp.v1.VirtualRouters/Stop"
+	VirtualRouters_Delete_FullMethodName = "/vrrp.v1.VirtualRouters/Delete"
+	VirtualRouters_Update_FullMethodName = "/vrrp.v1.VirtualRouters/Update"
+	VirtualRouters_Get_FullMethodName    = "/vrrp.v1.VirtualRouters/Get"
+	VirtualRouters_List_FullMethodName   = "/vrrp.v1.VirtualRouters/List"
+	VirtualRouters_Watch_FullMethodName  = "/vrrp.v1.VirtualRouters/Watch"
+)
+
+// VirtualRoutersClient is the client API for VirtualRouters service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// VirtualRouters controls the virtual routers run by a vrrp.Manager.
+type VirtualRoutersClient interface {
+	// Create adds a virtual router and starts it.
+	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*Router, error)
+	// Start runs a stopped virtual router again.
+	Start(ctx context.Context, in *RouterRequest, opts ...grpc.CallOption) (*Router, error)
+	// Stop shuts a virtual router down, a MASTER resigns with an advertisement of priority 0.
+	Stop(ctx context.Context, in *RouterRequest, opts ...grpc.CallOption) (*Router, error)
+	// Delete stops a virtual router and removes it.
+	Delete(ctx context.Context, in *RouterRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Update reconfigures a virtual router at runtime, nothing is changed if the request is rejected.
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Router, error)
+	// Get returns the status of a virtual router.
+	Get(ctx context.Context, in *RouterRequest, opts ...grpc.CallOption) (*Router, error)
+	// List returns the status of all the virtual routers.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// Watch streams the state transitions and the advertisements received by the virtual routers
+	// until the call is cancelled. The virtual routers are selected when the call starts.
+	// Every transition is delivered: a client too slow to keep up loses the stream, which ends
+	// with RESOURCE_EXHAUSTED, and is expected to List the routers again before watching anew.
+	// The advertisements are sampled, the oldest ones are dropped while the client lags behind.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (VirtualRouters_WatchClient, error)
+}
+
+type virtualRoutersClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVirtualRoutersClient(cc grpc.ClientConnInterface) VirtualRoutersClient {
+	return &virtualRoutersClient{cc}
+}
+
+func (c *virtualRoutersClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*Router, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Router)
+	err := c.cc.Invoke(ctx, VirtualRouters_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtualRoutersClient) Start(ctx context.Context, in *RouterRequest, opts ...grpc.CallOption) (*Router, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Router)
+	err := c.cc.Invoke(ctx, VirtualRouters_Start_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtualRoutersClient) Stop(ctx context.Context, in *RouterRequest, opts ...grpc.CallOption) (*Router, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Router)
+	err := c.cc.Invoke(ctx, VirtualRouters_Stop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtualRoutersClient) Delete(ctx context.Context, in *RouterRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, VirtualRouters_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtualRoutersClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Router, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Router)
+	err := c.cc.Invoke(ctx, VirtualRouters_Update_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtualRoutersClient) Get(ctx context.Context, in *RouterRequest, opts ...grpc.CallOption) (*Router, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Router)
+	err := c.cc.Invoke(ctx, VirtualRouters_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtualRoutersClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListResponse)
+	err := c.cc.Invoke(ctx, VirtualRouters_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtualRoutersClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (VirtualRouters_WatchClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VirtualRouters_ServiceDesc.Streams[0], VirtualRouters_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &virtualRoutersWatchClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type VirtualRouters_WatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type virtualRoutersWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *virtualRoutersWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VirtualRoutersServer is the server API for VirtualRouters service.
+// All implementations must embed UnimplementedVirtualRoutersServer
+// for forward compatibility
+//
+// VirtualRouters controls the virtual routers run by a vrrp.Manager.
+type VirtualRoutersServer interface {
+	// Create adds a virtual router and starts it.
+	Create(context.Context, *CreateRequest) (*Router, error)
+	// Start runs a stopped virtual router again.
+	Start(context.Context, *RouterRequest) (*Router, error)
+	// Stop shuts a virtual router down, a MASTER resigns with an advertisement of priority 0.
+	Stop(context.Context, *RouterRequest) (*Router, error)
+	// Delete stops a virtual router and removes it.
+	Delete(context.Context, *RouterRequest) (*emptypb.Empty, error)
+	// Update reconfigures a virtual router at runtime, nothing is changed if the request is rejected.
+	Update(context.Context, *UpdateRequest) (*Router, error)
+	// Get returns the status of a virtual router.
+	Get(context.Context, *RouterRequest) (*Router, error)
+	// List returns the status of all the virtual routers.
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	// Watch streams the state transitions and the advertisements received by the virtual routers
+	// until the call is cancelled. The virtual routers are selected when the call starts.
+	// Every transition is delivered: a client too slow to keep up loses the stream, which ends
+	// with RESOURCE_EXHAUSTED, and is expected to List the routers again before watching anew.
+	// The advertisements are sampled, the oldest ones are dropped while the client lags behind.
+	Watch(*WatchRequest, VirtualRouters_WatchServer) error
+	mustEmbedUnimplementedVirtualRoutersServer()
+}
+
+// UnimplementedVirtualRoutersServer must be embedded to have forward compatible implementations.
+type UnimplementedVirtualRoutersServer struct {
+}
+
+func (UnimplementedVirtualRoutersServer) Create(context.Context, *CreateRequest) (*Router, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedVirtualRoutersServer) Start(context.Context, *RouterRequest) (*Router, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Start not implemented")
+}
+func (UnimplementedVirtualRoutersServer) Stop(context.Context, *RouterRequest) (*Router, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+}
+func (UnimplementedVirtualRoutersServer) Delete(context.Context, *RouterRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedVirtualRoutersServer) Update(context.Context, *UpdateRequest) (*Router, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedVirtualRoutersServer) Get(context.Context, *RouterRequest) (*Router, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedVirtualRoutersServer) List(context.Context, *ListRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedVirtualRoutersServer) Watch(*WatchRequest, VirtualRouters_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedVirtualRoutersServer) mustEmbedUnimplementedVirtualRoutersServer() {}
+
+// UnsafeVirtualRoutersServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VirtualRoutersServer will
+// result in compilation errors.
+type UnsafeVirtualRoutersServer interface {
+	mustEmbedUnimplementedVirtualRoutersServer()
+}
+
+func RegisterVirtualRoutersServer(s grpc.ServiceRegistrar, srv VirtualRoutersServer) {
+	s.RegisterService(&VirtualRouters_ServiceDesc, srv)
+}
+
+func _VirtualRouters_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtualRoutersServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VirtualRouters_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtualRoutersServer).Create(ctx, req.(*CreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtualRouters_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RouterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtualRoutersServer).Start(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VirtualRouters_Start_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtualRoutersServer).Start(ctx, req.(*RouterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtualRouters_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RouterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtualRoutersServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VirtualRouters_Stop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtualRoutersServer).Stop(ctx, req.(*RouterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtualRouters_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RouterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtualRoutersServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VirtualRouters_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtualRoutersServer).Delete(ctx, req.(*RouterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtualRouters_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtualRoutersServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VirtualRouters_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtualRoutersServer).Update(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtualRouters_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RouterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtualRoutersServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VirtualRouters_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtualRoutersServer).Get(ctx, req.(*RouterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtualRouters_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtualRoutersServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VirtualRouters_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtualRoutersServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtualRouters_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VirtualRoutersServer).Watch(m, &virtualRoutersWatchServer{ServerStream: stream})
+}
+
+type VirtualRouters_WatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type virtualRoutersWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *virtualRoutersWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// VirtualRouters_ServiceDesc is the grpc.ServiceDesc for VirtualRouters service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VirtualRouters_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vrrp.v1.VirtualRouters",
+	HandlerType: (*VirtualRoutersServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _VirtualRouters_Create_Handler,
+		},
+		{
+			MethodName: "Start",
+			Handler:    _VirtualRouters_Start_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _VirtualRouters_Stop_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _VirtualRouters_Delete_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _VirtualRouters_Update_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _VirtualRouters_Get_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _VirtualRouters_List_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _VirtualRouters_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "vrrp.proto",
+}
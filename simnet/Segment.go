@@ -0,0 +1,376 @@
+// Package simnet provides an in-process network segment that carries VRRP advertisements
+// between virtual routers, it implements vrrp.IPConnection and vrrp.AddrAnnouncer so the
+// state machine can be exercised without raw sockets
+package simnet
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"time"
+	"vrrp-go/vrrp"
+)
+
+const endpointQueueSize = 1000
+
+// Announcement records one gratuitous ARP or unsolicited neighbor advertisement
+type Announcement struct {
+	VRID         byte
+	Source       net.IP
+	Addr         net.IP
+	HardwareAddr net.HardwareAddr
+	Time         time.Time
+}
+
+// Segment is a simulated broadcast domain, every advertisement written by an endpoint
+// is delivered to all the other endpoints attached to the same segment
+type Segment struct {
+	mu            sync.Mutex
+	endpoints     []*Endpoint
+	group         map[*Endpoint]int
+	loss          float64
+	duplicate     float64
+	delay         time.Duration
+	clock         vrrp.Clock
+	random        *rand.Rand
+	announcements []Announcement
+}
+
+// Endpoint is the attachment of one virtual router to the segment
+type Endpoint struct {
+	segment *Segment
+	addr    net.IP
+	ipvX    byte
+	queue   chan *vrrp.VRRPPacket
+	closed  chan struct{}
+	once    sync.Once
+	//expired is closed when the read deadline passes, it's replaced by the next SetReadDeadline
+	mutex   sync.Mutex
+	expired chan struct{}
+	timer   *time.Timer
+	//the clock is held for the queued advertisements and the one taken by the reader last, while the
+	//endpoint is read, from the first read or the clearing of the read deadline until a read fails
+	reading bool
+	held    []holder
+	taken   []holder
+}
+
+// holder is implemented by a clock which waits for the advertisements on the way, e.g. vrrp.FakeClock
+type holder interface {
+	Hold()
+	Release()
+}
+
+// NewSegment create an empty segment without loss, duplication or delay
+func NewSegment() *Segment {
+	return &Segment{
+		group:  make(map[*Endpoint]int),
+		clock:  vrrp.SystemClock,
+		random: rand.New(rand.NewSource(1)),
+	}
+}
+
+// Attach create an endpoint with source address addr on the segment
+func (s *Segment) Attach(addr net.IP) *Endpoint {
+	var e = &Endpoint{
+		segment: s,
+		addr:    addr.To16(),
+		ipvX:    vrrp.IPv6,
+		queue:   make(chan *vrrp.VRRPPacket, endpointQueueSize),
+		closed:  make(chan struct{}),
+		expired: make(chan struct{}),
+	}
+	if addr.To4() != nil {
+		e.ipvX = vrrp.IPv4
+	}
+	s.mu.Lock()
+	s.endpoints = append(s.endpoints, e)
+	s.mu.Unlock()
+	return e
+}
+
+// Seed reset the random source deciding loss and duplication
+func (s *Segment) Seed(seed int64) {
+	s.mu.Lock()
+	s.random = rand.New(rand.NewSource(seed))
+	s.mu.Unlock()
+}
+
+// SetLoss set the probability that one copy of an advertisement is dropped
+func (s *Segment) SetLoss(rate float64) {
+	s.mu.Lock()
+	s.loss = rate
+	s.mu.Unlock()
+}
+
+// SetDuplicate set the probability that an advertisement is delivered twice
+func (s *Segment) SetDuplicate(rate float64) {
+	s.mu.Lock()
+	s.duplicate = rate
+	s.mu.Unlock()
+}
+
+// SetDelay set the latency of every delivery
+func (s *Segment) SetDelay(delay time.Duration) {
+	s.mu.Lock()
+	s.delay = delay
+	s.mu.Unlock()
+}
+
+// SetClock set the clock measuring delay and timestamping announcements,
+// it should be the same clock driving the attached virtual routers
+func (s *Segment) SetClock(clock vrrp.Clock) {
+	s.mu.Lock()
+	s.clock = clock
+	s.mu.Unlock()
+}
+
+// Partition split the segment, endpoints in different groups can't reach each other,
+// endpoints not listed in any group form a group of their own
+func (s *Segment) Partition(groups ...[]*Endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.group = make(map[*Endpoint]int)
+	for index := range groups {
+		for _, e := range groups[index] {
+			s.group[e] = index + 1
+		}
+	}
+}
+
+// Heal remove all partitions
+func (s *Segment) Heal() {
+	s.Partition()
+}
+
+// Announcements return all the announcements recorded so far
+func (s *Segment) Announcements() []Announcement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Announcement(nil), s.announcements...)
+}
+
+// transmit deliver octets written by src to every reachable endpoint
+func (s *Segment) transmit(src *Endpoint, octets []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, dst := range s.endpoints {
+		if dst == src || s.group[dst] != s.group[src] {
+			continue
+		}
+		var copies = 1
+		if s.random.Float64() < s.duplicate {
+			copies++
+		}
+		for ; copies > 0; copies-- {
+			if s.random.Float64() < s.loss {
+				continue
+			}
+			if s.delay > 0 {
+				var dst, clock, timer = dst, s.clock, s.clock.NewTimer(s.delay)
+				go func() {
+					<-timer.C()
+					dst.deliver(clock, src.addr, octets)
+					vrrp.Handled(timer)
+				}()
+			} else {
+				dst.deliver(s.clock, src.addr, octets)
+			}
+		}
+	}
+}
+
+// deliver decode octets the way the IP layer does and push the advertisement into the queue,
+// clock is held until the reader is done with it
+func (e *Endpoint) deliver(clock vrrp.Clock, saddr net.IP, octets []byte) {
+	var packet, errOfUnmarshal = vrrp.FromBytes(e.ipvX, octets)
+	if errOfUnmarshal != nil {
+		return
+	}
+	var pshdr = vrrp.PseudoHeader{
+		Saddr:    saddr,
+		Daddr:    vrrp.VRRPMultiAddrIPv6,
+		Protocol: vrrp.VRRPIPProtocolNumber,
+		Len:      uint16(len(octets)),
+	}
+	if e.ipvX == vrrp.IPv4 {
+		pshdr.Daddr = vrrp.VRRPMultiAddrIPv4
+	}
+	if !packet.ValidateCheckSum(&pshdr) {
+		return
+	}
+	packet.Pshdr = &pshdr
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	var h, holding = clock.(holder)
+	holding = holding && e.reading
+	if holding {
+		h.Hold()
+	}
+	select {
+	case <-e.closed:
+	case e.queue <- packet:
+		if holding {
+			e.held = append(e.held, h)
+			holding = false
+		}
+	default:
+		//queue is full, drop it like a socket buffer does
+	}
+	if holding {
+		h.Release()
+	}
+}
+
+// Addr return the source address of the endpoint
+func (e *Endpoint) Addr() net.IP {
+	return e.addr
+}
+
+func (e *Endpoint) WriteMessage(packet *vrrp.VRRPPacket) error {
+	select {
+	case <-e.closed:
+		return fmt.Errorf("Endpoint.WriteMessage: %w", net.ErrClosed)
+	default:
+	}
+	e.segment.transmit(e, packet.ToBytes())
+	return nil
+}
+
+// ReadMessage return the next advertisement, it fails with os.ErrDeadlineExceeded once the read deadline
+// passes and leaves the advertisements queued for the next read. The reader is done with the advertisement
+// it took last when it reads again or sets a read deadline, the clock is held for it until then.
+func (e *Endpoint) ReadMessage() (*vrrp.VRRPPacket, error) {
+	e.mutex.Lock()
+	e.taken = release(e.taken)
+	e.reading = true
+	var expired = e.expired
+	e.mutex.Unlock()
+	var packet, errOfRead = e.read(expired)
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if errOfRead != nil {
+		//the advertisements left wait for a reader which may never come
+		e.reading = false
+		e.held = release(e.held)
+	} else if len(e.held) > 0 {
+		e.taken = append(e.taken, e.held[0])
+		e.held = e.held[1:]
+	}
+	return packet, errOfRead
+}
+
+// read wait for the next advertisement until expired or the endpoint is closed
+func (e *Endpoint) read(expired chan struct{}) (*vrrp.VRRPPacket, error) {
+	select {
+	case <-expired:
+		return nil, fmt.Errorf("Endpoint.ReadMessage: %w", os.ErrDeadlineExceeded)
+	default:
+	}
+	select {
+	case <-e.closed:
+		return nil, fmt.Errorf("Endpoint.ReadMessage: %w", net.ErrClosed)
+	case <-expired:
+		return nil, fmt.Errorf("Endpoint.ReadMessage: %w", os.ErrDeadlineExceeded)
+	case packet := <-e.queue:
+		return packet, nil
+	}
+}
+
+// SetReadDeadline make the pending and the future ReadMessage fail after deadline, a zero deadline clears it.
+// The deadline is measured on the wall clock like the one of a socket.
+func (e *Endpoint) SetReadDeadline(deadline time.Time) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.taken = release(e.taken)
+	if deadline.IsZero() {
+		e.reading = true
+	}
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	select {
+	case <-e.expired:
+		e.expired = make(chan struct{})
+	default:
+	}
+	if deadline.IsZero() {
+		return nil
+	}
+	if wait := time.Until(deadline); wait > 0 {
+		var timer *time.Timer
+		timer = time.AfterFunc(wait, func() {
+			e.mutex.Lock()
+			defer e.mutex.Unlock()
+			//a timer stopped too late leaves the deadline set after it alone
+			if e.timer == timer {
+				close(e.expired)
+				e.timer = nil
+			}
+		})
+		e.timer = timer
+		return nil
+	}
+	close(e.expired)
+	return nil
+}
+
+// AnnounceAll record an announcement for every protected address of vr
+func (e *Endpoint) AnnounceAll(vr *vrrp.VirtualRouter) error {
+	select {
+	case <-e.closed:
+		return fmt.Errorf("Endpoint.AnnounceAll: %w", net.ErrClosed)
+	default:
+	}
+	e.record(vr, vr.ProtectedIPaddrs()...)
+	return nil
+}
+
+// AnnounceAddr record an announcement for addr
+func (e *Endpoint) AnnounceAddr(vr *vrrp.VirtualRouter, addr net.IP) error {
+	select {
+	case <-e.closed:
+		return fmt.Errorf("Endpoint.AnnounceAddr: %w", net.ErrClosed)
+	default:
+	}
+	e.record(vr, addr)
+	return nil
+}
+
+func (e *Endpoint) record(vr *vrrp.VirtualRouter, addrs ...net.IP) {
+	e.segment.mu.Lock()
+	defer e.segment.mu.Unlock()
+	var now = e.segment.clock.Now()
+	for _, addr := range addrs {
+		e.segment.announcements = append(e.segment.announcements, Announcement{
+			VRID:         vr.VRID(),
+			Source:       e.addr,
+			Addr:         addr,
+			HardwareAddr: vr.HardwareAddr(),
+			Time:         now,
+		})
+	}
+}
+
+// Close detach the endpoint, pending and future reads fail with net.ErrClosed
+func (e *Endpoint) Close() error {
+	e.once.Do(func() {
+		close(e.closed)
+	})
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.held = release(e.held)
+	e.taken = release(e.taken)
+	return nil
+}
+
+// release end the holds, the emptied slice is returned
+func release(holds []holder) []holder {
+	for _, h := range holds {
+		h.Release()
+	}
+	return holds[:0]
+}
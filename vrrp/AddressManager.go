@@ -0,0 +1,235 @@
+package vrrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+	"vrrp-go/logger"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// AddrInstaller configures the protected addresses on the host, the virtual router installs them
+// when it becomes MASTER and uninstalls them when it leaves MASTER
+type AddrInstaller interface {
+	InstallAddr(vr *VirtualRouter, ip net.IP) error
+	UninstallAddr(vr *VirtualRouter, ip net.IP) error
+}
+
+// AddressOptions describes how AddressManager configures the protected addresses
+type AddressOptions struct {
+	// PrefixLength of the installed addresses, a host prefix is used if zero
+	PrefixLength int
+	// Label of the installed IPv4 addresses, it must start with the interface name
+	Label string
+	// Scope of the installed addresses, netlink.SCOPE_UNIVERSE if zero
+	Scope netlink.Scope
+	// Namespace is the network namespace of the interface, the current one is used if nil
+	Namespace *netns.NsHandle
+	// ReconcileInterval is the period of full reconciliation by Watch, 10 seconds is used if zero
+	ReconcileInterval time.Duration
+}
+
+// AddressManager is the AddrInstaller adding and deleting the protected addresses on an interface
+// through netlink, Watch puts back the installed addresses deleted by someone else
+type AddressManager struct {
+	nif       string
+	options   AddressOptions
+	handle    *netlink.Handle
+	mutex     sync.Mutex
+	installed map[[16]byte]bool
+}
+
+// NewAddressManager create an AddressManager configuring addresses on interface nif
+func NewAddressManager(nif string, options AddressOptions) (*AddressManager, error) {
+	var handle *netlink.Handle
+	var errOfHandle error
+	if options.Namespace != nil {
+		handle, errOfHandle = netlink.NewHandleAt(*options.Namespace)
+	} else {
+		handle, errOfHandle = netlink.NewHandle()
+	}
+	if errOfHandle != nil {
+		return nil, fmt.Errorf("NewAddressManager: %w", socketError(errOfHandle))
+	}
+	if _, errOfGetLink := handle.LinkByName(nif); errOfGetLink != nil {
+		handle.Close()
+		return nil, fmt.Errorf("NewAddressManager: %w: %v", ErrInterfaceNotFound, errOfGetLink)
+	}
+	if options.ReconcileInterval == 0 {
+		options.ReconcileInterval = 10 * time.Second
+	}
+	return &AddressManager{
+		nif:       nif,
+		options:   options,
+		handle:    handle,
+		installed: make(map[[16]byte]bool),
+	}, nil
+}
+
+// Close release the netlink socket, the installed addresses are left on the interface
+func (m *AddressManager) Close() error {
+	m.handle.Close()
+	return nil
+}
+
+// InstallAddr add ip to the interface
+func (m *AddressManager) InstallAddr(vr *VirtualRouter, ip net.IP) error {
+	var key [16]byte
+	copy(key[:], ip.To16())
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.installed[key] = true
+	if errOfAdd := m.add(ip); errOfAdd != nil {
+		return fmt.Errorf("AddressManager.InstallAddr: %w", errOfAdd)
+	}
+	logger.GLoger.Printf(logger.INFO, "IP %v installed on %v", ip, m.nif)
+	return nil
+}
+
+// UninstallAddr delete ip from the interface, it's not an error if ip is gone already
+func (m *AddressManager) UninstallAddr(vr *VirtualRouter, ip net.IP) error {
+	var key [16]byte
+	copy(key[:], ip.To16())
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.installed, key)
+	var link, errOfGetLink = m.handle.LinkByName(m.nif)
+	if errOfGetLink != nil {
+		return fmt.Errorf("AddressManager.UninstallAddr: %w: %v", ErrInterfaceNotFound, errOfGetLink)
+	}
+	if errOfDel := m.handle.AddrDel(link, m.addr(ip)); errOfDel != nil && !errors.Is(errOfDel, syscall.EADDRNOTAVAIL) && !errors.Is(errOfDel, syscall.ESRCH) {
+		return fmt.Errorf("AddressManager.UninstallAddr: %w", socketError(errOfDel))
+	}
+	logger.GLoger.Printf(logger.INFO, "IP %v uninstalled from %v", ip, m.nif)
+	return nil
+}
+
+// Installed return the addresses that should be on the interface
+func (m *AddressManager) Installed() []net.IP {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var addrs = make([]net.IP, 0, len(m.installed))
+	for key := range m.installed {
+		addrs = append(addrs, net.IP(append([]byte(nil), key[:]...)))
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return largerThan(addrs[j], addrs[i])
+	})
+	return addrs
+}
+
+// Reconcile add the installed addresses missing from the interface
+func (m *AddressManager) Reconcile() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(m.installed) == 0 {
+		return nil
+	}
+	var link, errOfGetLink = m.handle.LinkByName(m.nif)
+	if errOfGetLink != nil {
+		return fmt.Errorf("AddressManager.Reconcile: %w: %v", ErrInterfaceNotFound, errOfGetLink)
+	}
+	var present, errOfList = m.handle.AddrList(link, netlink.FAMILY_ALL)
+	if errOfList != nil {
+		return fmt.Errorf("AddressManager.Reconcile: %w", errOfList)
+	}
+	var onLink = make(map[[16]byte]bool)
+	for _, addr := range present {
+		var key [16]byte
+		copy(key[:], addr.IP.To16())
+		onLink[key] = true
+	}
+	for key := range m.installed {
+		if onLink[key] {
+			continue
+		}
+		var ip = net.IP(append([]byte(nil), key[:]...))
+		logger.GLoger.Printf(logger.INFO, "IP %v is missing from %v, install it again", ip, m.nif)
+		if errOfAdd := m.add(ip); errOfAdd != nil {
+			return fmt.Errorf("AddressManager.Reconcile: %w", errOfAdd)
+		}
+	}
+	return nil
+}
+
+// Watch reconcile the interface whenever an address is deleted from it and every
+// ReconcileInterval, until ctx is done
+func (m *AddressManager) Watch(ctx context.Context) error {
+	var updates, done = make(chan netlink.AddrUpdate), make(chan struct{})
+	defer close(done)
+	var errOfSubscribe = netlink.AddrSubscribeWithOptions(updates, done, netlink.AddrSubscribeOptions{
+		Namespace: m.options.Namespace,
+		ErrorCallback: func(err error) {
+			select {
+			case <-done:
+				//the subscription is being torn down
+			default:
+				logger.GLoger.Printf(logger.ERROR, "AddressManager.Watch: %v", err)
+			}
+		},
+	})
+	if errOfSubscribe != nil {
+		return fmt.Errorf("AddressManager.Watch: %w", socketError(errOfSubscribe))
+	}
+	var ticker = time.NewTicker(m.options.ReconcileInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case update, ok := <-updates:
+			if !ok {
+				return fmt.Errorf("AddressManager.Watch: netlink subscription closed")
+			}
+			if update.NewAddr {
+				continue
+			}
+		case <-ticker.C:
+		}
+		if errOfReconcile := m.Reconcile(); errOfReconcile != nil {
+			logger.GLoger.Printf(logger.ERROR, "AddressManager.Watch: %v", errOfReconcile)
+		}
+	}
+}
+
+// add configure ip on the interface, m.mutex must be held
+func (m *AddressManager) add(ip net.IP) error {
+	var link, errOfGetLink = m.handle.LinkByName(m.nif)
+	if errOfGetLink != nil {
+		return fmt.Errorf("%w: %v", ErrInterfaceNotFound, errOfGetLink)
+	}
+	if errOfAdd := m.handle.AddrReplace(link, m.addr(ip)); errOfAdd != nil {
+		return socketError(errOfAdd)
+	}
+	return nil
+}
+
+// addr make the netlink address of ip with the configured options
+func (m *AddressManager) addr(ip net.IP) *netlink.Addr {
+	var bits = 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+	}
+	var prefix = m.options.PrefixLength
+	if prefix == 0 || prefix > bits {
+		prefix = bits
+	}
+	var addr = &netlink.Addr{
+		IPNet: &net.IPNet{IP: ip, Mask: net.CIDRMask(prefix, bits)},
+		Scope: int(m.options.Scope),
+	}
+	if bits == 32 {
+		addr.Label = m.options.Label
+	} else {
+		//the address must be usable at once, skip duplicate address detection
+		addr.Flags = syscall.IFA_F_NODAD
+	}
+	return addr
+}
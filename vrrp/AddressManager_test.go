@@ -0,0 +1,136 @@
+package vrrp_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+// newTestNamespace create a throwaway network namespace with the veth pair v0/v1
+func newTestNamespace(t *testing.T) (netns.NsHandle, *netlink.Handle) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("network namespaces require root")
+	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	var origin, errOfGet = netns.Get()
+	if errOfGet != nil {
+		t.Skipf("can't get the current network namespace: %v", errOfGet)
+	}
+	defer origin.Close()
+	var ns, errOfNew = netns.New()
+	if errOfNew != nil {
+		t.Skipf("can't create network namespace: %v", errOfNew)
+	}
+	if err := netns.Set(origin); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ns.Close() })
+	var handle, errOfHandle = netlink.NewHandleAt(ns)
+	if errOfHandle != nil {
+		t.Fatal(errOfHandle)
+	}
+	t.Cleanup(handle.Close)
+	var veth = &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "v0"}, PeerName: "v1"}
+	if err := handle.LinkAdd(veth); err != nil {
+		t.Skipf("can't create veth pair: %v", err)
+	}
+	for _, name := range []string{"v0", "v1"} {
+		var link, err = handle.LinkByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = handle.LinkSetUp(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ns, handle
+}
+
+func hasAddr(t *testing.T, handle *netlink.Handle, nif string, want string) bool {
+	t.Helper()
+	var link, errOfGetLink = handle.LinkByName(nif)
+	if errOfGetLink != nil {
+		t.Fatal(errOfGetLink)
+	}
+	var addrs, errOfList = handle.AddrList(link, netlink.FAMILY_V4)
+	if errOfList != nil {
+		t.Fatal(errOfList)
+	}
+	for _, addr := range addrs {
+		if addr.IPNet.String() == want && addr.Label == "v0:vip" {
+			return true
+		}
+	}
+	return false
+}
+
+func awaitAddr(t *testing.T, handle *netlink.Handle, want string, present bool) {
+	t.Helper()
+	var deadline = time.Now().Add(2 * time.Second)
+	for hasAddr(t, handle, "v0", want) != present {
+		if time.Now().After(deadline) {
+			t.Fatalf("presence of %v on v0 is not %v", want, present)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAddressManagerInstallsVIPs(t *testing.T) {
+	var ns, handle = newTestNamespace(t)
+	var manager, errOfNew = vrrp.NewAddressManager("v0", vrrp.AddressOptions{
+		PrefixLength:      24,
+		Label:             "v0:vip",
+		Namespace:         &ns,
+		ReconcileInterval: time.Hour,
+	})
+	if errOfNew != nil {
+		t.Fatal(errOfNew)
+	}
+	defer manager.Close()
+	var ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go manager.Watch(ctx)
+
+	var seg = simnet.NewSegment()
+	var node = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:          1,
+		IPvX:          vrrp.IPv4,
+		SourceIP:      net.ParseIP("10.0.0.1"),
+		Addresses:     []net.IP{net.ParseIP("192.168.1.254")},
+		AddrInstaller: manager,
+	})
+	var vr, rec = node.vr, node.rec
+	rec.await(t, vrrp.Backup2Master, time.Second)
+	awaitAddr(t, handle, "192.168.1.254/24", true)
+
+	vr.AddIPvXAddr(net.ParseIP("192.168.2.254"))
+	awaitAddr(t, handle, "192.168.2.254/24", true)
+	vr.RemoveIPvXAddr(net.ParseIP("192.168.2.254"))
+	awaitAddr(t, handle, "192.168.2.254/24", false)
+
+	//someone deletes the address by hand
+	var link, _ = handle.LinkByName("v0")
+	var addr, _ = netlink.ParseAddr("192.168.1.254/24")
+	if err := handle.AddrDel(link, addr); err != nil {
+		t.Fatal(err)
+	}
+	awaitAddr(t, handle, "192.168.1.254/24", true)
+
+	//a higher priority router takes over
+	startNode(t, seg, "10.0.0.2", 200, false)
+	rec.await(t, vrrp.Master2Backup, time.Second)
+	awaitAddr(t, handle, "192.168.1.254/24", false)
+	if installed := manager.Installed(); len(installed) != 0 {
+		t.Fatalf("Installed() = %v after leaving MASTER", installed)
+	}
+}
@@ -0,0 +1,163 @@
+package vrrp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// AuthTrailerLength is the length of the trailer carried by an authenticated VRRPv3 advertisement
+const AuthTrailerLength = 44
+
+// AuthTrailer follows the IPvX addresses of an authenticated VRRPv3 advertisement:
+// key ID (1 octet), reserved (3 octets), sequence number (8 octets) and HMAC-SHA256 (32 octets)
+type AuthTrailer struct {
+	KeyID    byte
+	Sequence uint64
+	MAC      [sha256.Size]byte
+}
+
+func (trailer *AuthTrailer) toBytes() []byte {
+	var octets = make([]byte, AuthTrailerLength)
+	octets[0] = trailer.KeyID
+	binary.BigEndian.PutUint64(octets[4:], trailer.Sequence)
+	copy(octets[12:], trailer.MAC[:])
+	return octets
+}
+
+func (trailer *AuthTrailer) fromBytes(octets []byte) {
+	trailer.KeyID = octets[0]
+	trailer.Sequence = binary.BigEndian.Uint64(octets[4:])
+	copy(trailer.MAC[:], octets[12:])
+}
+
+// AuthKey is a shared key of the authenticated mode
+type AuthKey struct {
+	ID     byte
+	Secret []byte
+}
+
+// Authenticator signs the VRRPv3 advertisements with the HMAC-SHA256 of a shared key and rejects the
+// advertisements which are unsigned, signed with an unknown key or not newer than the last one from
+// the same source. The HMAC covers the source address, the advertisement without its check sum,
+// the key ID and the sequence number. The sequence number starts from the wall clock in nanoseconds,
+// so it keeps increasing across restarts. The sequence numbers seen are forgotten whenever the virtual
+// router finds its master down, so a peer restarted after its clock stepped back is accepted again
+// once the Master_Down_Timer fires.
+//
+// At most two keys are active to roll the key over without losing the election: configure
+// SetKeys(old, new) on every router, then SetKeys(new, old), then SetKeys(new).
+type Authenticator struct {
+	mutex    sync.Mutex
+	keys     []AuthKey
+	sequence uint64
+	lastSeen map[authSource]uint64
+}
+
+type authSource struct {
+	addr [16]byte
+	VRID byte
+}
+
+// NewAuthenticator create an Authenticator signing with the first key and accepting all the keys
+func NewAuthenticator(keys ...AuthKey) (*Authenticator, error) {
+	var a = &Authenticator{
+		sequence: uint64(time.Now().UnixNano()),
+		lastSeen: make(map[authSource]uint64),
+	}
+	if errOfSet := a.SetKeys(keys...); errOfSet != nil {
+		return nil, fmt.Errorf("NewAuthenticator: %w", errOfSet)
+	}
+	return a, nil
+}
+
+// SetKeys replace the active keys, the first key signs the advertisements and all of them
+// are accepted. One or two keys with distinct IDs and non-empty secrets are allowed.
+func (a *Authenticator) SetKeys(keys ...AuthKey) error {
+	if len(keys) == 0 || len(keys) > 2 {
+		return fmt.Errorf("%w: %v keys, one or two keys are allowed", ErrInvalidKey, len(keys))
+	}
+	if len(keys) == 2 && keys[0].ID == keys[1].ID {
+		return fmt.Errorf("%w: duplicate key ID %v", ErrInvalidKey, keys[0].ID)
+	}
+	var copied = make([]AuthKey, 0, len(keys))
+	for _, key := range keys {
+		if len(key.Secret) == 0 {
+			return fmt.Errorf("%w: empty secret of key %v", ErrInvalidKey, key.ID)
+		}
+		copied = append(copied, AuthKey{ID: key.ID, Secret: append([]byte(nil), key.Secret...)})
+	}
+	a.mutex.Lock()
+	a.keys = copied
+	a.mutex.Unlock()
+	return nil
+}
+
+// Sign attach the trailer to the VRRPv3 packet sent from saddr, the check sum must be set afterwards
+func (a *Authenticator) Sign(packet *VRRPPacket, saddr net.IP) {
+	a.mutex.Lock()
+	a.sequence++
+	var trailer = &AuthTrailer{KeyID: a.keys[0].ID, Sequence: a.sequence}
+	var secret = a.keys[0].Secret
+	a.mutex.Unlock()
+	copy(trailer.MAC[:], authMAC(secret, packet, saddr, trailer))
+	packet.Trailer = trailer
+}
+
+// Verify check the trailer of the received packet and remember its sequence number
+func (a *Authenticator) Verify(packet *VRRPPacket) error {
+	if packet.Trailer == nil || packet.Pshdr == nil {
+		return fmt.Errorf("Authenticator.Verify: %w: no trailer", ErrUnauthenticated)
+	}
+	var trailer = packet.Trailer
+	var source = authSource{VRID: packet.GetVirtualRouterID()}
+	copy(source.addr[:], packet.Pshdr.Saddr.To16())
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	var secret []byte
+	for _, key := range a.keys {
+		if key.ID == trailer.KeyID {
+			secret = key.Secret
+		}
+	}
+	if secret == nil {
+		return fmt.Errorf("Authenticator.Verify: %w: unknown key %v from %v", ErrUnauthenticated, trailer.KeyID, packet.Pshdr.Saddr)
+	}
+	if !hmac.Equal(trailer.MAC[:], authMAC(secret, packet, packet.Pshdr.Saddr, trailer)) {
+		return fmt.Errorf("Authenticator.Verify: %w: bad HMAC from %v", ErrUnauthenticated, packet.Pshdr.Saddr)
+	}
+	if last, ok := a.lastSeen[source]; ok && trailer.Sequence <= last {
+		return fmt.Errorf("Authenticator.Verify: %w: sequence %v from %v, last %v", ErrReplayed, trailer.Sequence, packet.Pshdr.Saddr, last)
+	}
+	a.lastSeen[source] = trailer.Sequence
+	return nil
+}
+
+// forget drop the sequence numbers seen from the sources of VRID
+func (a *Authenticator) forget(VRID byte) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for source := range a.lastSeen {
+		if source.VRID == VRID {
+			delete(a.lastSeen, source)
+		}
+	}
+}
+
+// authMAC compute the HMAC of the packet sent from saddr, the check sum is left out
+// since it covers the destination address which differs between the peers in unicast mode
+func authMAC(secret []byte, packet *VRRPPacket, saddr net.IP, trailer *AuthTrailer) []byte {
+	var unsigned = *packet
+	unsigned.Header[6], unsigned.Header[7] = 0, 0
+	unsigned.Trailer = nil
+	var mac = hmac.New(sha256.New, secret)
+	mac.Write(saddr.To16())
+	mac.Write(unsigned.ToBytes())
+	var octets = trailer.toBytes()
+	mac.Write(octets[:12])
+	return mac.Sum(nil)
+}
@@ -0,0 +1,214 @@
+package vrrp_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+func newAuthenticator(t *testing.T, keys ...vrrp.AuthKey) *vrrp.Authenticator {
+	t.Helper()
+	var a, err = vrrp.NewAuthenticator(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// signedPacket sign an advertisement with a and decode it the way the receiver does
+func signedPacket(t *testing.T, a *vrrp.Authenticator, saddr string, priority byte) *vrrp.VRRPPacket {
+	t.Helper()
+	var packet vrrp.VRRPPacket
+	packet.SetVersion(vrrp.VRRPv3)
+	packet.SetType()
+	packet.SetVirtualRouterID(1)
+	packet.SetPriority(priority)
+	packet.SetAdvertisementInterval(100)
+	packet.AddIPvXAddr(vrrp.IPv4, net.ParseIP("192.168.1.254"))
+	a.Sign(&packet, net.ParseIP(saddr))
+	var pshdr = &vrrp.PseudoHeader{Saddr: net.ParseIP(saddr), Daddr: vrrp.VRRPMultiAddrIPv4, Protocol: vrrp.VRRPIPProtocolNumber, Len: uint16(len(packet.ToBytes()))}
+	packet.SetCheckSum(pshdr)
+	var received, err = vrrp.FromBytes(vrrp.IPv4, packet.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !received.ValidateCheckSum(pshdr) {
+		t.Fatal("invalid check sum")
+	}
+	received.Pshdr = pshdr
+	return received
+}
+
+func TestAuthenticatorRejectsForgedAndReplayed(t *testing.T) {
+	var key = vrrp.AuthKey{ID: 1, Secret: []byte("secret")}
+	var sender, receiver = newAuthenticator(t, key), newAuthenticator(t, key)
+	var first, second = signedPacket(t, sender, "10.0.0.1", 100), signedPacket(t, sender, "10.0.0.1", 100)
+	if err := receiver.Verify(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiver.Verify(second); !errors.Is(err, vrrp.ErrReplayed) {
+		t.Fatalf("replayed advertisement: got %v", err)
+	}
+	if err := receiver.Verify(first); !errors.Is(err, vrrp.ErrReplayed) {
+		t.Fatalf("older advertisement: got %v", err)
+	}
+	var forged = signedPacket(t, sender, "10.0.0.1", 100)
+	forged.SetPriority(254)
+	if err := receiver.Verify(forged); !errors.Is(err, vrrp.ErrUnauthenticated) {
+		t.Fatalf("forged priority: got %v", err)
+	}
+	var spoofed = signedPacket(t, sender, "10.0.0.1", 100)
+	spoofed.Pshdr.Saddr = net.ParseIP("10.0.0.9")
+	if err := receiver.Verify(spoofed); !errors.Is(err, vrrp.ErrUnauthenticated) {
+		t.Fatalf("spoofed source: got %v", err)
+	}
+	var unsigned = signedPacket(t, sender, "10.0.0.1", 100)
+	unsigned.Trailer = nil
+	if err := receiver.Verify(unsigned); !errors.Is(err, vrrp.ErrUnauthenticated) {
+		t.Fatalf("unsigned advertisement: got %v", err)
+	}
+}
+
+func TestAuthenticatorKeyRollover(t *testing.T) {
+	var oldKey, newKey = vrrp.AuthKey{ID: 1, Secret: []byte("old")}, vrrp.AuthKey{ID: 2, Secret: []byte("new")}
+	var a, b = newAuthenticator(t, oldKey), newAuthenticator(t, oldKey)
+	//both keys are accepted on b while a still signs with the old one
+	if err := b.SetKeys(oldKey, newKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Verify(signedPacket(t, a, "10.0.0.1", 100)); err != nil {
+		t.Fatal(err)
+	}
+	//a switches to the new key
+	if err := a.SetKeys(newKey, oldKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Verify(signedPacket(t, a, "10.0.0.1", 100)); err != nil {
+		t.Fatal(err)
+	}
+	//the old key is retired
+	if err := b.SetKeys(newKey); err != nil {
+		t.Fatal(err)
+	}
+	var c = newAuthenticator(t, oldKey)
+	if err := b.Verify(signedPacket(t, c, "10.0.0.2", 100)); !errors.Is(err, vrrp.ErrUnauthenticated) {
+		t.Fatalf("retired key: got %v", err)
+	}
+	for _, keys := range [][]vrrp.AuthKey{nil, {oldKey, oldKey}, {oldKey, newKey, {ID: 3, Secret: []byte("x")}}, {{ID: 4}}} {
+		if err := b.SetKeys(keys...); !errors.Is(err, vrrp.ErrInvalidKey) {
+			t.Fatalf("SetKeys(%v): got %v", keys, err)
+		}
+	}
+}
+
+func TestAuthenticatedElection(t *testing.T) {
+	var key = vrrp.AuthKey{ID: 1, Secret: []byte("secret")}
+	var seg = simnet.NewSegment()
+	var backup = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:          1,
+		IPvX:          vrrp.IPv4,
+		Priority:      50,
+		SourceIP:      net.ParseIP("10.0.0.1"),
+		Authenticator: newAuthenticator(t, key),
+	})
+	var master = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:          1,
+		IPvX:          vrrp.IPv4,
+		Priority:      100,
+		SourceIP:      net.ParseIP("10.0.0.2"),
+		Authenticator: newAuthenticator(t, key),
+	})
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	backup.rec.never(t, 5*testInterval, vrrp.Backup2Master)
+
+	//neither an unauthenticated router nor one with another key can take over
+	startNode(t, seg, "10.0.0.3", 254, false)
+	startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:          1,
+		IPvX:          vrrp.IPv4,
+		Priority:      254,
+		SourceIP:      net.ParseIP("10.0.0.4"),
+		Authenticator: newAuthenticator(t, vrrp.AuthKey{ID: 1, Secret: []byte("guess")}),
+	})
+	master.rec.never(t, 10*testInterval, vrrp.Master2Backup)
+	if status := backup.vr.Status(); !status.MasterIP.Equal(net.ParseIP("10.0.0.2")) {
+		t.Fatalf("backup follows %v", status.MasterIP)
+	}
+}
+
+func TestAuthenticatedModeRefusesVRRPv2(t *testing.T) {
+	var endpoint = simnet.NewSegment().Attach(net.ParseIP("10.0.0.1"))
+	var cfg = vrrp.Config{
+		VRID:          1,
+		IPvX:          vrrp.IPv4,
+		SourceIP:      endpoint.Addr(),
+		Connection:    endpoint,
+		Announcer:     endpoint,
+		Authenticator: newAuthenticator(t, vrrp.AuthKey{ID: 1, Secret: []byte("secret")}),
+	}
+	var vr, err = vrrp.New(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = vr.UpdateVersion(vrrp.VRRPv2); !errors.Is(err, vrrp.ErrInvalidVersion) {
+		t.Fatalf("UpdateVersion of an authenticated virtual router returned %v", err)
+	}
+	if err = vr.UpdateV2Compatibility(true); !errors.Is(err, vrrp.ErrInvalidVersion) {
+		t.Fatalf("UpdateV2Compatibility of an authenticated virtual router returned %v", err)
+	}
+	var v2, compatible = cfg, cfg
+	v2.Version, compatible.V2Compatibility = vrrp.VRRPv2, true
+	for _, c := range []*vrrp.Config{&v2, &compatible} {
+		if _, err = vrrp.New(c); !errors.Is(err, vrrp.ErrInvalidVersion) {
+			t.Fatalf("New of an authenticated virtual router returned %v", err)
+		}
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("SetV2Compatibility of an authenticated virtual router didn't panic")
+			}
+		}()
+		vr.SetV2Compatibility(true)
+	}()
+}
+
+func TestAuthenticatedPeerRestartsWithClockStepBack(t *testing.T) {
+	var key = vrrp.AuthKey{ID: 1, Secret: []byte("secret")}
+	//the sequence numbers of the restarted master start below those it signed before
+	var restarted = newAuthenticator(t, key)
+	var seg = simnet.NewSegment()
+	var master = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:          1,
+		IPvX:          vrrp.IPv4,
+		Priority:      100,
+		SourceIP:      net.ParseIP("10.0.0.2"),
+		Authenticator: newAuthenticator(t, key),
+	})
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:          1,
+		IPvX:          vrrp.IPv4,
+		Priority:      50,
+		SourceIP:      net.ParseIP("10.0.0.1"),
+		Authenticator: newAuthenticator(t, key),
+	})
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	backup.rec.never(t, 5*testInterval, vrrp.Backup2Master)
+
+	master.vr.Stop()
+	master.endpoint.Close()
+	backup.rec.await(t, vrrp.Backup2Master, time.Second)
+	startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:          1,
+		IPvX:          vrrp.IPv4,
+		Priority:      100,
+		SourceIP:      net.ParseIP("10.0.0.2"),
+		Authenticator: restarted,
+	})
+	backup.rec.await(t, vrrp.Master2Backup, 2*time.Second)
+}
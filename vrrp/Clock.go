@@ -0,0 +1,303 @@
+package vrrp
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source of the virtual router, timers of the state machine are created by it
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	NewTimer(d time.Duration) Timer
+}
+
+// Ticker is the abstraction of time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer is the abstraction of time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// firingHandler is implemented by the timers and tickers of a Clock which waits until each firing is handled
+type firingHandler interface {
+	handled()
+}
+
+// Handled report that the firing just received from t is handled, t is a Timer or a Ticker.
+// A FakeClock moves on once its firing is handled, nothing is done for the other clocks.
+func Handled(t interface{}) {
+	if handler, ok := t.(firingHandler); ok {
+		handler.handled()
+	}
+}
+
+// holder is implemented by a Clock which waits for the events held on the way before it moves on
+type holder interface {
+	Hold()
+	Release()
+}
+
+// holdClock keep c from moving on until releaseClock is called, if c waits for the events on the way
+func holdClock(c Clock) {
+	if h, ok := c.(holder); ok {
+		h.Hold()
+	}
+}
+
+// releaseClock end a holdClock
+func releaseClock(c Clock) {
+	if h, ok := c.(holder); ok {
+		h.Release()
+	}
+}
+
+// SystemClock is the Clock backed by package time
+var SystemClock Clock = realClock{}
+
+// realClock is the Clock backed by package time
+type realClock struct{}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// FakeClock is a Clock whose time only moves when Advance is called,
+// it's used to drive the state machine in virtual time
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	holds  int
+	idle   chan struct{} //closed once the last hold is released
+}
+
+// fakeTimer implements Timer, period is zero unless it backs a fakeTicker.
+// firing is closed once the value sent last is handled or dropped.
+type fakeTimer struct {
+	clock  *FakeClock
+	c      chan time.Time
+	when   time.Time
+	period time.Duration
+	firing chan struct{}
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+// NewFakeClock create a FakeClock starting at start
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+	var t = &fakeTimer{clock: c, c: make(chan time.Time, 1), period: d}
+	c.mu.Lock()
+	t.when = c.now.Add(d)
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+	return fakeTicker{t}
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	var t = &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	c.mu.Lock()
+	t.when = c.now.Add(d)
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+	return t
+}
+
+// Advance move the clock forward by d, timers and tickers expiring in between fire in order.
+// The clock moves on once the receiver reports the firing handled by Handled, or once the timer is
+// stopped or reset before the value is taken, and once all the holds are released. A firing nobody
+// handles or a hold nobody releases is given up after FAKEFIRINGTIMEOUT.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	var target = c.now.Add(d)
+	c.mu.Unlock()
+	for {
+		c.mu.Lock()
+		var idle = c.idle
+		c.mu.Unlock()
+		if idle != nil {
+			awaitFake(idle)
+		}
+		c.mu.Lock()
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if !t.when.After(target) && (next == nil || t.when.Before(next.when)) {
+				next = t
+			}
+		}
+		if next == nil {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+		c.now = next.when
+		if next.period > 0 {
+			next.when = next.when.Add(next.period)
+		} else {
+			c.remove(next)
+		}
+		var firing chan struct{}
+		select {
+		case next.c <- c.now:
+			firing = make(chan struct{})
+			next.firing = firing
+		default:
+			//the last value isn't taken yet, the firing is skipped as by a time.Ticker
+		}
+		c.mu.Unlock()
+		if firing != nil {
+			awaitFake(firing)
+		}
+	}
+}
+
+// Hold keep Advance from firing the next timer until Release is called, a simulated network holds
+// the clock for the advertisements on the way so that they are handled before the time moves on
+func (c *FakeClock) Hold() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.holds == 0 {
+		c.idle = make(chan struct{})
+	}
+	c.holds++
+}
+
+// Release end a Hold
+func (c *FakeClock) Release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.holds == 0 {
+		panic("FakeClock.Release without Hold")
+	}
+	c.holds--
+	if c.holds == 0 {
+		close(c.idle)
+		c.idle = nil
+	}
+}
+
+// awaitFake wait until done is closed, for FAKEFIRINGTIMEOUT at most
+func awaitFake(done <-chan struct{}) {
+	var timer = time.NewTimer(FAKEFIRINGTIMEOUT)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
+
+// BlockUntil wait until at least n timers and tickers are active
+func (c *FakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		var active = len(c.timers)
+		c.mu.Unlock()
+		if active >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// remove drop t from the active timers, c.mu must be held
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for index := range c.timers {
+		if c.timers[index] == t {
+			c.timers = append(c.timers[:index], c.timers[index+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.drop()
+	return t.clock.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.drop()
+	var active = t.clock.remove(t)
+	t.when = t.clock.now.Add(d)
+	t.clock.timers = append(t.clock.timers, t)
+	return active
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
+
+func (t *fakeTimer) handled() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	if t.firing != nil {
+		close(t.firing)
+		t.firing = nil
+	}
+}
+
+// drop discard the value sent last if it isn't taken yet, the firing ends then. t.clock.mu must be held.
+func (t *fakeTimer) drop() {
+	if t.firing == nil {
+		return
+	}
+	select {
+	case <-t.c:
+		close(t.firing)
+		t.firing = nil
+	default:
+	}
+}
@@ -0,0 +1,213 @@
+package vrrp
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	"vrrp-go/logger"
+)
+
+// TransitionEvent describes one state transition of a virtual router
+type TransitionEvent struct {
+	VRID       byte
+	From       State
+	To         State
+	Transition Transition
+	Reason     TransitionReason
+	// MasterIP is the source address of the master after the transition, it's nil
+	// if the master is unknown
+	MasterIP net.IP
+	// Priority is the priority advertised by MasterIP
+	Priority byte
+	Time     time.Time
+}
+
+// DropPolicy decides which event is dropped when the queue of a subscription is full
+type DropPolicy int
+
+const (
+	// DropOldest discard the oldest queued event to make room for the new one
+	DropOldest DropPolicy = iota
+	// DropNewest discard the new event
+	DropNewest
+)
+
+// Subscription is a bounded queue of transition events, events are never delivered
+// to a subscriber from the event loop directly, so a slow subscriber can't stall advertisements
+type Subscription struct {
+	// C delivers the events, it's closed by Close
+	C        <-chan TransitionEvent
+	events   chan TransitionEvent
+	policy   DropPolicy
+	router   *VirtualRouter
+	dropped  uint64
+	overflow chan struct{}
+	once     sync.Once
+}
+
+// Subscribe create a subscription queueing at most size events, TRANSITIONQUEUESIZE is used
+// if size is not positive. The subscription must be closed once it's no longer consumed.
+func (r *VirtualRouter) Subscribe(size int, policy DropPolicy) *Subscription {
+	if size <= 0 {
+		size = TRANSITIONQUEUESIZE
+	}
+	var s = &Subscription{events: make(chan TransitionEvent, size), policy: policy, router: r, overflow: make(chan struct{})}
+	s.C = s.events
+	r.subscriberMutex.Lock()
+	r.subscribers[s] = struct{}{}
+	r.subscriberMutex.Unlock()
+	return s
+}
+
+// SubscribeFunc create a subscription calling handler for every event on a goroutine of its own,
+// handler may reconfigure the virtual router. Close stops the goroutine after the queued events
+// are handled.
+func (r *VirtualRouter) SubscribeFunc(size int, policy DropPolicy, handler func(TransitionEvent)) *Subscription {
+	var s = r.Subscribe(size, policy)
+	go func() {
+		for event := range s.C {
+			handler(event)
+		}
+	}()
+	return s
+}
+
+// Dropped return the number of events dropped because the queue was full
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Overflow return a channel closed as soon as the first event is dropped
+func (s *Subscription) Overflow() <-chan struct{} {
+	return s.overflow
+}
+
+// Close cancel the subscription and close C
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.router.subscriberMutex.Lock()
+		delete(s.router.subscribers, s)
+		close(s.events)
+		s.router.subscriberMutex.Unlock()
+	})
+}
+
+// offer queue event without blocking, s.router.subscriberMutex must be held
+func (s *Subscription) offer(event TransitionEvent) {
+	if offer(s.events, s.policy, event) {
+		return
+	}
+	if atomic.AddUint64(&s.dropped, 1) == 1 {
+		close(s.overflow)
+	}
+	logger.GLoger.Printf(logger.ERROR, "Subscription.offer: queue of virtual router %v is full, %v event dropped", event.VRID, event.Transition)
+}
+
+// offer queue event on events without blocking, false is returned if an event was dropped
+func offer[T any](events chan T, policy DropPolicy, event T) bool {
+	select {
+	case events <- event:
+		return true
+	default:
+	}
+	if policy == DropNewest {
+		return false
+	}
+	select {
+	case <-events:
+	default:
+	}
+	select {
+	case events <- event:
+	default:
+	}
+	return false
+}
+
+// publish deliver event to every subscription
+func (r *VirtualRouter) publish(event TransitionEvent) {
+	r.subscriberMutex.Lock()
+	defer r.subscriberMutex.Unlock()
+	for s := range r.subscribers {
+		s.offer(event)
+	}
+}
+
+// AdvertisementEvent summarizes a valid advertisement received by a virtual router
+type AdvertisementEvent struct {
+	VRID     byte
+	Source   net.IP
+	Version  VRRPVersion
+	Priority byte
+	// Interval is the advertisement interval carried by the advertisement
+	Interval  time.Duration
+	Addresses []net.IP
+	Time      time.Time
+}
+
+// AdvertisementSubscription is a bounded queue of the advertisements received by a virtual router,
+// the advertisements are offered from the reader of the connection and never block it
+type AdvertisementSubscription struct {
+	// C delivers the advertisements, it's closed by Close
+	C       <-chan AdvertisementEvent
+	events  chan AdvertisementEvent
+	policy  DropPolicy
+	router  *VirtualRouter
+	dropped uint64
+	once    sync.Once
+}
+
+// SubscribeAdvertisements create a subscription queueing at most size advertisements, PACKETQUEUESIZE
+// is used if size is not positive. The subscription must be closed once it's no longer consumed.
+func (r *VirtualRouter) SubscribeAdvertisements(size int, policy DropPolicy) *AdvertisementSubscription {
+	if size <= 0 {
+		size = PACKETQUEUESIZE
+	}
+	var s = &AdvertisementSubscription{events: make(chan AdvertisementEvent, size), policy: policy, router: r}
+	s.C = s.events
+	r.subscriberMutex.Lock()
+	r.advertisementSubscribers[s] = struct{}{}
+	r.subscriberMutex.Unlock()
+	return s
+}
+
+// Dropped return the number of advertisements dropped because the queue was full
+func (s *AdvertisementSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close cancel the subscription and close C
+func (s *AdvertisementSubscription) Close() {
+	s.once.Do(func() {
+		s.router.subscriberMutex.Lock()
+		delete(s.router.advertisementSubscribers, s)
+		close(s.events)
+		s.router.subscriberMutex.Unlock()
+	})
+}
+
+// publishAdvertisement deliver the summary of packet to every advertisement subscription
+func (r *VirtualRouter) publishAdvertisement(packet *VRRPPacket) {
+	r.subscriberMutex.Lock()
+	defer r.subscriberMutex.Unlock()
+	if len(r.advertisementSubscribers) == 0 {
+		return
+	}
+	var event = AdvertisementEvent{
+		VRID:      packet.GetVirtualRouterID(),
+		Version:   VRRPVersion(packet.GetVersion()),
+		Priority:  packet.GetPriority(),
+		Interval:  time.Duration(packet.GetAdvertisementInterval()) * 10 * time.Millisecond,
+		Addresses: packet.GetIPvXAddr(r.ipvX),
+		Time:      r.clock.Now(),
+	}
+	if packet.Pshdr != nil {
+		event.Source = packet.Pshdr.Saddr
+	}
+	for s := range r.advertisementSubscribers {
+		if !offer(s.events, s.policy, event) {
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
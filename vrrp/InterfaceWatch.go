@@ -0,0 +1,338 @@
+package vrrp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"sync"
+	"syscall"
+	"vrrp-go/logger"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// interfaceWatch is the netlink subscription to the link and address changes of the interface
+type interfaceWatch struct {
+	index int
+	links chan netlink.LinkUpdate
+	addrs chan netlink.AddrUpdate
+	done  chan struct{}
+}
+
+// close cancel the subscription and wait for it to release its channels
+func (w *interfaceWatch) close() {
+	close(w.done)
+	//the subscriptions close the channels once their sockets are closed
+	for range w.links {
+	}
+	for range w.addrs {
+	}
+}
+
+// subscribeInterface subscribe to the changes of the interface, nil is returned if the virtual router
+// runs over a caller supplied transport. The current link and addresses are delivered first.
+func (r *VirtualRouter) subscribeInterface() (*interfaceWatch, error) {
+	var nif = r.NetInterface()
+	if nif == nil {
+		return nil, nil
+	}
+	var watch = &interfaceWatch{
+		index: nif.Index,
+		links: make(chan netlink.LinkUpdate, WATCHQUEUESIZE),
+		addrs: make(chan netlink.AddrUpdate, WATCHQUEUESIZE),
+		done:  make(chan struct{}),
+	}
+	var ns = r.namespace
+	if ns == nil {
+		ns = processNamespace()
+	}
+	var onError = func(err error) {
+		select {
+		case <-watch.done:
+			//the sockets are closed on purpose
+		default:
+			logger.GLoger.Printf(logger.ERROR, "VirtualRouter.watchInterface: %v", err)
+		}
+	}
+	if errOfLinks := netlink.LinkSubscribeWithOptions(watch.links, watch.done, netlink.LinkSubscribeOptions{
+		Namespace:     ns,
+		ErrorCallback: onError,
+		ListExisting:  true,
+	}); errOfLinks != nil {
+		close(watch.done)
+		return nil, fmt.Errorf("VirtualRouter.subscribeInterface: %v", errOfLinks)
+	}
+	if errOfAddrs := netlink.AddrSubscribeWithOptions(watch.addrs, watch.done, netlink.AddrSubscribeOptions{
+		Namespace:     ns,
+		ErrorCallback: onError,
+		ListExisting:  true,
+	}); errOfAddrs != nil {
+		close(watch.done)
+		for range watch.links {
+		}
+		return nil, fmt.Errorf("VirtualRouter.subscribeInterface: %v", errOfAddrs)
+	}
+	return watch, nil
+}
+
+// watchInterface apply the changes of the interface until ctx is done, the interface and the source address
+// are polled like the other tracks if the subscription breaks
+func (r *VirtualRouter) watchInterface(ctx context.Context, watch *interfaceWatch) {
+	var broken = r.applyInterfaceUpdates(ctx, watch)
+	watch.close()
+	if !broken || ctx.Err() != nil {
+		return
+	}
+	logger.GLoger.Printf(logger.ERROR, "VirtualRouter.watchInterface: subscription of virtual router %v broken, polling the interface instead", r.vrID)
+	var link, source bool
+	r.execute(func() {
+		link, source = r.linkTrack.healthy, r.sourceTrack.healthy
+	})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		r.watchTrack(ctx, r.linkTrack, link)
+		wg.Done()
+	}()
+	go func() {
+		r.watchTrack(ctx, r.sourceTrack, source)
+		wg.Done()
+	}()
+	wg.Wait()
+}
+
+// applyInterfaceUpdates feed the updates of the interface into the event loop until ctx is done,
+// it returns true if the subscription breaks
+func (r *VirtualRouter) applyInterfaceUpdates(ctx context.Context, watch *interfaceWatch) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case update, ok := <-watch.links:
+			if !ok {
+				return true
+			}
+			if update.Link.Attrs().Index != watch.index {
+				continue
+			}
+			r.execute(func() {
+				r.applyLinkUpdate(update.Link, update.Header.Type == syscall.RTM_DELLINK)
+			})
+		case update, ok := <-watch.addrs:
+			if !ok {
+				return true
+			}
+			if update.LinkIndex != watch.index {
+				continue
+			}
+			r.execute(func() {
+				r.applyAddrUpdate(update)
+			})
+		}
+	}
+}
+
+// applyLinkUpdate refresh the interface, the virtual router enters FAULT when the link goes down and the
+// transport is set up again when it comes back. It's called by the event loop.
+func (r *VirtualRouter) applyLinkUpdate(link netlink.Link, deleted bool) {
+	var attrs = link.Attrs()
+	var flags = attrs.Flags
+	if attrs.RawFlags&syscall.IFF_RUNNING != 0 {
+		flags |= net.FlagRunning
+	}
+	var before = r.NetInterface()
+	r.interfaceMutex.Lock()
+	r.netInterface = &net.Interface{
+		Index:        attrs.Index,
+		MTU:          attrs.MTU,
+		Name:         attrs.Name,
+		HardwareAddr: attrs.HardwareAddr,
+		Flags:        flags,
+	}
+	r.interfaceMutex.Unlock()
+
+	var healthy = !deleted && linkState(attrs) == nil
+	if healthy != r.linkTrack.healthy {
+		if healthy {
+			logger.GLoger.Printf(logger.INFO, "interface %v of virtual router %v is up", attrs.Name, r.vrID)
+		} else {
+			logger.GLoger.Printf(logger.INFO, "interface %v of virtual router %v is down", attrs.Name, r.vrID)
+		}
+		r.linkTrack.healthy = healthy
+		if healthy && r.sourceTrack.healthy {
+			//join the multicast group again, the interface may have been recreated meanwhile
+			r.redial()
+		}
+		r.updateHealth()
+		return
+	}
+	if !bytes.Equal(before.HardwareAddr, attrs.HardwareAddr) {
+		logger.GLoger.Printf(logger.INFO, "hardware address of %v changes from %v to %v", attrs.Name, before.HardwareAddr, attrs.HardwareAddr)
+		//the announcer sends from the hardware address it's set up with
+		r.redial()
+		if r.state == MASTER {
+			r.announceAll()
+		}
+	}
+}
+
+// applyAddrUpdate follow the source address, an automatically selected source address is replaced by another
+// address of the interface when it's removed, the virtual router enters FAULT if none is left or the
+// source address can't move. It's called by the event loop.
+func (r *VirtualRouter) applyAddrUpdate(update netlink.AddrUpdate) {
+	var ip = update.LinkAddress.IP
+	//an address can't be bound before duplicate address detection completes
+	var usable = update.NewAddr && update.Flags&(syscall.IFA_F_TENTATIVE|syscall.IFA_F_DADFAILED) == 0
+	switch {
+	case ip.Equal(r.preferredSourceIP):
+		if !usable && r.sourceMovable() {
+			if replacement := r.findSource(); replacement != nil {
+				r.changeSource(replacement)
+				return
+			}
+		}
+		r.setSourceHealth(usable)
+	case usable && r.sourceMovable() && !r.sourceTrack.healthy && eligibleSource(ip, r.ipvX):
+		r.changeSource(ip)
+	}
+}
+
+// sourceMovable report whether the source address may be replaced, it can't if it's configured or if the
+// connection is supplied by the caller or shared by a Manager, that connection stays bound to the old address
+func (r *VirtualRouter) sourceMovable() bool {
+	return !r.sourceConfigured && r.ownConnection
+}
+
+// findSource select the source address among the usable addresses of the interface, nil is returned if none is left
+func (r *VirtualRouter) findSource() net.IP {
+	var handle, errOfHandle = newNetlinkHandle(r.namespace)
+	if errOfHandle != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.findSource: %v", errOfHandle)
+		return nil
+	}
+	defer handle.Close()
+	var family = netlink.FAMILY_V4
+	if r.ipvX == IPv6 {
+		family = netlink.FAMILY_V6
+	}
+	var link = &netlink.Device{LinkAttrs: netlink.LinkAttrs{Index: r.NetInterface().Index}}
+	var addrs, errOfList = handle.AddrList(link, family)
+	if errOfList != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.findSource: %v", errOfList)
+		return nil
+	}
+	for _, addr := range addrs {
+		if addr.Flags&(syscall.IFA_F_TENTATIVE|syscall.IFA_F_DADFAILED) == 0 && eligibleSource(addr.IP, r.ipvX) {
+			return addr.IP
+		}
+	}
+	return nil
+}
+
+// changeSource send the advertisements from ip from now on
+func (r *VirtualRouter) changeSource(ip net.IP) {
+	logger.GLoger.Printf(logger.INFO, "source address of virtual router %v changes from %v to %v", r.vrID, r.preferredSourceIP, ip)
+	r.preferredSourceIP = ip
+	r.sourceTrack.Tracker = &AddressTracker{Interface: r.NetInterface().Name, Address: ip, Namespace: r.namespace}
+	r.sourceTrack.healthy = true
+	if r.linkTrack.healthy {
+		r.redial()
+	}
+	var state = r.state
+	r.updateHealth()
+	if state == MASTER && r.state == MASTER {
+		//the backups learn the new address of the master at once
+		r.sendAdvertMessage()
+	}
+}
+
+// setSourceHealth record whether the source address is usable, the transport is set up again when it comes back
+func (r *VirtualRouter) setSourceHealth(healthy bool) {
+	if healthy == r.sourceTrack.healthy {
+		return
+	}
+	if healthy {
+		logger.GLoger.Printf(logger.INFO, "source address %v of virtual router %v is back", r.preferredSourceIP, r.vrID)
+	} else {
+		logger.GLoger.Printf(logger.INFO, "source address %v of virtual router %v is gone", r.preferredSourceIP, r.vrID)
+	}
+	r.sourceTrack.healthy = healthy
+	if healthy && r.linkTrack.healthy {
+		r.redial()
+	}
+	r.updateHealth()
+}
+
+// announceAll announce all the protected addresses
+func (r *VirtualRouter) announceAll() {
+	if errOfAnnounce := r.ipAddrAnnouncer.AnnounceAll(r); errOfAnnounce != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.announceAll: %v", errOfAnnounce)
+	} else {
+		r.counters.announcements.Add(uint64(len(r.ProtectedIPaddrs())))
+	}
+}
+
+// redial set up the connection and the announcer owned by the virtual router again, so that the multicast
+// group is joined on the interface as it is now and the announcements carry the current hardware address.
+// The old ones are kept if the new ones can't be set up.
+func (r *VirtualRouter) redial() {
+	if r.ownAnnouncer {
+		var old = r.ipAddrAnnouncer
+		r.ipAddrAnnouncer, r.ownAnnouncer = nil, false
+		if errOfDial := r.inNamespace(r.dialAnnouncer); errOfDial != nil {
+			logger.GLoger.Printf(logger.ERROR, "VirtualRouter.redial: %v", errOfDial)
+			r.ipAddrAnnouncer, r.ownAnnouncer = old, true
+		} else if closer, ok := old.(io.Closer); ok {
+			if errOfClose := closer.Close(); errOfClose != nil {
+				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.redial: %v", errOfClose)
+			}
+		}
+	}
+	if r.ownConnection {
+		var old = r.iplayerInterface
+		r.iplayerInterface, r.ownConnection = nil, false
+		if errOfDial := r.inNamespace(r.dialConnection); errOfDial != nil {
+			logger.GLoger.Printf(logger.ERROR, "VirtualRouter.redial: %v", errOfDial)
+			r.iplayerInterface, r.ownConnection = old, true
+			return
+		}
+		//the old reader exits quietly once its connection is closed
+		r.stopReader()
+		if closer, ok := old.(io.Closer); ok {
+			if errOfClose := closer.Close(); errOfClose != nil {
+				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.redial: %v", errOfClose)
+			}
+		}
+		r.startReader()
+	}
+}
+
+// inNamespace run f on a thread in the network namespace the virtual router is created in,
+// the sockets set up by f stay in that namespace
+func (r *VirtualRouter) inNamespace(f func() error) error {
+	if r.namespace == nil {
+		return f()
+	}
+	runtime.LockOSThread()
+	var origin, errOfGet = netns.Get()
+	if errOfGet != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("VirtualRouter.inNamespace: %v", errOfGet)
+	}
+	defer origin.Close()
+	if errOfSet := netns.Set(*r.namespace); errOfSet != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("VirtualRouter.inNamespace: %v", errOfSet)
+	}
+	defer func() {
+		//the thread is left locked if it can't leave the namespace, so that no other goroutine runs on it
+		if netns.Set(origin) == nil {
+			runtime.UnlockOSThread()
+		}
+	}()
+	return f()
+}
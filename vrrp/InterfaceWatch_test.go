@@ -0,0 +1,195 @@
+package vrrp_test
+
+import (
+	"bytes"
+	"net"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"vrrp-go/vrrp"
+)
+
+// movePeer move the interface into a new network namespace and bring it up there, the packets from
+// the addresses of the original namespace would be dropped as martians otherwise
+func movePeer(t *testing.T, handle *netlink.Handle, name string) (netns.NsHandle, *netlink.Handle, netlink.Link) {
+	t.Helper()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	var origin, errOfGet = netns.Get()
+	if errOfGet != nil {
+		t.Fatal(errOfGet)
+	}
+	defer origin.Close()
+	var ns, errOfNew = netns.New()
+	if errOfNew != nil {
+		t.Fatal(errOfNew)
+	}
+	if err := netns.Set(origin); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ns.Close() })
+	var peerHandle, errOfHandle = netlink.NewHandleAt(ns)
+	if errOfHandle != nil {
+		t.Fatal(errOfHandle)
+	}
+	t.Cleanup(peerHandle.Close)
+	var link, _ = handle.LinkByName(name)
+	if err := handle.LinkSetNsFd(link, int(ns)); err != nil {
+		t.Fatal(err)
+	}
+	link, _ = peerHandle.LinkByName(name)
+	if err := peerHandle.LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	return ns, peerHandle, link
+}
+
+func TestInterfaceWatch(t *testing.T) {
+	var ns, handle = newTestNamespace(t)
+	var peerNS, peerHandle, peer = movePeer(t, handle, "v1")
+	var link, _ = handle.LinkByName("v0")
+	var addAddr = func(handle *netlink.Handle, link netlink.Link, cidr string) *netlink.Addr {
+		t.Helper()
+		var addr, _ = netlink.ParseAddr(cidr)
+		addr.Flags = syscall.IFA_F_NODAD
+		if err := handle.AddrAdd(link, addr); err != nil {
+			t.Fatal(err)
+		}
+		return addr
+	}
+	var first = addAddr(handle, link, "10.6.0.1/24")
+	addAddr(peerHandle, peer, "10.6.0.9/24")
+	var vr *vrrp.VirtualRouter
+	inNamespace(t, ns, func() {
+		var err error
+		vr, err = vrrp.New(&vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, Interface: "v0", AdvertisementInterval: testInterval})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	var listener *vrrp.IPv4Con
+	inNamespace(t, peerNS, func() {
+		var err error
+		if listener, err = vrrp.DialIPv4Conn(net.ParseIP("10.6.0.9"), vrrp.VRRPMultiAddrIPv4); err != nil {
+			t.Fatal(err)
+		}
+	})
+	defer listener.Close()
+	var rec = newRecorder(vr)
+	go vr.StartWithEventSelector()
+	t.Cleanup(vr.Stop)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+
+	//the source address moves to the remaining address of the interface without a fault
+	var second = net.ParseIP("10.6.1.1")
+	addAddr(handle, link, "10.6.1.1/24")
+	if err := handle.AddrDel(link, first); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); ; {
+		var packet, err = readWithin(t, listener, time.Second)
+		if err == nil && packet.Pshdr.Saddr.Equal(second) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("advertisements not sent from %v", second)
+		}
+	}
+	if status := vr.Status(); status.State != vrrp.MASTER || !status.MasterIP.Equal(second) {
+		t.Fatalf("status after the source address changes = %+v", status)
+	}
+	rec.never(t, 5*testInterval, vrrp.Master2Fault)
+
+	//the hardware address follows the interface
+	var mac, _ = net.ParseMAC("02:00:5e:10:00:42")
+	if err := handle.LinkSetHardwareAddr(link, mac); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); !bytes.Equal(vr.HardwareAddr(), mac); {
+		if time.Now().After(deadline) {
+			t.Fatalf("hardware address = %v, want %v", vr.HardwareAddr(), mac)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	//the link changes are applied without waiting for the next poll
+	if err := peerHandle.LinkSetDown(peer); err != nil {
+		t.Fatal(err)
+	}
+	rec.await(t, vrrp.Master2Fault, 500*time.Millisecond)
+	if err := peerHandle.LinkSetUp(peer); err != nil {
+		t.Fatal(err)
+	}
+	rec.await(t, vrrp.Fault2Backup, 500*time.Millisecond)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+	if _, err := readWithin(t, listener, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSuppliedConnectionKeepsSource(t *testing.T) {
+	var ns, handle = newTestNamespace(t)
+	var peerNS, peerHandle, peer = movePeer(t, handle, "v1")
+	var link, _ = handle.LinkByName("v0")
+	var addAddr = func(handle *netlink.Handle, link netlink.Link, cidr string) *netlink.Addr {
+		t.Helper()
+		var addr, _ = netlink.ParseAddr(cidr)
+		addr.Flags = syscall.IFA_F_NODAD
+		if err := handle.AddrAdd(link, addr); err != nil {
+			t.Fatal(err)
+		}
+		return addr
+	}
+	var first = addAddr(handle, link, "10.6.0.1/24")
+	addAddr(peerHandle, peer, "10.6.0.9/24")
+	var vr *vrrp.VirtualRouter
+	inNamespace(t, ns, func() {
+		//the connection is bound to the source address by the caller
+		var con, err = vrrp.DialIPv4Conn(first.IP, vrrp.VRRPMultiAddrIPv4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { con.Close() })
+		vr, err = vrrp.New(&vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, Interface: "v0", Connection: con, AdvertisementInterval: testInterval})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	var listener *vrrp.IPv4Con
+	inNamespace(t, peerNS, func() {
+		var err error
+		if listener, err = vrrp.DialIPv4Conn(net.ParseIP("10.6.0.9"), vrrp.VRRPMultiAddrIPv4); err != nil {
+			t.Fatal(err)
+		}
+	})
+	defer listener.Close()
+	var rec = newRecorder(vr)
+	go vr.StartWithEventSelector()
+	t.Cleanup(vr.Stop)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+
+	//the source address doesn't move to another address the connection isn't bound to
+	addAddr(handle, link, "10.6.1.1/24")
+	if err := handle.AddrDel(link, first); err != nil {
+		t.Fatal(err)
+	}
+	rec.await(t, vrrp.Master2Fault, 500*time.Millisecond)
+
+	//the virtual router recovers once the source address is back
+	addAddr(handle, link, "10.6.0.1/24")
+	rec.await(t, vrrp.Fault2Backup, 500*time.Millisecond)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+	for deadline := time.Now().Add(time.Second); ; {
+		var packet, err = readWithin(t, listener, time.Second)
+		if err == nil && packet.Pshdr.Saddr.Equal(first.IP) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("advertisements not sent from %v", first.IP)
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package vrrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"vrrp-go/logger"
+)
+
+// TransportFactory opens the connection and the announcer shared by all the virtual routers
+// of one address family on interface nif, source is the source address of the advertisements.
+// The connection must implement io.Closer, and closing it must unblock ReadMessage with net.ErrClosed.
+type TransportFactory func(nif string, IPvX byte, source net.IP) (IPConnection, AddrAnnouncer, error)
+
+// Manager runs many virtual routers, the virtual routers of one address family on one interface
+// share a single connection, advertisements received on it are dispatched by VRID
+type Manager struct {
+	factory    TransportFactory
+	mutex      sync.Mutex
+	closed     bool
+	transports map[transportKey]*sharedTransport
+	routers    map[*VirtualRouter]*managedRouter
+}
+
+type transportKey struct {
+	nif  string
+	ipvX byte
+}
+
+// sharedTransport is the connection and the announcer of one interface and address family
+type sharedTransport struct {
+	key           transportKey
+	source        net.IP
+	con           IPConnection
+	announcer     *sharedAnnouncer
+	mutex         sync.Mutex
+	views         map[byte]*demuxConnection
+	readerStopped chan struct{}
+}
+
+type managedRouter struct {
+	transport *sharedTransport
+	cancel    context.CancelFunc
+	stopped   chan struct{}
+}
+
+// demuxConnection is the IPConnection of one virtual router on a sharedTransport,
+// it receives the advertisements carrying its VRID only. The demuxConnection of a virtual router
+// in unicast mode only reserves the VRID, the virtual router has its own connection.
+type demuxConnection struct {
+	transport *sharedTransport
+	queue     chan *VRRPPacket
+	closed    chan struct{}
+	once      sync.Once
+	//expired is closed when the read deadline passes, it's replaced by the next SetReadDeadline
+	mutex   sync.Mutex
+	expired chan struct{}
+	timer   *time.Timer
+}
+
+// sharedAnnouncer serializes the announcements of the virtual routers sharing an announcer
+type sharedAnnouncer struct {
+	mutex     sync.Mutex
+	announcer AddrAnnouncer
+}
+
+// NewManager create a Manager opening transports with factory, raw sockets are used if factory is nil
+func NewManager(factory TransportFactory) *Manager {
+	if factory == nil {
+		factory = DialTransport
+	}
+	return &Manager{
+		factory:    factory,
+		transports: make(map[transportKey]*sharedTransport),
+		routers:    make(map[*VirtualRouter]*managedRouter),
+	}
+}
+
+// DialTransport open a raw IP connection and an ARP/NDP client on interface nif
+func DialTransport(nif string, IPvX byte, source net.IP) (IPConnection, AddrAnnouncer, error) {
+	var itf, errOfGetIF = net.InterfaceByName(nif)
+	if errOfGetIF != nil {
+		return nil, nil, fmt.Errorf("DialTransport: %w: %v", ErrInterfaceNotFound, errOfGetIF)
+	}
+	var con IPConnection
+	var announcer AddrAnnouncer
+	var errOfDial error
+	if IPvX == IPv4 {
+		announcer, errOfDial = DialIPv4AddrAnnouncer(itf)
+	} else {
+		announcer, errOfDial = DialIPv6AddrAnnouncer(itf)
+	}
+	if errOfDial != nil {
+		return nil, nil, fmt.Errorf("DialTransport: %w", errOfDial)
+	}
+	if IPvX == IPv4 {
+		con, errOfDial = DialIPv4Conn(source, VRRPMultiAddrIPv4)
+	} else {
+		con, errOfDial = DialIPv6Con(source, VRRPMultiAddrIPv6)
+	}
+	if errOfDial != nil {
+		announcer.(io.Closer).Close()
+		return nil, nil, fmt.Errorf("DialTransport: %w", errOfDial)
+	}
+	return con, announcer, nil
+}
+
+// Add create a virtual router described by cfg and run it until it's removed, cfg.Connection
+// and cfg.Announcer are replaced by the transport shared with the other virtual routers of
+// cfg.IPvX on cfg.Interface. The VRID must be unique on the shared transport. In virtual MAC mode the
+// advertisements still leave from the shared connection, only the announcements carry the virtual MAC.
+// A virtual router in unicast mode shares the announcer only, unless cfg.Connection is supplied
+// it sends and receives advertisements on its own connection to cfg.Peers.
+func (m *Manager) Add(cfg *Config) (*VirtualRouter, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var vr, managed, errOfCreate = m.create(cfg)
+	if errOfCreate != nil {
+		return nil, fmt.Errorf("Manager.Add: %w", errOfCreate)
+	}
+	managed.run(vr)
+	return vr, nil
+}
+
+// Create create a virtual router like Add without running it, Start runs it. Subscriptions made
+// before Start see the first transition of the virtual router.
+func (m *Manager) Create(cfg *Config) (*VirtualRouter, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var vr, _, errOfCreate = m.create(cfg)
+	if errOfCreate != nil {
+		return nil, fmt.Errorf("Manager.Create: %w", errOfCreate)
+	}
+	return vr, nil
+}
+
+// create attach a new virtual router described by cfg to its transport, the virtual router is stopped.
+// m.mutex must be held.
+func (m *Manager) create(cfg *Config) (*VirtualRouter, *managedRouter, error) {
+	if m.closed {
+		return nil, nil, ErrManagerClosed
+	}
+	var key = transportKey{nif: cfg.Interface, ipvX: cfg.IPvX}
+	var transport, ok = m.transports[key]
+	if !ok {
+		var errOfOpen error
+		if transport, errOfOpen = m.openTransport(key, cfg.SourceIP); errOfOpen != nil {
+			return nil, nil, errOfOpen
+		}
+	} else if cfg.SourceIP != nil && !cfg.SourceIP.Equal(transport.source) {
+		return nil, nil, fmt.Errorf("%w: %v is used on %v", ErrSourceAddressConflict, transport.source, cfg.Interface)
+	}
+	var unicast = len(cfg.Peers) != 0
+	var view, errOfAttach = transport.attach(cfg.VRID, unicast)
+	if errOfAttach != nil {
+		m.releaseTransport(transport)
+		return nil, nil, errOfAttach
+	}
+	var routerConfig = *cfg
+	if !unicast {
+		routerConfig.Connection = view
+	}
+	routerConfig.Announcer = transport.announcer
+	routerConfig.SourceIP = transport.source
+	var vr, errOfNew = New(&routerConfig)
+	if errOfNew != nil {
+		transport.detach(cfg.VRID)
+		m.releaseTransport(transport)
+		return nil, nil, errOfNew
+	}
+	//a stopped virtual router has a cancelled context and a closed channel, as if it ran already
+	var stopped = make(chan struct{})
+	close(stopped)
+	var managed = &managedRouter{transport: transport, cancel: func() {}, stopped: stopped}
+	m.routers[vr] = managed
+	return vr, managed, nil
+}
+
+// Start run vr created by Create, or run it again after it was stopped by Stop or by VirtualRouter.Stop
+func (m *Manager) Start(vr *VirtualRouter) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var managed, ok = m.routers[vr]
+	if !ok {
+		return fmt.Errorf("Manager.Start: %w: %v", ErrUnknownRouter, vr.VRID())
+	}
+	select {
+	case <-managed.stopped:
+	default:
+		return fmt.Errorf("Manager.Start: %w: %v", ErrRouterRunning, vr.VRID())
+	}
+	managed.run(vr)
+	return nil
+}
+
+// Stop shut vr down and wait until it's stopped, it keeps its share of the transport until it's removed
+func (m *Manager) Stop(vr *VirtualRouter) error {
+	m.mutex.Lock()
+	var managed, ok = m.routers[vr]
+	if !ok {
+		m.mutex.Unlock()
+		return fmt.Errorf("Manager.Stop: %w: %v", ErrUnknownRouter, vr.VRID())
+	}
+	var cancel, stopped = managed.cancel, managed.stopped
+	m.mutex.Unlock()
+	cancel()
+	<-stopped
+	return nil
+}
+
+// run start vr on a goroutine of its own, m.mutex must be held
+func (managed *managedRouter) run(vr *VirtualRouter) {
+	if managed.cancel != nil {
+		//release the context of the last run, it may have ended with VirtualRouter.Stop
+		managed.cancel()
+	}
+	var ctx, cancel = context.WithCancel(context.Background())
+	var stopped = make(chan struct{})
+	managed.cancel, managed.stopped = cancel, stopped
+	go func() {
+		if errOfRun := vr.Run(ctx); errOfRun != nil && !errors.Is(errOfRun, context.Canceled) {
+			logger.GLoger.Printf(logger.ERROR, "Manager: virtual router %v: %v", vr.VRID(), errOfRun)
+		}
+		close(stopped)
+	}()
+}
+
+// Remove shut vr down and release its share of the transport, the transport is closed
+// with the last virtual router using it
+func (m *Manager) Remove(vr *VirtualRouter) error {
+	m.mutex.Lock()
+	var managed, ok = m.routers[vr]
+	delete(m.routers, vr)
+	m.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("Manager.Remove: %w: %v", ErrUnknownRouter, vr.VRID())
+	}
+	managed.cancel()
+	<-managed.stopped
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	managed.transport.detach(vr.VRID())
+	m.releaseTransport(managed.transport)
+	return nil
+}
+
+// Routers return the managed virtual routers ordered by interface, address family and VRID
+func (m *Manager) Routers() []*VirtualRouter {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var routers = make([]*VirtualRouter, 0, len(m.routers))
+	for vr := range m.routers {
+		routers = append(routers, vr)
+	}
+	sort.Slice(routers, func(i, j int) bool {
+		var ki, kj = m.routers[routers[i]].transport.key, m.routers[routers[j]].transport.key
+		if ki.nif != kj.nif {
+			return ki.nif < kj.nif
+		}
+		if ki.ipvX != kj.ipvX {
+			return ki.ipvX < kj.ipvX
+		}
+		return routers[i].VRID() < routers[j].VRID()
+	})
+	return routers
+}
+
+// RouterSource lists virtual routers, *Manager is a RouterSource
+type RouterSource interface {
+	Routers() []*VirtualRouter
+}
+
+// RouterList is a fixed list of virtual routers
+type RouterList []*VirtualRouter
+
+// Routers return the virtual routers of the list
+func (l RouterList) Routers() []*VirtualRouter {
+	return l
+}
+
+// Close remove all the virtual routers, Add fails afterwards
+func (m *Manager) Close() error {
+	m.mutex.Lock()
+	m.closed = true
+	m.mutex.Unlock()
+	for _, vr := range m.Routers() {
+		if errOfRemove := m.Remove(vr); errOfRemove != nil && !errors.Is(errOfRemove, ErrUnknownRouter) {
+			return fmt.Errorf("Manager.Close: %w", errOfRemove)
+		}
+	}
+	return nil
+}
+
+// openTransport open the transport of key and start dispatching advertisements, m.mutex must be held
+func (m *Manager) openTransport(key transportKey, source net.IP) (*sharedTransport, error) {
+	if source == nil {
+		if key.nif == "" {
+			return nil, fmt.Errorf("%w: interface or source IP must be designated", ErrNoSourceAddress)
+		}
+		var itf, errOfGetIF = net.InterfaceByName(key.nif)
+		if errOfGetIF != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInterfaceNotFound, errOfGetIF)
+		}
+		var preferred, errOfGetPreferred = findIPbyInterface(itf, key.ipvX)
+		if errOfGetPreferred != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoSourceAddress, errOfGetPreferred)
+		}
+		source = preferred
+	}
+	var con, announcer, errOfDial = m.factory(key.nif, key.ipvX, source)
+	if errOfDial != nil {
+		return nil, errOfDial
+	}
+	var transport = &sharedTransport{
+		key:           key,
+		source:        source,
+		con:           con,
+		announcer:     &sharedAnnouncer{announcer: announcer},
+		views:         make(map[byte]*demuxConnection),
+		readerStopped: make(chan struct{}),
+	}
+	go transport.dispatch()
+	m.transports[key] = transport
+	logger.GLoger.Printf(logger.INFO, "shared transport of IPv%v on %v opened", key.ipvX, key.nif)
+	return transport, nil
+}
+
+// releaseTransport close transport if no virtual router uses it, m.mutex must be held
+func (m *Manager) releaseTransport(transport *sharedTransport) {
+	transport.mutex.Lock()
+	var inUse = len(transport.views) > 0
+	transport.mutex.Unlock()
+	if inUse {
+		return
+	}
+	delete(m.transports, transport.key)
+	for _, part := range []interface{}{transport.con, transport.announcer.announcer} {
+		if closer, ok := part.(io.Closer); ok {
+			if errOfClose := closer.Close(); errOfClose != nil {
+				logger.GLoger.Printf(logger.ERROR, "Manager.releaseTransport: %v", errOfClose)
+			}
+		}
+	}
+	<-transport.readerStopped
+	logger.GLoger.Printf(logger.INFO, "shared transport of IPv%v on %v closed", transport.key.ipvX, transport.key.nif)
+}
+
+// attach create the connection of VRID on the transport, nothing is dispatched to it in unicast mode
+func (t *sharedTransport) attach(VRID byte, unicast bool) (*demuxConnection, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, ok := t.views[VRID]; ok {
+		return nil, fmt.Errorf("%w: %v on IPv%v of %v", ErrDuplicateVRID, VRID, t.key.ipvX, t.key.nif)
+	}
+	var view = &demuxConnection{
+		transport: t,
+		closed:    make(chan struct{}),
+		expired:   make(chan struct{}),
+	}
+	if !unicast {
+		view.queue = make(chan *VRRPPacket, PACKETQUEUESIZE)
+	}
+	t.views[VRID] = view
+	return view, nil
+}
+
+// detach close the connection of VRID
+func (t *sharedTransport) detach(VRID byte) {
+	t.mutex.Lock()
+	var view = t.views[VRID]
+	delete(t.views, VRID)
+	t.mutex.Unlock()
+	if view != nil {
+		view.Close()
+	}
+}
+
+// dispatch read advertisements from the shared connection and push them to the connection
+// of their VRID until the shared connection is closed
+func (t *sharedTransport) dispatch() {
+	defer close(t.readerStopped)
+	for {
+		var packet, errOfRead = t.con.ReadMessage()
+		if errOfRead != nil {
+			if errors.Is(errOfRead, net.ErrClosed) {
+				return
+			}
+			//the invalid advertisement can't be attributed to a virtual router
+			countReceiveError(nil, errOfRead)
+			logger.GLoger.Printf(logger.ERROR, "sharedTransport.dispatch: %v", errOfRead)
+			continue
+		}
+		t.mutex.Lock()
+		var view = t.views[packet.GetVirtualRouterID()]
+		t.mutex.Unlock()
+		if view == nil || view.queue == nil {
+			receiveErrors.vrIDErrors.Add(1)
+			logger.GLoger.Printf(logger.DEBUG, "sharedTransport.dispatch: no virtual router with ID %v", packet.GetVirtualRouterID())
+			continue
+		}
+		select {
+		case view.queue <- packet:
+		default:
+			logger.GLoger.Printf(logger.ERROR, "sharedTransport.dispatch: queue of virtual router %v is full", packet.GetVirtualRouterID())
+		}
+	}
+}
+
+func (c *demuxConnection) WriteMessage(packet *VRRPPacket) error {
+	select {
+	case <-c.closed:
+		return fmt.Errorf("demuxConnection.WriteMessage: %w", net.ErrClosed)
+	default:
+	}
+	return c.transport.con.WriteMessage(packet)
+}
+
+// ReadMessage return the next advertisement, it fails with os.ErrDeadlineExceeded once the read deadline
+// passes and leaves the advertisements queued for the next read
+func (c *demuxConnection) ReadMessage() (*VRRPPacket, error) {
+	c.mutex.Lock()
+	var expired = c.expired
+	c.mutex.Unlock()
+	select {
+	case <-expired:
+		return nil, fmt.Errorf("demuxConnection.ReadMessage: %w", os.ErrDeadlineExceeded)
+	default:
+	}
+	select {
+	case <-c.closed:
+		return nil, fmt.Errorf("demuxConnection.ReadMessage: %w", net.ErrClosed)
+	case <-expired:
+		return nil, fmt.Errorf("demuxConnection.ReadMessage: %w", os.ErrDeadlineExceeded)
+	case packet := <-c.queue:
+		return packet, nil
+	}
+}
+
+// SetReadDeadline make the pending and the future ReadMessage fail after deadline, a zero deadline clears it
+func (c *demuxConnection) SetReadDeadline(deadline time.Time) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	select {
+	case <-c.expired:
+		c.expired = make(chan struct{})
+	default:
+	}
+	if deadline.IsZero() {
+		return nil
+	}
+	if wait := time.Until(deadline); wait > 0 {
+		var timer *time.Timer
+		timer = time.AfterFunc(wait, func() {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			//a timer stopped too late leaves the deadline set after it alone
+			if c.timer == timer {
+				close(c.expired)
+				c.timer = nil
+			}
+		})
+		c.timer = timer
+		return nil
+	}
+	close(c.expired)
+	return nil
+}
+
+// Close stop the delivery to the connection, the shared connection is left open
+func (c *demuxConnection) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+func (a *sharedAnnouncer) AnnounceAll(vr *VirtualRouter) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.announcer.AnnounceAll(vr)
+}
+
+func (a *sharedAnnouncer) AnnounceAddr(vr *VirtualRouter, ip net.IP) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if single, ok := a.announcer.(SingleAddrAnnouncer); ok {
+		return single.AnnounceAddr(vr, ip)
+	}
+	return a.announcer.AnnounceAll(vr)
+}
@@ -0,0 +1,200 @@
+package vrrp_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+	"vrrp-go/vrrptest"
+)
+
+// simTransport return a TransportFactory attaching one endpoint per call to seg, the calls are counted in dialed
+func simTransport(seg *simnet.Segment, dialed *int) vrrp.TransportFactory {
+	var transport = vrrptest.Transport(seg)
+	return func(nif string, IPvX byte, source net.IP) (vrrp.IPConnection, vrrp.AddrAnnouncer, error) {
+		*dialed++
+		return transport(nif, IPvX, source)
+	}
+}
+
+func TestManagerSharesTransport(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var dialedA, dialedB int
+	var hostA, hostB = vrrp.NewManager(simTransport(seg, &dialedA)), vrrp.NewManager(simTransport(seg, &dialedB))
+	defer hostA.Close()
+	defer hostB.Close()
+	var add = func(m *vrrp.Manager, source string, VRID, priority byte) *vrrp.VirtualRouter {
+		var vr, err = m.Add(&vrrp.Config{
+			VRID:                  VRID,
+			IPvX:                  vrrp.IPv4,
+			SourceIP:              net.ParseIP(source),
+			Priority:              priority,
+			AdvertisementInterval: testInterval,
+			Addresses:             []net.IP{net.IPv4(192, 168, 1, VRID)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vr
+	}
+	var routersA, routersB = map[byte]*vrrp.VirtualRouter{}, map[byte]*vrrp.VirtualRouter{}
+	for VRID := byte(1); VRID <= 10; VRID++ {
+		var priorityA, priorityB byte = 200, 100
+		if VRID%2 == 0 {
+			priorityA, priorityB = 100, 200
+		}
+		routersA[VRID] = add(hostA, "10.0.0.1", VRID, priorityA)
+		routersB[VRID] = add(hostB, "10.0.0.2", VRID, priorityB)
+	}
+	if dialedA != 1 || dialedB != 1 {
+		t.Fatalf("transports dialed %v and %v times", dialedA, dialedB)
+	}
+	for VRID := byte(1); VRID <= 10; VRID++ {
+		var master, backup = routersA[VRID], routersB[VRID]
+		if VRID%2 == 0 {
+			master, backup = backup, master
+		}
+		vrrptest.AwaitState(t, master, vrrp.MASTER)
+		vrrptest.AwaitState(t, backup, vrrp.BACKUP)
+	}
+	if routers := hostA.Routers(); len(routers) != 10 || routers[0].VRID() != 1 || routers[9].VRID() != 10 {
+		t.Fatalf("Routers() = %v", routers)
+	}
+
+	if err := hostA.Remove(routersA[1]); err != nil {
+		t.Fatal(err)
+	}
+	vrrptest.AwaitState(t, routersB[1], vrrp.MASTER)
+	if err := hostA.Remove(routersA[1]); !errors.Is(err, vrrp.ErrUnknownRouter) {
+		t.Fatalf("second Remove returned %v", err)
+	}
+	if _, err := hostA.Add(&vrrp.Config{VRID: 2, IPvX: vrrp.IPv4}); !errors.Is(err, vrrp.ErrDuplicateVRID) {
+		t.Fatalf("Add of duplicate VRID returned %v", err)
+	}
+	if _, err := hostA.Add(&vrrp.Config{VRID: 11, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.0.0.3")}); !errors.Is(err, vrrp.ErrSourceAddressConflict) {
+		t.Fatalf("Add with another source returned %v", err)
+	}
+
+	if err := hostB.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for VRID := byte(2); VRID <= 10; VRID += 2 {
+		vrrptest.AwaitState(t, routersA[VRID], vrrp.MASTER)
+	}
+	if _, err := hostB.Add(&vrrp.Config{VRID: 1, IPvX: vrrp.IPv4}); !errors.Is(err, vrrp.ErrManagerClosed) {
+		t.Fatalf("Add after Close returned %v", err)
+	}
+}
+
+func TestManagerStartStop(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var dialed int
+	var m = vrrp.NewManager(simTransport(seg, &dialed))
+	defer m.Close()
+	var vr, err = m.Add(&vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.0.0.1"), AdvertisementInterval: testInterval})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vrrptest.AwaitState(t, vr, vrrp.MASTER)
+	if err = m.Start(vr); !errors.Is(err, vrrp.ErrRouterRunning) {
+		t.Fatalf("Start of a running virtual router returned %v", err)
+	}
+	if err = m.Stop(vr); err != nil {
+		t.Fatal(err)
+	}
+	if state := vr.Status().State; state != vrrp.INIT {
+		t.Fatalf("virtual router is %v after Stop", state)
+	}
+	if err = m.Start(vr); err != nil {
+		t.Fatal(err)
+	}
+	vrrptest.AwaitState(t, vr, vrrp.MASTER)
+
+	//a virtual router stopped by itself can be started again as well
+	vr.Stop()
+	vrrptest.AwaitState(t, vr, vrrp.INIT)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if err = m.Start(vr); !errors.Is(err, vrrp.ErrRouterRunning) || time.Now().After(deadline) {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	vrrptest.AwaitState(t, vr, vrrp.MASTER)
+	if dialed != 1 || len(m.Routers()) != 1 {
+		t.Fatalf("transport dialed %v times for %v virtual routers", dialed, len(m.Routers()))
+	}
+}
+
+func TestManagerCreate(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var dialed int
+	var m = vrrp.NewManager(simTransport(seg, &dialed))
+	defer m.Close()
+	var vr, err = m.Create(&vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.0.0.1"), AdvertisementInterval: testInterval})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the subscription made before Start sees the first transition
+	var subscription = vr.Subscribe(0, vrrp.DropNewest)
+	defer subscription.Close()
+	time.Sleep(5 * testInterval)
+	if state := vr.Status().State; state != vrrp.INIT {
+		t.Fatalf("created virtual router is %v", state)
+	}
+	if _, err = m.Create(&vrrp.Config{VRID: 1, IPvX: vrrp.IPv4}); !errors.Is(err, vrrp.ErrDuplicateVRID) {
+		t.Fatalf("Create of a duplicate VRID returned %v", err)
+	}
+	if err = m.Start(vr); err != nil {
+		t.Fatal(err)
+	}
+	if event := <-subscription.C; event.From != vrrp.INIT {
+		t.Fatalf("first event = %+v", event)
+	}
+	vrrptest.AwaitState(t, vr, vrrp.MASTER)
+
+	//a virtual router which never ran can be removed
+	var idle *vrrp.VirtualRouter
+	if idle, err = m.Create(&vrrp.Config{VRID: 2, IPvX: vrrp.IPv4}); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Remove(idle); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerRestartLosesNoAdvertisement(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNode(t, seg, "10.0.0.2", 200, false)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var dialed int
+	var m = vrrp.NewManager(simTransport(seg, &dialed))
+	defer m.Close()
+	var vr, err = m.Add(&vrrp.Config{
+		VRID:                  1,
+		IPvX:                  vrrp.IPv4,
+		SourceIP:              net.ParseIP("10.0.0.1"),
+		AdvertisementInterval: testInterval,
+		Addresses:             []net.IP{net.ParseIP("192.168.1.254")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vrrptest.AwaitState(t, vr, vrrp.BACKUP)
+	//the reader of every stopped run would take its share of the advertisements of the master
+	for i := 0; i < 10; i++ {
+		if err = m.Stop(vr); err != nil {
+			t.Fatal(err)
+		}
+		if err = m.Start(vr); err != nil {
+			t.Fatal(err)
+		}
+		vrrptest.AwaitState(t, vr, vrrp.BACKUP)
+	}
+	var rec = newRecorder(vr)
+	rec.never(t, 20*testInterval, vrrp.Backup2Master)
+}
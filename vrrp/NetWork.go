@@ -1,6 +1,7 @@
 package vrrp
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/netip"
@@ -22,6 +23,12 @@ type AddrAnnouncer interface {
 	AnnounceAll(vr *VirtualRouter) error
 }
 
+// SingleAddrAnnouncer is implemented by the AddrAnnouncer able to announce one address,
+// it's used to announce the address added to a MASTER
+type SingleAddrAnnouncer interface {
+	AnnounceAddr(vr *VirtualRouter, ip net.IP) error
+}
+
 type IPv4AddrAnnouncer struct {
 	ARPClient *arp.Client
 }
@@ -31,46 +38,67 @@ type IPv6AddrAnnouncer struct {
 }
 
 func NewIPIPv6AddrAnnouncer(nif *net.Interface) *IPv6AddrAnnouncer {
+	var announcer, errOfDial = DialIPv6AddrAnnouncer(nif)
+	if errOfDial != nil {
+		logger.GLoger.Printf(logger.FATAL, "NewIPv6AddrAnnouncer: %v", errOfDial)
+	}
+	return announcer
+}
+
+// DialIPv6AddrAnnouncer create an NDP client on nif to send unsolicited neighbor advertisements
+func DialIPv6AddrAnnouncer(nif *net.Interface) (*IPv6AddrAnnouncer, error) {
 	var con, ip, errOfMakeNDPCon = ndp.Listen(nif, ndp.LinkLocal)
 	if errOfMakeNDPCon != nil {
-		logger.GLoger.Printf(logger.FATAL, "NewIPv6AddrAnnouncer: %v", errOfMakeNDPCon)
+		return nil, fmt.Errorf("DialIPv6AddrAnnouncer: %w", socketError(errOfMakeNDPCon))
 	}
 	logger.GLoger.Printf(logger.INFO, "NDP client initialized, working on %v, source IP %v", nif.Name, ip)
-	return &IPv6AddrAnnouncer{con: con}
+	return &IPv6AddrAnnouncer{con: con}, nil
+}
+
+// Close close the NDP client
+func (nd *IPv6AddrAnnouncer) Close() error {
+	return nd.con.Close()
 }
 
 func (nd *IPv6AddrAnnouncer) AnnounceAll(vr *VirtualRouter) error {
-	for key := range vr.protectedIPaddrs {
-		address := netip.AddrFrom16(key)
-		var multicastgroup, errOfParseMulticastGroup = ndp.SolicitedNodeMulticast(address)
-		if errOfParseMulticastGroup != nil {
-			logger.GLoger.Printf(logger.ERROR, "IPv6AddrAnnouncer.AnnounceAll: %v", errOfParseMulticastGroup)
-			return errOfParseMulticastGroup
-		} else {
-			//send unsolicited NeighborAdvertisement to refresh link layer address cache
-			var msg = &ndp.NeighborAdvertisement{
-				Override:      true,
-				TargetAddress: address,
-				Options: []ndp.Option{
-					&ndp.LinkLayerAddress{
-						Direction: ndp.Source,
-						Addr:      vr.netInterface.HardwareAddr,
-					},
-				},
-			}
-			if errOfWrite := nd.con.WriteTo(msg, nil, multicastgroup); errOfWrite != nil {
-				logger.GLoger.Printf(logger.ERROR, "IPv6AddrAnnouncer.AnnounceAll: %v", errOfWrite)
-				return errOfWrite
-			} else {
-				logger.GLoger.Printf(logger.INFO, "send unsolicited neighbor advertisement for %v", net.IP(key[:]))
-			}
+	for _, ip := range vr.ProtectedIPaddrs() {
+		if errOfAnnounce := nd.AnnounceAddr(vr, ip); errOfAnnounce != nil {
+			return errOfAnnounce
 		}
-
 	}
 
 	return nil
 }
 
+// AnnounceAddr send unsolicited neighbor advertisement for ip
+func (nd *IPv6AddrAnnouncer) AnnounceAddr(vr *VirtualRouter, ip net.IP) error {
+	var key [16]byte
+	copy(key[:], ip.To16())
+	address := netip.AddrFrom16(key)
+	var multicastgroup, errOfParseMulticastGroup = ndp.SolicitedNodeMulticast(address)
+	if errOfParseMulticastGroup != nil {
+		logger.GLoger.Printf(logger.ERROR, "IPv6AddrAnnouncer.AnnounceAddr: %v", errOfParseMulticastGroup)
+		return errOfParseMulticastGroup
+	}
+	//send unsolicited NeighborAdvertisement to refresh link layer address cache
+	var msg = &ndp.NeighborAdvertisement{
+		Override:      true,
+		TargetAddress: address,
+		Options: []ndp.Option{
+			&ndp.LinkLayerAddress{
+				Direction: ndp.Source,
+				Addr:      vr.HardwareAddr(),
+			},
+		},
+	}
+	if errOfWrite := nd.con.WriteTo(msg, nil, multicastgroup); errOfWrite != nil {
+		logger.GLoger.Printf(logger.ERROR, "IPv6AddrAnnouncer.AnnounceAddr: %v", errOfWrite)
+		return errOfWrite
+	}
+	logger.GLoger.Printf(logger.INFO, "send unsolicited neighbor advertisement for %v", ip)
+	return nil
+}
+
 // makeGratuitousPacket make gratuitous ARP packet with out payload
 func (ar *IPv4AddrAnnouncer) makeGratuitousPacket() *arp.Packet {
 	var packet arp.Packet
@@ -84,32 +112,56 @@ func (ar *IPv4AddrAnnouncer) makeGratuitousPacket() *arp.Packet {
 
 // AnnounceAll send gratuitous ARP response for all protected IPv4 addresses
 func (ar *IPv4AddrAnnouncer) AnnounceAll(vr *VirtualRouter) error {
+	for _, ip := range vr.ProtectedIPaddrs() {
+		if errOfAnnounce := ar.AnnounceAddr(vr, ip); errOfAnnounce != nil {
+			return errOfAnnounce
+		}
+	}
+	return nil
+}
+
+// AnnounceAddr send gratuitous ARP response for ip
+func (ar *IPv4AddrAnnouncer) AnnounceAddr(vr *VirtualRouter, ip net.IP) error {
 	if errofSetDealLine := ar.ARPClient.SetWriteDeadline(time.Now().Add(500 * time.Microsecond)); errofSetDealLine != nil {
-		return fmt.Errorf("IPv4AddrAnnouncer.AnnounceAll: %v", errofSetDealLine)
+		return fmt.Errorf("IPv4AddrAnnouncer.AnnounceAddr: %v", errofSetDealLine)
 	}
 	var packet = ar.makeGratuitousPacket()
-	for k := range vr.protectedIPaddrs {
-		address := netip.AddrFrom4(netip.AddrFrom16(k).As4())
-		packet.SenderHardwareAddr = vr.netInterface.HardwareAddr
-		packet.SenderIP = address
-		packet.TargetHardwareAddr = BaordcastHADDR
-		packet.TargetIP = address
-		logger.GLoger.Printf(logger.INFO, "send gratuitous arp for %v", net.IP(k[:]))
-		if errofsendarp := ar.ARPClient.WriteTo(packet, BaordcastHADDR); errofsendarp != nil {
-			return fmt.Errorf("IPv4AddrAnnouncer.AnnounceAll: %v", errofsendarp)
-		}
+	var key [16]byte
+	copy(key[:], ip.To16())
+	address := netip.AddrFrom4(netip.AddrFrom16(key).As4())
+	packet.SenderHardwareAddr = vr.HardwareAddr()
+	packet.SenderIP = address
+	packet.TargetHardwareAddr = BaordcastHADDR
+	packet.TargetIP = address
+	logger.GLoger.Printf(logger.INFO, "send gratuitous arp for %v", ip)
+	if errofsendarp := ar.ARPClient.WriteTo(packet, BaordcastHADDR); errofsendarp != nil {
+		return fmt.Errorf("IPv4AddrAnnouncer.AnnounceAddr: %v", errofsendarp)
 	}
 	return nil
 }
 
 func NewIPv4AddrAnnouncer(nif *net.Interface) *IPv4AddrAnnouncer {
-	if aar, errofDialARP := arp.Dial(nif); errofDialARP != nil {
+	if aar, errofDialARP := DialIPv4AddrAnnouncer(nif); errofDialARP != nil {
 		panic(errofDialARP)
 	} else {
-		logger.GLoger.Printf(logger.DEBUG, "IPv4 addresses announcer created")
-		return &IPv4AddrAnnouncer{ARPClient: aar}
+		return aar
+	}
+
+}
+
+// DialIPv4AddrAnnouncer create an ARP client on nif to send gratuitous ARP
+func DialIPv4AddrAnnouncer(nif *net.Interface) (*IPv4AddrAnnouncer, error) {
+	var aar, errofDialARP = arp.Dial(nif)
+	if errofDialARP != nil {
+		return nil, fmt.Errorf("DialIPv4AddrAnnouncer: %w", socketError(errofDialARP))
 	}
+	logger.GLoger.Printf(logger.DEBUG, "IPv4 addresses announcer created")
+	return &IPv4AddrAnnouncer{ARPClient: aar}, nil
+}
 
+// Close close the ARP client
+func (ar *IPv4AddrAnnouncer) Close() error {
+	return ar.ARPClient.Close()
 }
 
 type IPv4Con struct {
@@ -128,6 +180,14 @@ type IPv6Con struct {
 	Con    *net.IPConn
 }
 
+// socketError mark the error caused by lack of privileges with ErrPermissionDenied
+func socketError(err error) error {
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+		return fmt.Errorf("%w: %v", ErrPermissionDenied, err)
+	}
+	return err
+}
+
 func ipConnection(local, remote net.IP) (*net.IPConn, error) {
 
 	var conn *net.IPConn
@@ -144,78 +204,95 @@ func ipConnection(local, remote net.IP) (*net.IPConn, error) {
 		conn, errOfListenIP = net.ListenIP("ip:112", &net.IPAddr{IP: local})
 	}
 	if errOfListenIP != nil {
-		return nil, errOfListenIP
-	}
-	var fd, errOfGetFD = conn.File()
-	if errOfGetFD != nil {
-		return nil, errOfGetFD
-	}
-	defer fd.Close()
-	if remote.To4() != nil {
-		//IPv4 mode
-		//set hop limit
-		if errOfSetHopLimit := syscall.SetsockoptInt(int(fd.Fd()), syscall.IPPROTO_IP, syscall.IP_MULTICAST_TTL, VRRPMultiTTL); errOfSetHopLimit != nil {
-			return nil, fmt.Errorf("ipConnection: %v", errOfSetHopLimit)
-		}
-		//set tos
-		if errOfSetTOS := syscall.SetsockoptInt(int(fd.Fd()), syscall.IPPROTO_IP, syscall.IP_TOS, 7); errOfSetTOS != nil {
-			return nil, fmt.Errorf("ipConnection: %v", errOfSetTOS)
-		}
-		//disable multicast loop
-		if errOfSetLoop := syscall.SetsockoptInt(int(fd.Fd()), syscall.IPPROTO_IP, syscall.IP_MULTICAST_LOOP, 0); errOfSetLoop != nil {
-			return nil, fmt.Errorf("ipConnection: %v", errOfSetLoop)
-		}
-	} else {
-		//IPv6 mode
-		//set hop limit
-		if errOfSetHOPLimit := syscall.SetsockoptInt(int(fd.Fd()), syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_HOPS, 255); errOfSetHOPLimit != nil {
-			return nil, fmt.Errorf("ipConnection: %v", errOfSetHOPLimit)
-		}
-		//disable multicast loop
-		if errOfSetLoop := syscall.SetsockoptInt(int(fd.Fd()), syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_LOOP, 0); errOfSetLoop != nil {
-			return nil, fmt.Errorf("ipConnection: %v", errOfSetLoop)
-		}
-		//to receive the hop limit and dst address in oob
-		if err := syscall.SetsockoptInt(int(fd.Fd()), syscall.IPPROTO_IPV6, syscall.IPV6_2292HOPLIMIT, 1); err != nil {
-			return nil, fmt.Errorf("ipConnection: %v", err)
-		}
-		if err := syscall.SetsockoptInt(int(fd.Fd()), syscall.IPPROTO_IPV6, syscall.IPV6_2292PKTINFO, 1); err != nil {
-			return nil, fmt.Errorf("ipConnection: %v", err)
+		return nil, socketError(errOfListenIP)
+	}
+	var established = false
+	defer func() {
+		if !established {
+			conn.Close()
 		}
+	}()
+	var errOfSet = setsockopt(conn, func(fd int) error {
+		if remote.To4() != nil {
+			//IPv4 mode
+			//set hop limit
+			if errOfSetHopLimit := syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_MULTICAST_TTL, VRRPMultiTTL); errOfSetHopLimit != nil {
+				return errOfSetHopLimit
+			}
+			//set tos
+			if errOfSetTOS := syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_TOS, 7); errOfSetTOS != nil {
+				return errOfSetTOS
+			}
+			//disable multicast loop
+			if errOfSetLoop := syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_MULTICAST_LOOP, 0); errOfSetLoop != nil {
+				return errOfSetLoop
+			}
+		} else {
+			//IPv6 mode
+			//set hop limit
+			if errOfSetHOPLimit := syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_HOPS, 255); errOfSetHOPLimit != nil {
+				return errOfSetHOPLimit
+			}
+			//disable multicast loop
+			if errOfSetLoop := syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_LOOP, 0); errOfSetLoop != nil {
+				return errOfSetLoop
+			}
+			//to receive the hop limit and dst address in oob
+			if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_2292HOPLIMIT, 1); err != nil {
+				return err
+			}
+			if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_2292PKTINFO, 1); err != nil {
+				return err
+			}
 
+		}
+		return nil
+	})
+	if errOfSet != nil {
+		return nil, fmt.Errorf("ipConnection: %v", errOfSet)
 	}
 	logger.GLoger.Printf(logger.INFO, "IP virtual connection established %v ==> %v", local, remote)
+	established = true
 	return conn, nil
 }
 
+// setsockopt run set with the descriptor of conn. Unlike conn.File, the socket is left in non-blocking mode,
+// so that closing the connection unblocks a pending read.
+func setsockopt(conn *net.IPConn, set func(fd int) error) error {
+	var raw, errOfRaw = conn.SyscallConn()
+	if errOfRaw != nil {
+		return errOfRaw
+	}
+	var errOfSet error
+	if errOfControl := raw.Control(func(fd uintptr) {
+		errOfSet = set(int(fd))
+	}); errOfControl != nil {
+		return errOfControl
+	}
+	return errOfSet
+}
+
 func makeMulticastIPv4Conn(multi, local net.IP) (*net.IPConn, error) {
 	var conn, errOfListenIP = net.ListenIP("ip4:112", &net.IPAddr{IP: multi})
 	if errOfListenIP != nil {
-		return nil, fmt.Errorf("makeMulticastIPv4Conn: %v", errOfListenIP)
-	}
-	var fd, errOfGetFD = conn.File()
-	if errOfGetFD != nil {
-		return nil, fmt.Errorf("makeMulticastIPv4Conn: %v", errOfGetFD)
+		return nil, fmt.Errorf("makeMulticastIPv4Conn: %w", socketError(errOfListenIP))
 	}
-	defer fd.Close()
 	multi = multi.To4()
 	local = local.To4()
 	var mreq = &syscall.IPMreq{
 		Multiaddr: [4]byte{multi[0], multi[1], multi[2], multi[3]},
 		Interface: [4]byte{local[0], local[1], local[2], local[3]},
 	}
-	if errSetMreq := syscall.SetsockoptIPMreq(int(fd.Fd()), syscall.IPPROTO_IP, syscall.IP_ADD_MEMBERSHIP, mreq); errSetMreq != nil {
+	if errSetMreq := setsockopt(conn, func(fd int) error {
+		return syscall.SetsockoptIPMreq(fd, syscall.IPPROTO_IP, syscall.IP_ADD_MEMBERSHIP, mreq)
+	}); errSetMreq != nil {
+		conn.Close()
 		return nil, fmt.Errorf("makeMulticastIPv4Conn: %v", errSetMreq)
 	}
 	return conn, nil
 }
 
 func joinIPv6MulticastGroup(con *net.IPConn, local, remote net.IP) error {
-	var fd, errOfGetFD = con.File()
-	if errOfGetFD != nil {
-		return fmt.Errorf("joinIPv6MulticastGroup: %v", errOfGetFD)
-	}
-	defer fd.Close()
 	var mreq = &syscall.IPv6Mreq{}
 	copy(mreq.Multiaddr[:], remote.To16())
 	var IF, errOfGetIF = findInterfacebyIP(local)
@@ -223,7 +300,9 @@ func joinIPv6MulticastGroup(con *net.IPConn, local, remote net.IP) error {
 		return fmt.Errorf("joinIPv6MulticastGroup: %v", errOfGetIF)
 	}
 	mreq.Interface = uint32(IF.Index)
-	if errOfSetMreq := syscall.SetsockoptIPv6Mreq(int(fd.Fd()), syscall.IPPROTO_IPV6, syscall.IPV6_JOIN_GROUP, mreq); errOfSetMreq != nil {
+	if errOfSetMreq := setsockopt(con, func(fd int) error {
+		return syscall.SetsockoptIPv6Mreq(fd, syscall.IPPROTO_IPV6, syscall.IPV6_JOIN_GROUP, mreq)
+	}); errOfSetMreq != nil {
 		return fmt.Errorf("joinIPv6MulticastGroup: %v", errOfSetMreq)
 	}
 	logger.GLoger.Printf(logger.INFO, "Join IPv6 multicast group %v on %v", remote, IF.Name)
@@ -231,13 +310,25 @@ func joinIPv6MulticastGroup(con *net.IPConn, local, remote net.IP) error {
 }
 
 func NewIPv4Conn(local, remote net.IP) IPConnection {
+	var con, errOfDial = DialIPv4Conn(local, remote)
+	if errOfDial != nil {
+		panic(errOfDial)
+	}
+	return con
+
+}
+
+// DialIPv4Conn create the raw IP connections sending advertisements from local to remote
+// and receiving advertisements from the VRRP multicast group
+func DialIPv4Conn(local, remote net.IP) (*IPv4Con, error) {
 	var SendConn, errOfMakeIPConn = ipConnection(local, remote)
 	if errOfMakeIPConn != nil {
-		panic(errOfMakeIPConn)
+		return nil, fmt.Errorf("DialIPv4Conn: %w", errOfMakeIPConn)
 	}
 	var receiveConn, errOfMakeRecv = makeMulticastIPv4Conn(VRRPMultiAddrIPv4, local)
 	if errOfMakeRecv != nil {
-		panic(errOfMakeRecv)
+		SendConn.Close()
+		return nil, fmt.Errorf("DialIPv4Conn: %w", errOfMakeRecv)
 	}
 	return &IPv4Con{
 		buffer:     make([]byte, 2048),
@@ -245,8 +336,30 @@ func NewIPv4Conn(local, remote net.IP) IPConnection {
 		remote:     remote,
 		SendCon:    SendConn,
 		ReceiveCon: receiveConn,
+	}, nil
+}
+
+// Close close both the sending and the receiving connections
+func (conn *IPv4Con) Close() error {
+	var errOfSend, errOfReceive = conn.SendCon.Close(), conn.ReceiveCon.Close()
+	if errOfSend != nil {
+		return fmt.Errorf("IPv4Con.Close: %w", errOfSend)
 	}
+	if errOfReceive != nil {
+		return fmt.Errorf("IPv4Con.Close: %w", errOfReceive)
+	}
+	return nil
+}
 
+// SetMulticastInterface send the advertisements through itf instead of the interface of the local address
+func (conn *IPv4Con) SetMulticastInterface(itf *net.Interface) error {
+	var mreqn = &syscall.IPMreqn{Ifindex: int32(itf.Index)}
+	if errOfSet := setsockopt(conn.SendCon, func(fd int) error {
+		return syscall.SetsockoptIPMreqn(fd, syscall.IPPROTO_IP, syscall.IP_MULTICAST_IF, mreqn)
+	}); errOfSet != nil {
+		return fmt.Errorf("IPv4Con.SetMulticastInterface: %v", errOfSet)
+	}
+	return nil
 }
 
 func (conn *IPv4Con) WriteMessage(packet *VRRPPacket) error {
@@ -256,34 +369,50 @@ func (conn *IPv4Con) WriteMessage(packet *VRRPPacket) error {
 	return nil
 }
 
+// SetReadDeadline make the pending and the future ReadMessage fail after deadline, a zero deadline clears it
+func (conn *IPv4Con) SetReadDeadline(deadline time.Time) error {
+	return conn.ReceiveCon.SetReadDeadline(deadline)
+}
+
 func (conn *IPv4Con) ReadMessage() (*VRRPPacket, error) {
 	var n, errOfRead = conn.ReceiveCon.Read(conn.buffer)
 	if errOfRead != nil {
-		return nil, fmt.Errorf("IPv4Con.ReadMessage: %v", errOfRead)
+		return nil, fmt.Errorf("IPv4Con.ReadMessage: %w", errOfRead)
+	}
+	var advertisement, errOfParse = parseIPv4Advertisement(conn.buffer[:n])
+	if errOfParse != nil {
+		return nil, fmt.Errorf("IPv4Con.ReadMessage: %w", errOfParse)
 	}
+	return advertisement, nil
+}
+
+// parseIPv4Advertisement unmarshal the advertisement carried by the IP datagram and validate its TTL and check sum
+func parseIPv4Advertisement(datagram []byte) (*VRRPPacket, error) {
+	var n = len(datagram)
 	if n < 20 {
-		return nil, fmt.Errorf("IPv4Con.ReadMessage: IP datagram lenght %v too small", n)
+		return nil, fmt.Errorf("IP datagram lenght %v too small: %w", n, ErrInvalidLength)
 	}
-	var hdrlen = (int(conn.buffer[0]) & 0x0f) << 2
+	var hdrlen = (int(datagram[0]) & 0x0f) << 2
 	if hdrlen > n {
-		return nil, fmt.Errorf("IPv4Con.ReadMessage: the header length %v is lagger than total length %V", hdrlen, n)
+		return nil, fmt.Errorf("the header length %v is lagger than total length %v: %w", hdrlen, n, ErrInvalidLength)
 	}
-	if conn.buffer[8] != 255 {
-		return nil, fmt.Errorf("IPv4Con.ReadMessage: the TTL of IP datagram carring VRRP advertisment must equal to 255")
+	if datagram[8] != 255 {
+		return nil, fmt.Errorf("the TTL of IP datagram carring VRRP advertisment is %v: %w", datagram[8], ErrInvalidTTL)
 	}
-	if advertisement, errOfUnmarshal := FromBytes(IPv4, conn.buffer[hdrlen:n]); errOfUnmarshal != nil {
-		return nil, fmt.Errorf("IPv4Con.ReadMessage: %v", errOfUnmarshal)
+	if advertisement, errOfUnmarshal := FromBytes(IPv4, datagram[hdrlen:n]); errOfUnmarshal != nil {
+		return nil, errOfUnmarshal
 	} else {
-		if VRRPVersion(advertisement.GetVersion()) != VRRPv3 {
-			return nil, fmt.Errorf("IPv4Con.ReadMessage: received an advertisement with %s", VRRPVersion(advertisement.GetVersion()))
+		//VRRPv2 advertisement is accepted here, the virtual router decides whether to process it
+		if version := VRRPVersion(advertisement.GetVersion()); version != VRRPv3 && version != VRRPv2 {
+			return nil, fmt.Errorf("received an advertisement with %s: %w", version, ErrInvalidVersion)
 		}
 		var pshdr PseudoHeader
-		pshdr.Saddr = net.IPv4(conn.buffer[12], conn.buffer[13], conn.buffer[14], conn.buffer[15]).To16()
-		pshdr.Daddr = net.IPv4(conn.buffer[16], conn.buffer[17], conn.buffer[18], conn.buffer[19]).To16()
+		pshdr.Saddr = net.IPv4(datagram[12], datagram[13], datagram[14], datagram[15]).To16()
+		pshdr.Daddr = net.IPv4(datagram[16], datagram[17], datagram[18], datagram[19]).To16()
 		pshdr.Protocol = VRRPIPProtocolNumber
 		pshdr.Len = uint16(n - hdrlen)
 		if !advertisement.ValidateCheckSum(&pshdr) {
-			return nil, fmt.Errorf("IPv4Con.ReadMessage: validate the check sum of advertisement failed")
+			return nil, fmt.Errorf("validate the check sum of advertisement failed: %w", ErrInvalidChecksum)
 		} else {
 			advertisement.Pshdr = &pshdr
 			return advertisement, nil
@@ -292,12 +421,23 @@ func (conn *IPv4Con) ReadMessage() (*VRRPPacket, error) {
 }
 
 func NewIPv6Con(local, remote net.IP) *IPv6Con {
+	var con, errOfDial = DialIPv6Con(local, remote)
+	if errOfDial != nil {
+		panic(errOfDial)
+	}
+	return con
+}
+
+// DialIPv6Con create the raw IP connection sending advertisements from local to remote,
+// the connection joins the multicast group remote to receive advertisements
+func DialIPv6Con(local, remote net.IP) (*IPv6Con, error) {
 	var con, errOfNewIPv6Con = ipConnection(local, remote)
 	if errOfNewIPv6Con != nil {
-		panic(fmt.Errorf("NewIPv6Con: %v", errOfNewIPv6Con))
+		return nil, fmt.Errorf("DialIPv6Con: %w", errOfNewIPv6Con)
 	}
 	if errOfJoinMG := joinIPv6MulticastGroup(con, local, remote); errOfJoinMG != nil {
-		panic(fmt.Errorf("NewIPv6Con: %v", errOfJoinMG))
+		con.Close()
+		return nil, fmt.Errorf("DialIPv6Con: %w", errOfJoinMG)
 	}
 	return &IPv6Con{
 		buffer: make([]byte, 4096),
@@ -305,7 +445,22 @@ func NewIPv6Con(local, remote net.IP) *IPv6Con {
 		local:  local,
 		remote: remote,
 		Con:    con,
+	}, nil
+}
+
+// SetMulticastInterface send the advertisements through itf instead of the interface of the local address
+func (con *IPv6Con) SetMulticastInterface(itf *net.Interface) error {
+	if errOfSet := setsockopt(con.Con, func(fd int) error {
+		return syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_IF, itf.Index)
+	}); errOfSet != nil {
+		return fmt.Errorf("IPv6Con.SetMulticastInterface: %v", errOfSet)
 	}
+	return nil
+}
+
+// Close close the connection
+func (con *IPv6Con) Close() error {
+	return con.Con.Close()
 }
 
 func (con *IPv6Con) WriteMessage(packet *VRRPPacket) error {
@@ -315,14 +470,29 @@ func (con *IPv6Con) WriteMessage(packet *VRRPPacket) error {
 	return nil
 }
 
+// SetReadDeadline make the pending and the future ReadMessage fail after deadline, a zero deadline clears it
+func (con *IPv6Con) SetReadDeadline(deadline time.Time) error {
+	return con.Con.SetReadDeadline(deadline)
+}
+
 func (con *IPv6Con) ReadMessage() (*VRRPPacket, error) {
-	var buffern, oobn, _, raddr, errOfRead = con.Con.ReadMsgIP(con.buffer, con.oob)
+	var advertisement, errOfRead = readIPv6Advertisement(con.Con, con.buffer, con.oob)
 	if errOfRead != nil {
-		return nil, fmt.Errorf("IPv6Con.ReadMessage: %v", errOfRead)
+		return nil, fmt.Errorf("IPv6Con.ReadMessage: %w", errOfRead)
 	}
-	var oobdata, errOfParseOOB = syscall.ParseSocketControlMessage(con.oob[:oobn])
+	return advertisement, nil
+}
+
+// readIPv6Advertisement read one advertisement from con, the hop limit and the destination address
+// are taken from the ancillary data to validate the hop limit and the check sum
+func readIPv6Advertisement(con *net.IPConn, buffer, oob []byte) (*VRRPPacket, error) {
+	var buffern, oobn, _, raddr, errOfRead = con.ReadMsgIP(buffer, oob)
+	if errOfRead != nil {
+		return nil, errOfRead
+	}
+	var oobdata, errOfParseOOB = syscall.ParseSocketControlMessage(oob[:oobn])
 	if errOfParseOOB != nil {
-		return nil, fmt.Errorf("IPv6Con.ReadMessage: %v", errOfParseOOB)
+		return nil, fmt.Errorf("%v", errOfParseOOB)
 	}
 	var (
 		dst    net.IP
@@ -336,22 +506,22 @@ func (con *IPv6Con) ReadMessage() (*VRRPPacket, error) {
 		switch oobdata[index].Header.Type {
 		case syscall.IPV6_2292HOPLIMIT:
 			if len(oobdata[index].Data) == 0 {
-				return nil, fmt.Errorf("IPv6Con.ReadMessage: invalid HOPLIMIT")
+				return nil, fmt.Errorf("invalid HOPLIMIT")
 			}
 			TTL = oobdata[index].Data[0]
 			GetTTL = true
 		case syscall.IPV6_2292PKTINFO:
 			if len(oobdata[index].Data) < 16 {
-				return nil, fmt.Errorf("IPv6Con.ReadMessage: invalid destination IP addrress length")
+				return nil, fmt.Errorf("invalid destination IP addrress length")
 			}
-			dst = net.IP(oobdata[index].Data[:16])
+			dst = net.IP(append([]byte(nil), oobdata[index].Data[:16]...))
 		}
 	}
 	if GetTTL == false {
-		return nil, fmt.Errorf("IPv6Con.ReadMessage: HOPLIMIT not found")
+		return nil, fmt.Errorf("HOPLIMIT not found")
 	}
 	if dst == nil {
-		return nil, fmt.Errorf("IPv6Con.ReadMessage: destination address not found")
+		return nil, fmt.Errorf("destination address not found")
 	}
 	var pshdr = PseudoHeader{
 		Daddr:    dst,
@@ -359,18 +529,18 @@ func (con *IPv6Con) ReadMessage() (*VRRPPacket, error) {
 		Protocol: VRRPIPProtocolNumber,
 		Len:      uint16(buffern),
 	}
-	var advertisement, errOfUnmarshal = FromBytes(IPv6, con.buffer)
+	var advertisement, errOfUnmarshal = FromBytes(IPv6, buffer[:buffern])
 	if errOfUnmarshal != nil {
-		return nil, fmt.Errorf("IPv6Con.ReadMessage: %v", errOfUnmarshal)
+		return nil, fmt.Errorf("%w", errOfUnmarshal)
 	}
 	if TTL != 255 {
-		return nil, fmt.Errorf("IPv6Con.ReadMessage: invalid HOPLIMIT")
+		return nil, fmt.Errorf("invalid HOPLIMIT %v: %w", TTL, ErrInvalidTTL)
 	}
 	if VRRPVersion(advertisement.GetVersion()) != VRRPv3 {
-		return nil, fmt.Errorf("IPv6Con.ReadMessage: invalid VRRP version %v", advertisement.GetVersion())
+		return nil, fmt.Errorf("invalid VRRP version %v: %w", advertisement.GetVersion(), ErrInvalidVersion)
 	}
 	if !advertisement.ValidateCheckSum(&pshdr) {
-		return nil, fmt.Errorf("IPv6Con.ReadMessage: invalid check sum")
+		return nil, fmt.Errorf("invalid check sum: %w", ErrInvalidChecksum)
 	}
 	advertisement.Pshdr = &pshdr
 	return advertisement, nil
@@ -386,23 +556,22 @@ func findIPbyInterface(itf *net.Interface, IPvX byte) (net.IP, error) {
 		if errOfParseIP != nil {
 			return nil, fmt.Errorf("findIPbyInterface: %v", errOfParseIP)
 		}
-		if IPvX == IPv4 {
-			if ipaddr.To4() != nil {
-				if ipaddr.IsGlobalUnicast() {
-					return ipaddr, nil
-				}
-			}
-		} else {
-			if ipaddr.To4() == nil {
-				if ipaddr.IsLinkLocalUnicast() {
-					return ipaddr, nil
-				}
-			}
+		if eligibleSource(ipaddr, IPvX) {
+			return ipaddr, nil
 		}
 	}
 	return nil, fmt.Errorf("findIPbyInterface: can not find valid IP addrs on %v", itf.Name)
 }
 
+// eligibleSource report whether ip can be the source address of advertisements, a global unicast
+// address is used for IPv4 and a link local one for IPv6
+func eligibleSource(ip net.IP, IPvX byte) bool {
+	if IPvX == IPv4 {
+		return ip.To4() != nil && ip.IsGlobalUnicast()
+	}
+	return ip.To4() == nil && ip.IsLinkLocalUnicast()
+}
+
 func findInterfacebyIP(ip net.IP) (*net.Interface, error) {
 	if itfs, errOfListInterface := net.Interfaces(); errOfListInterface != nil {
 		return nil, fmt.Errorf("findInterfacebyIP: %v", errOfListInterface)
@@ -0,0 +1,121 @@
+package vrrp
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// PacketFilter drops the packets addressed to the protected addresses, it's used by a non-owner
+// MASTER whose Accept_Mode is false, see RFC 5798 6.1. ARP and neighbor discovery must still pass.
+type PacketFilter interface {
+	Block(vr *VirtualRouter, ip net.IP) error
+	Unblock(vr *VirtualRouter, ip net.IP) error
+}
+
+// NftablesFilter is the PacketFilter keeping the blocked addresses in the sets of an nftables
+// table, the table is created on the first Block and deleted by Close
+type NftablesFilter struct {
+	// Path of the nft binary, nft is looked up in PATH if empty
+	Path string
+	// Table is the name of the inet table, vrrp_go is used if empty
+	Table string
+	mutex sync.Mutex
+	ready bool
+}
+
+func (f *NftablesFilter) Block(vr *VirtualRouter, ip net.IP) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.ready {
+		if errOfCreate := f.run(f.ruleset()); errOfCreate != nil {
+			return fmt.Errorf("NftablesFilter.Block: %w", errOfCreate)
+		}
+		f.ready = true
+	}
+	if errOfAdd := f.run(fmt.Sprintf("add element inet %s %s { %s }\n", f.table(), f.set(ip), ip)); errOfAdd != nil {
+		return fmt.Errorf("NftablesFilter.Block: %w", errOfAdd)
+	}
+	return nil
+}
+
+func (f *NftablesFilter) Unblock(vr *VirtualRouter, ip net.IP) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.ready {
+		return nil
+	}
+	if errOfDelete := f.run(fmt.Sprintf("delete element inet %s %s { %s }\n", f.table(), f.set(ip), ip)); errOfDelete != nil {
+		return fmt.Errorf("NftablesFilter.Unblock: %w", errOfDelete)
+	}
+	return nil
+}
+
+// Close delete the table
+func (f *NftablesFilter) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.ready {
+		return nil
+	}
+	f.ready = false
+	if errOfDelete := f.run(fmt.Sprintf("delete table inet %s\n", f.table())); errOfDelete != nil {
+		return fmt.Errorf("NftablesFilter.Close: %w", errOfDelete)
+	}
+	return nil
+}
+
+// ruleset recreate the table, the addresses in vips4 and vips6 are dropped except neighbor discovery
+func (f *NftablesFilter) ruleset() string {
+	var table = f.table()
+	return fmt.Sprintf(`add table inet %[1]s
+delete table inet %[1]s
+table inet %[1]s {
+	set vips4 {
+		type ipv4_addr
+	}
+	set vips6 {
+		type ipv6_addr
+	}
+	chain input {
+		type filter hook input priority filter; policy accept;
+		ip daddr @vips4 drop
+		ip6 daddr @vips6 icmpv6 type { nd-neighbor-solicit, nd-neighbor-advert } accept
+		ip6 daddr @vips6 drop
+	}
+}
+`, table)
+}
+
+func (f *NftablesFilter) table() string {
+	if f.Table == "" {
+		return "vrrp_go"
+	}
+	return f.Table
+}
+
+func (f *NftablesFilter) set(ip net.IP) string {
+	if ip.To4() != nil {
+		return "vips4"
+	}
+	return "vips6"
+}
+
+// run feed script to nft
+func (f *NftablesFilter) run(script string) error {
+	var path = f.Path
+	if path == "" {
+		path = "nft"
+	}
+	var cmd = exec.Command(path, "-f", "-")
+	var stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stderr = &stderr
+	if errOfRun := cmd.Run(); errOfRun != nil {
+		return fmt.Errorf("%v: %v", errOfRun, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package vrrp_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+// filterRecorder is a PacketFilter remembering the blocked addresses
+type filterRecorder struct {
+	mutex   sync.Mutex
+	blocked map[string]bool
+}
+
+func (f *filterRecorder) Block(vr *vrrp.VirtualRouter, ip net.IP) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.blocked[ip.String()] = true
+	return nil
+}
+
+func (f *filterRecorder) Unblock(vr *vrrp.VirtualRouter, ip net.IP) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.blocked, ip.String())
+	return nil
+}
+
+func (f *filterRecorder) await(t *testing.T, want ...string) {
+	t.Helper()
+	sort.Strings(want)
+	var deadline = time.Now().Add(time.Second)
+	for {
+		f.mutex.Lock()
+		var blocked = make([]string, 0, len(f.blocked))
+		for ip := range f.blocked {
+			blocked = append(blocked, ip)
+		}
+		f.mutex.Unlock()
+		sort.Strings(blocked)
+		if strings.Join(blocked, ",") == strings.Join(want, ",") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("blocked %v, want %v", blocked, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAcceptMode(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var filter = &filterRecorder{blocked: map[string]bool{}}
+	var node = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:         1,
+		IPvX:         vrrp.IPv4,
+		SourceIP:     net.ParseIP("10.0.0.1"),
+		Addresses:    []net.IP{net.ParseIP("192.168.1.254")},
+		PacketFilter: filter,
+	})
+	filter.await(t)
+	node.rec.await(t, vrrp.Backup2Master, time.Second)
+	filter.await(t, "192.168.1.254")
+	if node.vr.Status().AcceptMode {
+		t.Fatalf("Status().AcceptMode is true by default")
+	}
+
+	node.vr.AddIPvXAddr(net.ParseIP("192.168.1.253"))
+	filter.await(t, "192.168.1.253", "192.168.1.254")
+	node.vr.SetAcceptMode(true)
+	filter.await(t)
+	if !node.vr.Status().AcceptMode {
+		t.Fatalf("Status().AcceptMode is false after SetAcceptMode(true)")
+	}
+	node.vr.SetAcceptMode(false)
+	filter.await(t, "192.168.1.253", "192.168.1.254")
+
+	//leaving MASTER unblocks the addresses
+	startNode(t, seg, "10.0.0.2", 200, false)
+	node.rec.await(t, vrrp.Master2Backup, time.Second)
+	filter.await(t)
+}
+
+func TestAcceptModeOwner(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var filter = &filterRecorder{blocked: map[string]bool{}}
+	var node = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:         1,
+		Owner:        true,
+		IPvX:         vrrp.IPv4,
+		SourceIP:     net.ParseIP("10.0.0.1"),
+		Addresses:    []net.IP{net.ParseIP("192.168.1.254")},
+		PacketFilter: filter,
+	})
+	node.rec.await(t, vrrp.Init2Master, time.Second)
+	filter.await(t)
+	if !node.vr.Status().AcceptMode {
+		t.Fatalf("owner doesn't accept")
+	}
+}
+
+func TestNftablesFilter(t *testing.T) {
+	var dir = t.TempDir()
+	var log = filepath.Join(dir, "nft.log")
+	var nft = filepath.Join(dir, "nft")
+	if err := os.WriteFile(nft, []byte("#!/bin/sh\necho \"$@\" >> "+log+"\ncat >> "+log+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var filter = &vrrp.NftablesFilter{Path: nft, Table: "test"}
+	if err := filter.Unblock(nil, net.ParseIP("192.168.1.254")); err != nil {
+		t.Fatal(err)
+	}
+	for _, ip := range []string{"192.168.1.254", "2001:db8::1"} {
+		if err := filter.Block(nil, net.ParseIP(ip)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := filter.Unblock(nil, net.ParseIP("2001:db8::1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := filter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var script, errOfRead = os.ReadFile(log)
+	if errOfRead != nil {
+		t.Fatal(errOfRead)
+	}
+	for _, want := range []string{
+		"delete table inet test\ntable inet test {",
+		"ip daddr @vips4 drop",
+		"icmpv6 type { nd-neighbor-solicit, nd-neighbor-advert } accept",
+		"add element inet test vips4 { 192.168.1.254 }",
+		"add element inet test vips6 { 2001:db8::1 }",
+		"delete element inet test vips6 { 2001:db8::1 }",
+	} {
+		if !strings.Contains(string(script), want) {
+			t.Errorf("nft input lacks %q:\n%s", want, script)
+		}
+	}
+	if strings.Count(string(script), "table inet test {") != 1 || !strings.HasSuffix(string(script), "delete table inet test\n") {
+		t.Errorf("unexpected nft input:\n%s", script)
+	}
+
+	var failing = &vrrp.NftablesFilter{Path: filepath.Join(dir, "missing")}
+	if err := failing.Block(nil, net.ParseIP("192.168.1.254")); err == nil {
+		t.Fatalf("Block succeeded without nft")
+	}
+}
@@ -0,0 +1,76 @@
+package vrrp
+
+import (
+	"sync"
+	"time"
+)
+
+// serialQueue run the pushed functions one by one on a goroutine started on demand,
+// at most limit functions are pending unless limit is zero
+type serialQueue struct {
+	mutex   sync.Mutex
+	pending []func()
+	running bool
+	limit   int
+	dropped uint64
+}
+
+// push queue f without blocking, f is dropped if the queue is full
+func (q *serialQueue) push(f func()) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.limit > 0 && len(q.pending) >= q.limit {
+		q.dropped++
+		return false
+	}
+	q.enqueue(f)
+	return true
+}
+
+// enqueue append f to the pending functions, q.mutex must be held
+func (q *serialQueue) enqueue(f func()) {
+	q.pending = append(q.pending, f)
+	if !q.running {
+		q.running = true
+		go q.drain()
+	}
+}
+
+// wait block until the functions pushed so far are run or timeout elapses, false is returned on timeout
+func (q *serialQueue) wait(timeout time.Duration) bool {
+	var done = make(chan struct{})
+	q.mutex.Lock()
+	//the mark is queued even if the queue is full
+	q.enqueue(func() { close(done) })
+	q.mutex.Unlock()
+	var timer = time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// droppedCount return how many functions were dropped since the queue was full
+func (q *serialQueue) droppedCount() uint64 {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.dropped
+}
+
+func (q *serialQueue) drain() {
+	for {
+		q.mutex.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.mutex.Unlock()
+			return
+		}
+		var f = q.pending[0]
+		q.pending = q.pending[1:]
+		q.mutex.Unlock()
+		f()
+	}
+}
@@ -0,0 +1,277 @@
+package vrrp
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+	"vrrp-go/logger"
+)
+
+// NewMasterReason is why the virtual router became MASTER, as vrrpv3StatisticsNewMasterReason of RFC 6527
+type NewMasterReason int
+
+const (
+	NotMaster NewMasterReason = iota
+	// MasterByPriority the virtual router has the highest priority, or the master resigned
+	MasterByPriority
+	// MasterByPreemption the virtual router preempted a lower priority master
+	MasterByPreemption
+	// MasterNoResponse the master stopped advertising
+	MasterNoResponse
+)
+
+func (reason NewMasterReason) String() string {
+	switch reason {
+	case NotMaster:
+		return "not master"
+	case MasterByPriority:
+		return "priority"
+	case MasterByPreemption:
+		return "preempted"
+	case MasterNoResponse:
+		return "master no response"
+	default:
+		return "unknown reason"
+	}
+}
+
+// ProtocolError is the reason the last invalid advertisement was discarded for, as vrrpv3StatisticsProtoErrReason of RFC 6527
+type ProtocolError int
+
+const (
+	NoProtocolError ProtocolError = iota
+	TTLError
+	VersionError
+	ChecksumError
+	VRIDError
+)
+
+func (e ProtocolError) String() string {
+	switch e {
+	case NoProtocolError:
+		return "no error"
+	case TTLError:
+		return "TTL error"
+	case VersionError:
+		return "version error"
+	case ChecksumError:
+		return "checksum error"
+	case VRIDError:
+		return "VRID error"
+	default:
+		return "unknown error"
+	}
+}
+
+// Statistics are the protocol counters of a virtual router after RFC 6527, they only grow while the process runs
+type Statistics struct {
+	AdvertisementsSent     uint64
+	AdvertisementsReceived uint64
+	// ChecksumErrors, TTLErrors and VersionErrors count the advertisements discarded since they
+	// carry an invalid check sum, a TTL or hop limit other than 255 or a version the virtual router doesn't run
+	ChecksumErrors uint64
+	TTLErrors      uint64
+	VersionErrors  uint64
+	// InvalidTypeErrors and PacketLengthErrors count the packets discarded since they aren't advertisements
+	// or they are truncated
+	InvalidTypeErrors  uint64
+	PacketLengthErrors uint64
+	// AddressListErrors counts the advertisements whose address list doesn't match the protected addresses,
+	// they are processed all the same
+	AddressListErrors uint64
+	// AdvertisementIntervalErrors counts the advertisements with an interval other than the configured one,
+	// they are processed all the same
+	AdvertisementIntervalErrors uint64
+	// UnknownPeerErrors counts the advertisements discarded in unicast mode since they aren't sent by a peer
+	UnknownPeerErrors    uint64
+	PriorityZeroReceived uint64
+	PriorityZeroSent     uint64
+	// Announcements counts the gratuitous ARP and unsolicited NA sent for the protected addresses
+	Announcements uint64
+	// Transitions counts the state transitions by type, MasterTransitions the transitions into MASTER
+	Transitions       map[Transition]uint64
+	MasterTransitions uint64
+	// NewMasterReason is why the virtual router became MASTER, NotMaster out of MASTER
+	NewMasterReason NewMasterReason
+	// ProtocolError is the reason the last invalid advertisement was discarded for
+	ProtocolError ProtocolError
+	// Discontinuity is the creation time of the virtual router, when the counters started from zero
+	Discontinuity time.Time
+	// RefreshRate is the advertisement interval, the counters of received advertisements move about once per interval
+	RefreshRate time.Duration
+}
+
+// ReceiveErrors are the counters of the invalid advertisements received by all the connections of the process,
+// including those shared by the virtual routers of a Manager
+type ReceiveErrors struct {
+	ChecksumErrors uint64
+	TTLErrors      uint64
+	VersionErrors  uint64
+	// VRIDErrors counts the advertisements received by a Manager for a VRID it doesn't run
+	VRIDErrors uint64
+	// Discontinuity is the start of the process, when the counters started from zero
+	Discontinuity time.Time
+}
+
+// counters are the atomic counters behind Statistics, they are updated off the event loop as well
+type counters struct {
+	advertisementsSent     atomic.Uint64
+	advertisementsReceived atomic.Uint64
+	checksumErrors         atomic.Uint64
+	ttlErrors              atomic.Uint64
+	versionErrors          atomic.Uint64
+	vrIDErrors             atomic.Uint64
+	invalidTypeErrors      atomic.Uint64
+	packetLengthErrors     atomic.Uint64
+	addressListErrors      atomic.Uint64
+	intervalErrors         atomic.Uint64
+	unknownPeerErrors      atomic.Uint64
+	priorityZeroReceived   atomic.Uint64
+	priorityZeroSent       atomic.Uint64
+	announcements          atomic.Uint64
+	transitions            [Fault2Init + 1]atomic.Uint64
+	masterTransitions      atomic.Uint64
+	newMasterReason        atomic.Int32
+	protocolError          atomic.Int32
+}
+
+// receiveErrors count the invalid advertisements of the process, processStart is when they started from zero
+var (
+	receiveErrors counters
+	processStart  = time.Now()
+)
+
+// Statistics return the protocol counters of the virtual router, it's safe to call from any goroutine
+func (r *VirtualRouter) Statistics() Statistics {
+	var statistics = Statistics{
+		AdvertisementsSent:          r.counters.advertisementsSent.Load(),
+		AdvertisementsReceived:      r.counters.advertisementsReceived.Load(),
+		ChecksumErrors:              r.counters.checksumErrors.Load(),
+		TTLErrors:                   r.counters.ttlErrors.Load(),
+		VersionErrors:               r.counters.versionErrors.Load(),
+		InvalidTypeErrors:           r.counters.invalidTypeErrors.Load(),
+		PacketLengthErrors:          r.counters.packetLengthErrors.Load(),
+		AddressListErrors:           r.counters.addressListErrors.Load(),
+		AdvertisementIntervalErrors: r.counters.intervalErrors.Load(),
+		UnknownPeerErrors:           r.counters.unknownPeerErrors.Load(),
+		PriorityZeroReceived:        r.counters.priorityZeroReceived.Load(),
+		PriorityZeroSent:            r.counters.priorityZeroSent.Load(),
+		Announcements:               r.counters.announcements.Load(),
+		Transitions:                 make(map[Transition]uint64),
+		MasterTransitions:           r.counters.masterTransitions.Load(),
+		NewMasterReason:             NewMasterReason(r.counters.newMasterReason.Load()),
+		ProtocolError:               ProtocolError(r.counters.protocolError.Load()),
+		Discontinuity:               r.created,
+	}
+	r.statusMutex.RLock()
+	statistics.RefreshRate = r.status.AdvertisementInterval
+	r.statusMutex.RUnlock()
+	for t := range r.counters.transitions {
+		if count := r.counters.transitions[t].Load(); count != 0 {
+			statistics.Transitions[Transition(t)] = count
+		}
+	}
+	return statistics
+}
+
+// GlobalReceiveErrors return the counters of the invalid advertisements received by the process
+func GlobalReceiveErrors() ReceiveErrors {
+	return ReceiveErrors{
+		ChecksumErrors: receiveErrors.checksumErrors.Load(),
+		TTLErrors:      receiveErrors.ttlErrors.Load(),
+		VersionErrors:  receiveErrors.versionErrors.Load(),
+		VRIDErrors:     receiveErrors.vrIDErrors.Load(),
+		Discontinuity:  processStart,
+	}
+}
+
+// countReceiveError count the invalid packet reported by ReadMessage into c and the counters of the process,
+// it returns false if errOfRead isn't about an invalid packet
+func countReceiveError(c *counters, errOfRead error) bool {
+	var counter func(*counters) *atomic.Uint64
+	var reason = NoProtocolError
+	switch {
+	case errors.Is(errOfRead, ErrInvalidChecksum):
+		counter, reason = func(c *counters) *atomic.Uint64 { return &c.checksumErrors }, ChecksumError
+	case errors.Is(errOfRead, ErrInvalidTTL):
+		counter, reason = func(c *counters) *atomic.Uint64 { return &c.ttlErrors }, TTLError
+	case errors.Is(errOfRead, ErrInvalidVersion):
+		counter, reason = func(c *counters) *atomic.Uint64 { return &c.versionErrors }, VersionError
+	case errors.Is(errOfRead, ErrInvalidType):
+		counter = func(c *counters) *atomic.Uint64 { return &c.invalidTypeErrors }
+	case errors.Is(errOfRead, ErrInvalidLength):
+		counter = func(c *counters) *atomic.Uint64 { return &c.packetLengthErrors }
+	default:
+		return false
+	}
+	counter(&receiveErrors).Add(1)
+	if c != nil {
+		counter(c).Add(1)
+		if reason != NoProtocolError {
+			c.protocolError.Store(int32(reason))
+		}
+	}
+	return true
+}
+
+// countSent count the advertisement just sent
+func (r *VirtualRouter) countSent() {
+	r.counters.advertisementsSent.Add(1)
+	if r.priority == 0 {
+		r.counters.priorityZeroSent.Add(1)
+	}
+}
+
+// countReceived count the advertisement accepted by the reader, the mismatches of the address list and
+// the advertisement interval are counted but the advertisement is processed all the same
+func (r *VirtualRouter) countReceived(packet *VRRPPacket) {
+	r.counters.advertisementsReceived.Add(1)
+	if packet.GetPriority() == 0 {
+		r.counters.priorityZeroReceived.Add(1)
+	}
+	if !r.matchesAddresses(packet) {
+		//RFC 5798 7.1, the mismatch is a misconfiguration to be logged
+		r.counters.addressListErrors.Add(1)
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.fetchVRRPPacket: addresses advertised by %v don't match the protected addresses", packet.Pshdr.Saddr)
+	}
+	r.statusMutex.RLock()
+	var interval = r.status.AdvertisementInterval
+	r.statusMutex.RUnlock()
+	if time.Duration(packet.GetAdvertisementInterval())*10*time.Millisecond != interval {
+		r.counters.intervalErrors.Add(1)
+	}
+}
+
+// countMasterReason record why the virtual router enters state, it's called by transit before the master is forgotten
+func (r *VirtualRouter) countMasterReason(state State) {
+	if state != MASTER {
+		r.counters.newMasterReason.Store(int32(NotMaster))
+		return
+	}
+	var reason = MasterByPriority
+	switch {
+	case r.preempting:
+		reason = MasterByPreemption
+	case r.masterIP != nil:
+		reason = MasterNoResponse
+	}
+	r.counters.masterTransitions.Add(1)
+	r.counters.newMasterReason.Store(int32(reason))
+}
+
+// matchesAddresses report whether the address list of the advertisement is the set of the protected addresses
+func (r *VirtualRouter) matchesAddresses(packet *VRRPPacket) bool {
+	var addrs = packet.GetIPvXAddr(r.ipvX)
+	r.addrMutex.RLock()
+	defer r.addrMutex.RUnlock()
+	var seen = make(map[[16]byte]bool, len(addrs))
+	for _, ip := range addrs {
+		var key [16]byte
+		copy(key[:], ip.To16())
+		if !r.protectedIPaddrs[key] {
+			return false
+		}
+		seen[key] = true
+	}
+	return len(seen) == len(r.protectedIPaddrs)
+}
@@ -0,0 +1,189 @@
+package vrrp_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+// faultyConnection fail the reads with the queued errors before it reads from the connection
+type faultyConnection struct {
+	vrrp.IPConnection
+	errs chan error
+}
+
+func (c *faultyConnection) ReadMessage() (*vrrp.VRRPPacket, error) {
+	select {
+	case err := <-c.errs:
+		return nil, err
+	default:
+	}
+	return c.IPConnection.ReadMessage()
+}
+
+func awaitStatistics(t *testing.T, vr *vrrp.VirtualRouter, reached func(vrrp.Statistics) bool) vrrp.Statistics {
+	t.Helper()
+	var deadline = time.Now().Add(time.Second)
+	for {
+		var statistics = vr.Statistics()
+		if reached(statistics) {
+			return statistics
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("statistics = %+v", statistics)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:      1,
+		IPvX:      vrrp.IPv4,
+		Priority:  200,
+		SourceIP:  net.ParseIP("10.0.0.2"),
+		Addresses: []net.IP{net.ParseIP("192.168.1.254")},
+	})
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+
+	//the backup protects one more address than the master advertises
+	var endpoint = seg.Attach(net.ParseIP("10.0.0.1"))
+	var connection = &faultyConnection{IPConnection: endpoint, errs: make(chan error, 5)}
+	var backup, err = vrrp.New(&vrrp.Config{
+		VRID:                  1,
+		IPvX:                  vrrp.IPv4,
+		Priority:              100,
+		SourceIP:              endpoint.Addr(),
+		Addresses:             []net.IP{net.ParseIP("192.168.1.254"), net.ParseIP("192.168.1.253")},
+		AdvertisementInterval: testInterval,
+		Connection:            connection,
+		Announcer:             endpoint,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var before = vrrp.GlobalReceiveErrors()
+	connection.errs <- fmt.Errorf("corrupted: %w", vrrp.ErrInvalidChecksum)
+	connection.errs <- fmt.Errorf("not an advertisement: %w", vrrp.ErrInvalidType)
+	connection.errs <- fmt.Errorf("truncated: %w", vrrp.ErrInvalidLength)
+	connection.errs <- fmt.Errorf("forwarded: %w", vrrp.ErrInvalidTTL)
+	connection.errs <- fmt.Errorf("unknown: %w", vrrp.ErrInvalidVersion)
+	go backup.StartWithEventSelector()
+	t.Cleanup(backup.Stop)
+
+	var statistics = awaitStatistics(t, backup, func(s vrrp.Statistics) bool {
+		return s.AdvertisementsReceived >= 3 && s.ChecksumErrors == 1 && s.TTLErrors == 1 && s.VersionErrors == 1 &&
+			s.InvalidTypeErrors == 1 && s.PacketLengthErrors == 1
+	})
+	if statistics.AddressListErrors != statistics.AdvertisementsReceived || statistics.AdvertisementsSent != 0 ||
+		statistics.AdvertisementIntervalErrors != 0 || statistics.ProtocolError != vrrp.VersionError ||
+		statistics.NewMasterReason != vrrp.NotMaster || statistics.RefreshRate != testInterval {
+		t.Fatalf("statistics of the backup = %+v", statistics)
+	}
+	if statistics.Transitions[vrrp.Init2Backup] != 1 || len(statistics.Transitions) != 1 {
+		t.Fatalf("transitions of the backup = %v", statistics.Transitions)
+	}
+	var after = vrrp.GlobalReceiveErrors()
+	if after.ChecksumErrors-before.ChecksumErrors != 1 || after.TTLErrors-before.TTLErrors != 1 || after.VersionErrors-before.VersionErrors != 1 {
+		t.Fatalf("receive errors of the process went from %+v to %+v", before, after)
+	}
+
+	statistics = master.vr.Statistics()
+	if statistics.AdvertisementsSent < 3 || statistics.Announcements != 1 || statistics.AddressListErrors != 0 ||
+		statistics.MasterTransitions != 1 || statistics.NewMasterReason != vrrp.MasterByPriority {
+		t.Fatalf("statistics of the master = %+v", statistics)
+	}
+	if statistics.Transitions[vrrp.Init2Backup] != 1 || statistics.Transitions[vrrp.Backup2Master] != 1 {
+		t.Fatalf("transitions of the master = %v", statistics.Transitions)
+	}
+	if status := master.vr.Status(); status.AdvertisementInterval != testInterval {
+		t.Fatalf("advertisement interval = %v", status.AdvertisementInterval)
+	}
+
+	//the master resigns with an advertisement of priority 0
+	master.vr.Stop()
+	statistics = awaitStatistics(t, backup, func(s vrrp.Statistics) bool {
+		return s.PriorityZeroReceived == 1 && s.MasterTransitions == 1
+	})
+	if statistics.NewMasterReason != vrrp.MasterByPriority {
+		t.Fatalf("statistics of the new master = %+v", statistics)
+	}
+	if statistics = master.vr.Statistics(); statistics.PriorityZeroSent != 1 || statistics.NewMasterReason != vrrp.NotMaster {
+		t.Fatalf("statistics of the resigned master = %+v", statistics)
+	}
+}
+
+func TestNewMasterReason(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var low = startNode(t, seg, "10.0.0.1", 100, false)
+	low.rec.await(t, vrrp.Backup2Master, time.Second)
+	var high = startNode(t, seg, "10.0.0.2", 200, false)
+	high.rec.await(t, vrrp.Backup2Master, time.Second)
+	if reason := high.vr.Statistics().NewMasterReason; reason != vrrp.MasterByPreemption {
+		t.Fatalf("reason after preemption = %v", reason)
+	}
+	low.rec.await(t, vrrp.Master2Backup, time.Second)
+
+	//the master goes silent without resigning
+	seg.Partition([]*simnet.Endpoint{high.endpoint}, []*simnet.Endpoint{low.endpoint})
+	low.rec.await(t, vrrp.Backup2Master, time.Second)
+	if reason := low.vr.Statistics().NewMasterReason; reason != vrrp.MasterNoResponse {
+		t.Fatalf("reason after the master went silent = %v", reason)
+	}
+	if status := low.vr.Status(); status.UpSince.IsZero() || !status.UpSince.Before(status.LastTransition) || !status.Preempt {
+		t.Fatalf("status = %+v", status)
+	}
+}
+
+func TestAdvertisementOfAnotherVersion(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var backup = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:      1,
+		IPvX:      vrrp.IPv4,
+		Priority:  100,
+		SourceIP:  net.ParseIP("10.0.0.1"),
+		Addresses: []net.IP{net.ParseIP("192.168.1.254")},
+	})
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	var advertisements = backup.vr.SubscribeAdvertisements(0, vrrp.DropNewest)
+	defer advertisements.Close()
+
+	//a VRRPv2 advertisement with another interval and other addresses, then a valid one
+	var peer = seg.Attach(net.ParseIP("10.0.0.2"))
+	for _, version := range []vrrp.VRRPVersion{vrrp.VRRPv2, vrrp.VRRPv3} {
+		var packet vrrp.VRRPPacket
+		packet.SetVersion(version)
+		packet.SetType()
+		packet.SetVirtualRouterID(1)
+		packet.SetPriority(200)
+		if version == vrrp.VRRPv2 {
+			packet.SetAdvertisementInterval(300)
+			packet.AddIPvXAddr(vrrp.IPv4, net.ParseIP("192.168.1.253"))
+		} else {
+			packet.SetAdvertisementInterval(uint16(testInterval / (10 * time.Millisecond)))
+			packet.AddIPvXAddr(vrrp.IPv4, net.ParseIP("192.168.1.254"))
+		}
+		var pshdr = &vrrp.PseudoHeader{Saddr: peer.Addr(), Daddr: vrrp.VRRPMultiAddrIPv4, Protocol: vrrp.VRRPIPProtocolNumber, Len: uint16(len(packet.ToBytes()))}
+		packet.SetCheckSum(pshdr)
+		if err := peer.WriteMessage(&packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var statistics = awaitStatistics(t, backup.vr, func(s vrrp.Statistics) bool { return s.AdvertisementsReceived > 0 })
+	if statistics.AdvertisementsReceived != 1 || statistics.VersionErrors != 1 || statistics.AddressListErrors != 0 || statistics.AdvertisementIntervalErrors != 0 {
+		t.Fatalf("statistics = %+v", statistics)
+	}
+	if event := <-advertisements.C; event.Version != vrrp.VRRPv3 {
+		t.Fatalf("published advertisement %+v", event)
+	}
+	select {
+	case event := <-advertisements.C:
+		t.Fatalf("published advertisement %+v", event)
+	default:
+	}
+}
@@ -0,0 +1,90 @@
+package vrrp
+
+import (
+	"net"
+	"time"
+)
+
+// Status is a snapshot of the virtual router taken by Status
+type Status struct {
+	VRID  byte
+	State State
+	// Priority is the priority the virtual router advertises with
+	Priority byte
+	// BasePriority is the configured priority before the tracks are applied
+	BasePriority byte
+	// SourceIP is the address the advertisements are sent from
+	SourceIP net.IP
+	Preempt  bool
+	// MasterIP is the source address of the current master, it's nil in INIT
+	// and before the first advertisement is accepted in BACKUP
+	MasterIP net.IP
+	// MasterPriority is the priority advertised by the current master
+	MasterPriority byte
+	// AdvertisementInterval is the interval the virtual router advertises at as MASTER
+	AdvertisementInterval time.Duration
+	// MasterAdvertisementInterval is the advertisement interval learned from the current master
+	MasterAdvertisementInterval time.Duration
+	SkewTime                    time.Duration
+	MasterDownInterval          time.Duration
+	// LastTransition is the time of the last state transition, it's zero if the
+	// virtual router never left INIT
+	LastTransition time.Time
+	// UpSince is the time the virtual router left INIT, it's zero in INIT
+	UpSince time.Time
+	// Addresses are the protected IP addresses
+	Addresses []net.IP
+	// AcceptMode reports whether the virtual router accepts packets addressed to the protected
+	// addresses as MASTER, it's always true for the owner
+	AcceptMode bool
+	// Tracks are the health of the tracks adjusting the priority
+	Tracks []TrackStatus
+	// SyncGroup is the name of the sync group of the virtual router, it's empty if there is none
+	SyncGroup string
+}
+
+// Status return a snapshot of the virtual router, it's safe to call from any goroutine
+func (r *VirtualRouter) Status() Status {
+	r.statusMutex.RLock()
+	var status = r.status
+	r.statusMutex.RUnlock()
+	status.MasterIP = append(net.IP(nil), status.MasterIP...)
+	status.SourceIP = append(net.IP(nil), status.SourceIP...)
+	status.Addresses = r.ProtectedIPaddrs()
+	status.Tracks = append([]TrackStatus(nil), status.Tracks...)
+	return status
+}
+
+// refreshStatus copy the state owned by the event loop into the snapshot returned by Status
+func (r *VirtualRouter) refreshStatus() {
+	var status = Status{
+		VRID:                        r.vrID,
+		State:                       r.state,
+		Priority:                    r.priority,
+		BasePriority:                r.basePriority,
+		SourceIP:                    r.preferredSourceIP,
+		Preempt:                     r.preempt,
+		AdvertisementInterval:       time.Duration(r.advertisementInterval) * 10 * time.Millisecond,
+		MasterAdvertisementInterval: time.Duration(r.advertisementIntervalOfMaster) * 10 * time.Millisecond,
+		SkewTime:                    time.Duration(r.skewTime) * 10 * time.Millisecond,
+		MasterDownInterval:          time.Duration(r.masterDownInterval) * 10 * time.Millisecond,
+		LastTransition:              r.lastTransition,
+		UpSince:                     r.upSince,
+		AcceptMode:                  r.accepting(),
+		Tracks:                      r.trackStatus(),
+	}
+	if r.syncGroup != nil {
+		status.SyncGroup = r.syncGroup.name
+	}
+	switch r.state {
+	case MASTER:
+		status.MasterIP = r.preferredSourceIP
+		status.MasterPriority = r.priority
+	case BACKUP:
+		status.MasterIP = r.masterIP
+		status.MasterPriority = r.masterPriority
+	}
+	r.statusMutex.Lock()
+	r.status = status
+	r.statusMutex.Unlock()
+}
@@ -0,0 +1,352 @@
+package vrrp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"vrrp-go/logger"
+)
+
+// SyncGroup binds virtual routers which must have the same master, such as the virtual routers of
+// the public and the private VLAN of one gateway. When a member transits from MASTER to BACKUP the
+// other members resign as well, and when a member enters FAULT the other members follow it until
+// it recovers. A member in BACKUP doesn't become MASTER while another member follows a live master.
+//
+// The state of the group is FAULT if a member is in FAULT, BACKUP if a member is in BACKUP,
+// MASTER if a member is in MASTER and INIT otherwise. The handlers of the group are called once per
+// transition of the group, however many members transit.
+type SyncGroup struct {
+	name    string
+	mutex   sync.Mutex
+	members map[*VirtualRouter]*syncMember
+	order   []*VirtualRouter
+	state   State
+	//actions make the members follow each other, notifications call the handlers
+	actions       serialQueue
+	notifications serialQueue
+	handlerMutex  sync.Mutex
+	handlers      map[Transition]func()
+	observers     []func(SyncGroupEvent)
+}
+
+// syncMember is the state of a member last reported from its event loop
+type syncMember struct {
+	state State
+	//fault is set while the member fails by itself rather than following the group
+	fault bool
+}
+
+// SyncGroupEvent describes one state transition of a sync group
+type SyncGroupEvent struct {
+	Group      string
+	From       State
+	To         State
+	Transition Transition
+	Time       time.Time
+}
+
+// NewSyncGroup bind members into the sync group name, a virtual router belongs to one sync group at most.
+// Members may be running already, the group follows them from their current state.
+func NewSyncGroup(name string, members ...*VirtualRouter) (*SyncGroup, error) {
+	if len(members) == 0 {
+		return nil, fmt.Errorf("NewSyncGroup: %w: %v", ErrEmptySyncGroup, name)
+	}
+	var g = &SyncGroup{
+		name:     name,
+		members:  make(map[*VirtualRouter]*syncMember),
+		handlers: make(map[Transition]func()),
+	}
+	for _, r := range members {
+		if _, ok := g.members[r]; ok {
+			return nil, fmt.Errorf("NewSyncGroup: %w: virtual router %v listed twice", ErrInSyncGroup, r.VRID())
+		}
+		g.members[r] = &syncMember{}
+		g.order = append(g.order, r)
+	}
+	var joined []*VirtualRouter
+	for _, r := range members {
+		var errOfJoin error
+		r.execute(func() {
+			if r.syncGroup != nil {
+				errOfJoin = fmt.Errorf("NewSyncGroup: %w: virtual router %v is in %v", ErrInSyncGroup, r.vrID, r.syncGroup.name)
+				return
+			}
+			r.syncGroup = g
+			g.mutex.Lock()
+			g.members[r].state, g.members[r].fault = r.state, r.ownFault()
+			g.mutex.Unlock()
+		})
+		if errOfJoin != nil {
+			for _, joinedRouter := range joined {
+				joinedRouter.execute(func() {
+					joinedRouter.syncGroup = nil
+				})
+			}
+			return nil, errOfJoin
+		}
+		joined = append(joined, r)
+	}
+	g.mutex.Lock()
+	g.state = g.evaluate()
+	//the members in FAULT by themselves take the others with them
+	g.followAll(nil, (*VirtualRouter).followSyncFault)
+	g.mutex.Unlock()
+	logger.GLoger.Printf(logger.INFO, "sync group %v created in %v", name, g.State())
+	return g, nil
+}
+
+// Name return the name of the sync group
+func (g *SyncGroup) Name() string {
+	return g.name
+}
+
+// Members return the virtual routers of the sync group
+func (g *SyncGroup) Members() []*VirtualRouter {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return append([]*VirtualRouter(nil), g.order...)
+}
+
+// Remove take r out of the sync group, r goes on by itself and the group follows the remaining members
+func (g *SyncGroup) Remove(r *VirtualRouter) error {
+	var errOfRemove error
+	r.execute(func() {
+		if r.syncGroup != g {
+			errOfRemove = fmt.Errorf("SyncGroup.Remove: %w: virtual router %v isn't in %v", ErrNotInSyncGroup, r.vrID, g.name)
+			return
+		}
+		r.syncGroup = nil
+		g.leave(r)
+		//the faults of the other members no longer hold r
+		if r.syncFault {
+			r.syncFault = false
+			r.updateHealth()
+		}
+	})
+	return errOfRemove
+}
+
+// Close dissolve the sync group, the members go on by themselves and the group is left in INIT
+func (g *SyncGroup) Close() error {
+	for _, r := range g.Members() {
+		if errOfRemove := g.Remove(r); errOfRemove != nil && !errors.Is(errOfRemove, ErrNotInSyncGroup) {
+			return fmt.Errorf("SyncGroup.Close: %w", errOfRemove)
+		}
+	}
+	return nil
+}
+
+// leave forget member r, it's called by the event loop of r
+func (g *SyncGroup) leave(r *VirtualRouter) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	var member = g.members[r]
+	delete(g.members, r)
+	for i, other := range g.order {
+		if other == r {
+			g.order = append(g.order[:i:i], g.order[i+1:]...)
+			break
+		}
+	}
+	if member.fault {
+		g.followAll(nil, (*VirtualRouter).followSyncFault)
+	}
+	g.transitTo(g.evaluate(), r.clock.Now())
+}
+
+// State return the state of the sync group
+func (g *SyncGroup) State() State {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.state
+}
+
+// Enroll register handler for transition2 of the sync group, it returns true if an earlier handler
+// is overwritten. Handlers are called in order off the event loops of the members.
+func (g *SyncGroup) Enroll(transition2 Transition, handler func()) bool {
+	g.handlerMutex.Lock()
+	defer g.handlerMutex.Unlock()
+	var _, ok = g.handlers[transition2]
+	g.handlers[transition2] = handler
+	return ok
+}
+
+// Observe call handler for every transition of the sync group, in order and off the event loops of the members
+func (g *SyncGroup) Observe(handler func(SyncGroupEvent)) {
+	g.handlerMutex.Lock()
+	defer g.handlerMutex.Unlock()
+	g.observers = append(g.observers, handler)
+}
+
+// update record the state of member r, the other members are told to follow it. It's called by the event loop of r.
+func (g *SyncGroup) update(r *VirtualRouter, state State, fault bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	var member = g.members[r]
+	var from, wasFaulty = member.state, member.fault
+	member.state, member.fault = state, fault
+	if fault != wasFaulty {
+		g.followAll(r, (*VirtualRouter).followSyncFault)
+	}
+	if from == MASTER && state == BACKUP {
+		g.followAll(r, (*VirtualRouter).yieldToSyncGroup)
+	}
+	if from != MASTER && state == MASTER {
+		g.followAll(r, (*VirtualRouter).followSyncMaster)
+	}
+	g.transitTo(g.evaluate(), r.clock.Now())
+}
+
+// transitTo move the sync group into state to at time now, taken from the clock of the reporting member.
+// The handlers are notified off the event loops, g.mutex must be held.
+func (g *SyncGroup) transitTo(to State, now time.Time) {
+	if to == g.state {
+		return
+	}
+	var event = SyncGroupEvent{Group: g.name, From: g.state, To: to, Transition: transitionBetween(g.state, to), Time: now}
+	g.state = to
+	logger.GLoger.Printf(logger.INFO, "sync group %v transits from %v to %v", g.name, event.From, event.To)
+	g.notifications.push(func() {
+		g.notify(event)
+	})
+}
+
+// followAll run follow in the event loop of every member but r, g.mutex must be held
+func (g *SyncGroup) followAll(r *VirtualRouter, follow func(*VirtualRouter)) {
+	for _, other := range g.order {
+		if other == r {
+			continue
+		}
+		var other = other
+		g.actions.push(func() {
+			other.execute(func() {
+				//the member may have left a sync group under construction
+				if other.syncGroup == g {
+					follow(other)
+				}
+			})
+		})
+	}
+}
+
+// evaluate return the state of the sync group derived from its members, g.mutex must be held
+func (g *SyncGroup) evaluate() State {
+	var master, backup, fault bool
+	for _, member := range g.members {
+		switch member.state {
+		case MASTER:
+			master = true
+		case BACKUP:
+			backup = true
+		case FAULT:
+			fault = true
+		}
+	}
+	switch {
+	case fault:
+		return FAULT
+	case backup:
+		return BACKUP
+	case master:
+		return MASTER
+	default:
+		return INIT
+	}
+}
+
+// notify call the handlers of the sync group for event
+func (g *SyncGroup) notify(event SyncGroupEvent) {
+	g.handlerMutex.Lock()
+	var handler, ok = g.handlers[event.Transition]
+	//observers are only appended, the slice taken here stays intact
+	var observers = g.observers
+	g.handlerMutex.Unlock()
+	if ok {
+		handler()
+	}
+	for _, observe := range observers {
+		observe(event)
+	}
+}
+
+// faultElsewhere report whether a member other than r fails by itself
+func (g *SyncGroup) faultElsewhere(r *VirtualRouter) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for other, member := range g.members {
+		if other != r && member.fault {
+			return true
+		}
+	}
+	return false
+}
+
+// holdsBackup report whether another member than r is BACKUP with a live master, r must stay BACKUP then
+func (g *SyncGroup) holdsBackup(r *VirtualRouter) bool {
+	for _, other := range g.Members() {
+		if other == r {
+			continue
+		}
+		other.statusMutex.RLock()
+		var following = other.status.State == BACKUP && other.status.MasterIP != nil
+		other.statusMutex.RUnlock()
+		if following {
+			return true
+		}
+	}
+	return false
+}
+
+// reportToSyncGroup tell the sync group the state of the virtual router, it's called by the event loop
+func (r *VirtualRouter) reportToSyncGroup() {
+	if r.syncGroup != nil {
+		r.syncGroup.update(r, r.state, r.ownFault())
+	}
+}
+
+// followSyncFault enter FAULT while another member fails and leave it once none does
+func (r *VirtualRouter) followSyncFault() {
+	r.syncFault = r.syncGroup.faultElsewhere(r)
+	r.updateHealth()
+}
+
+// yieldToSyncGroup resign since another member of the sync group transited from MASTER to BACKUP
+func (r *VirtualRouter) yieldToSyncGroup() {
+	if r.state != MASTER {
+		return
+	}
+	r.resign()
+	r.setMasterAdvInterval(r.advertisementInterval)
+	r.makeMasterDownTimer()
+	r.transit(BACKUP, Master2Backup, ReasonSyncGroup)
+}
+
+// followSyncMaster take over after Skew_Time since another member of the sync group became MASTER,
+// unless a live master is known
+func (r *VirtualRouter) followSyncMaster() {
+	if r.state == BACKUP && r.masterIP == nil {
+		r.resetMasterDownTimerToSkewTime()
+	}
+}
+
+// transitionBetween return the transition from one state to another
+func transitionBetween(from, to State) Transition {
+	return transitions[[2]State{from, to}]
+}
+
+// transitions map the pairs of states to the transitions between them
+var transitions = map[[2]State]Transition{
+	{MASTER, BACKUP}: Master2Backup,
+	{BACKUP, MASTER}: Backup2Master,
+	{INIT, MASTER}:   Init2Master,
+	{INIT, BACKUP}:   Init2Backup,
+	{MASTER, INIT}:   Master2Init,
+	{BACKUP, INIT}:   Backup2Init,
+	{INIT, FAULT}:    Init2Fault,
+	{MASTER, FAULT}:  Master2Fault,
+	{BACKUP, FAULT}:  Backup2Fault,
+	{FAULT, MASTER}:  Fault2Master,
+	{FAULT, BACKUP}:  Fault2Backup,
+	{FAULT, INIT}:    Fault2Init,
+}
@@ -0,0 +1,175 @@
+package vrrp_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+// member is a virtual router of a sync group and the segment it's attached to
+type member struct {
+	seg *simnet.Segment
+	cfg *vrrp.Config
+}
+
+// startSyncGroup create the virtual routers, bind them into a sync group and start them,
+// the recorder of the group is returned along with the nodes
+func startSyncGroup(t *testing.T, name string, members ...member) (*vrrp.SyncGroup, *recorder, []*node) {
+	t.Helper()
+	var nodes []*node
+	var routers []*vrrp.VirtualRouter
+	for _, m := range members {
+		var endpoint = m.seg.Attach(m.cfg.SourceIP)
+		m.cfg.Connection, m.cfg.Announcer, m.cfg.AdvertisementInterval = endpoint, endpoint, testInterval
+		var vr, err = vrrp.New(m.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, &node{vr: vr, rec: newRecorder(vr), endpoint: endpoint})
+		routers = append(routers, vr)
+	}
+	var group, err = vrrp.NewSyncGroup(name, routers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec = &recorder{events: make(chan fmt.Stringer, 100)}
+	enrollAll(rec, group.Enroll, vrrp.Master2Backup, vrrp.Backup2Master, vrrp.Init2Master, vrrp.Init2Backup, vrrp.Master2Init, vrrp.Backup2Init,
+		vrrp.Init2Fault, vrrp.Master2Fault, vrrp.Backup2Fault, vrrp.Fault2Master, vrrp.Fault2Backup, vrrp.Fault2Init)
+	for _, n := range nodes {
+		go n.vr.StartWithEventSelector()
+		t.Cleanup(n.vr.Stop)
+	}
+	return group, rec, nodes
+}
+
+func TestSyncGroupFollowsBackup(t *testing.T) {
+	var public, private = simnet.NewSegment(), simnet.NewSegment()
+	var groupA, recA, a = startSyncGroup(t, "A",
+		member{public, &vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, Priority: 200, SourceIP: net.ParseIP("10.0.0.2")}},
+		member{private, &vrrp.Config{VRID: 2, IPvX: vrrp.IPv4, Priority: 200, SourceIP: net.ParseIP("10.1.0.2")}},
+	)
+	//the routers of the other gateway aren't synchronized
+	var b = []*node{
+		startNodeWithConfig(t, public, &vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, Priority: 100, SourceIP: net.ParseIP("10.0.0.1")}),
+		startNodeWithConfig(t, private, &vrrp.Config{VRID: 2, IPvX: vrrp.IPv4, Priority: 100, SourceIP: net.ParseIP("10.1.0.1")}),
+	}
+	a[0].rec.await(t, vrrp.Backup2Master, time.Second)
+	a[1].rec.await(t, vrrp.Backup2Master, time.Second)
+	//the group becomes MASTER once both members are
+	recA.await(t, vrrp.Init2Backup, time.Second)
+	recA.await(t, vrrp.Backup2Master, time.Second)
+	recA.never(t, 5*testInterval, vrrp.Backup2Master, vrrp.Master2Backup)
+	if status := a[1].vr.Status(); groupA.State() != vrrp.MASTER || status.SyncGroup != "A" {
+		t.Fatalf("group in %v, member status %+v", groupA.State(), status)
+	}
+
+	//the public VRID is preempted, the private one follows although nothing outranks it there
+	b[0].vr.SetPriority(250)
+	a[0].rec.await(t, vrrp.Master2Backup, time.Second)
+	a[1].rec.await(t, vrrp.Master2Backup, time.Second)
+	recA.await(t, vrrp.Master2Backup, time.Second)
+	b[1].rec.await(t, vrrp.Backup2Master, time.Second)
+	//the private member would preempt b[1] by priority, it's held back while the public one follows b[0]
+	a[1].rec.never(t, 10*testInterval, vrrp.Backup2Master)
+	recA.never(t, time.Millisecond, vrrp.Master2Backup)
+
+	//the whole group takes over again once the public VRID can
+	b[0].vr.SetPriority(50)
+	a[0].rec.await(t, vrrp.Backup2Master, time.Second)
+	a[1].rec.await(t, vrrp.Backup2Master, time.Second)
+	recA.await(t, vrrp.Backup2Master, time.Second)
+	b[1].rec.await(t, vrrp.Master2Backup, time.Second)
+}
+
+func TestSyncGroupFollowsFault(t *testing.T) {
+	var public, private = simnet.NewSegment(), simnet.NewSegment()
+	var uplink = &switchTracker{}
+	var group, rec, a = startSyncGroup(t, "A",
+		member{public, &vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.0.0.2"),
+			Tracks: []vrrp.Track{{Name: "uplink", Tracker: uplink, Interval: 10 * time.Millisecond}}}},
+		member{private, &vrrp.Config{VRID: 2, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.1.0.2")}},
+	)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+
+	uplink.failed.Store(true)
+	a[0].rec.await(t, vrrp.Master2Fault, time.Second)
+	a[1].rec.await(t, vrrp.Master2Fault, time.Second)
+	rec.await(t, vrrp.Master2Fault, time.Second)
+	a[1].rec.never(t, 5*testInterval, vrrp.Fault2Backup, vrrp.Fault2Master)
+
+	uplink.failed.Store(false)
+	a[1].rec.await(t, vrrp.Fault2Backup, time.Second)
+	rec.await(t, vrrp.Fault2Backup, time.Second)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+	if group.State() != vrrp.MASTER {
+		t.Fatalf("group in %v", group.State())
+	}
+
+	if _, err := vrrp.NewSyncGroup("again", a[1].vr); !errors.Is(err, vrrp.ErrInSyncGroup) {
+		t.Fatalf("got %v", err)
+	}
+	if _, err := vrrp.NewSyncGroup("empty"); !errors.Is(err, vrrp.ErrEmptySyncGroup) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestSyncGroupRemove(t *testing.T) {
+	var public, private = simnet.NewSegment(), simnet.NewSegment()
+	var uplink = &switchTracker{}
+	var group, rec, a = startSyncGroup(t, "A",
+		member{public, &vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.0.0.2"),
+			Tracks: []vrrp.Track{{Name: "uplink", Tracker: uplink, Interval: 10 * time.Millisecond}}}},
+		member{private, &vrrp.Config{VRID: 2, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.1.0.2")}},
+	)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+	uplink.failed.Store(true)
+	a[1].rec.await(t, vrrp.Master2Fault, time.Second)
+
+	//the member leaving the group no longer follows the fault of the other one
+	if err := group.Remove(a[1].vr); err != nil {
+		t.Fatal(err)
+	}
+	a[1].rec.await(t, vrrp.Fault2Backup, time.Second)
+	a[1].rec.await(t, vrrp.Backup2Master, time.Second)
+	if members := group.Members(); len(members) != 1 || members[0] != a[0].vr || group.State() != vrrp.FAULT {
+		t.Fatalf("group in %v with %v members", group.State(), len(members))
+	}
+	if status := a[1].vr.Status(); status.SyncGroup != "" {
+		t.Fatalf("removed member in sync group %q", status.SyncGroup)
+	}
+	if err := group.Remove(a[1].vr); !errors.Is(err, vrrp.ErrNotInSyncGroup) {
+		t.Fatalf("second Remove returned %v", err)
+	}
+	if _, err := vrrp.NewSyncGroup("B", a[1].vr); err != nil {
+		t.Fatal(err)
+	}
+
+	//the dissolved group is left in INIT, its former member goes on by itself
+	if err := group.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rec.await(t, vrrp.Fault2Init, time.Second)
+	if len(group.Members()) != 0 || a[0].vr.Status().State != vrrp.FAULT {
+		t.Fatalf("group in %v, former member in %v", group.State(), a[0].vr.Status().State)
+	}
+}
+
+func TestSyncGroupEventTime(t *testing.T) {
+	var clock = vrrp.NewFakeClock(time.Unix(1000, 0))
+	var public, private = simnet.NewSegment(), simnet.NewSegment()
+	var group, rec, _ = startSyncGroup(t, "A",
+		member{public, &vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.0.0.2"), Clock: clock}},
+		member{private, &vrrp.Config{VRID: 2, IPvX: vrrp.IPv4, SourceIP: net.ParseIP("10.1.0.2"), Clock: clock}},
+	)
+	var events = make(chan vrrp.SyncGroupEvent, 10)
+	group.Observe(func(event vrrp.SyncGroupEvent) { events <- event })
+	rec.await(t, vrrp.Init2Backup, time.Second)
+	if event := <-events; event.To != vrrp.BACKUP || !event.Time.Equal(clock.Now()) {
+		t.Fatalf("event = %+v, want the time of the fake clock %v", event, clock.Now())
+	}
+}
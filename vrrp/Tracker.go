@@ -0,0 +1,436 @@
+package vrrp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+	"vrrp-go/logger"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// Tracker checks the health of an object the virtual router depends on
+type Tracker interface {
+	// Check return nil if the object is healthy, it must return when ctx is done
+	Check(ctx context.Context) error
+}
+
+// Track adjusts the priority of the virtual router with the health reported by Tracker
+type Track struct {
+	// Name identifies the track in logs and Status
+	Name    string
+	Tracker Tracker
+	// Weight is added to the priority while the object is healthy if it's positive,
+	// and while the object fails if it's negative. A track of weight 0 is mandatory,
+	// the virtual router stays in FAULT while it fails.
+	Weight int
+	// Interval between two checks, 1 second is used if zero
+	Interval time.Duration
+	// Timeout of one check, Interval is used if zero
+	Timeout time.Duration
+}
+
+// TrackStatus is the health of a track reported by Status
+type TrackStatus struct {
+	Name    string
+	Weight  int
+	Healthy bool
+}
+
+// trackState is a track and its last result, healthy is owned by the event loop
+type trackState struct {
+	Track
+	healthy bool
+}
+
+// weight return the adjustment of the priority made by the track
+func (t *trackState) weight() int {
+	if t.Weight > 0 && t.healthy || t.Weight < 0 && !t.healthy {
+		return t.Weight
+	}
+	return 0
+}
+
+// check run the tracker once within the timeout
+func (t *trackState) check(ctx context.Context) error {
+	var ctxOfCheck, cancel = context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	return t.Tracker.Check(ctxOfCheck)
+}
+
+// newTrackState fill the defaults of track
+func newTrackState(track Track) (*trackState, error) {
+	if track.Tracker == nil {
+		return nil, fmt.Errorf("track %q has no tracker", track.Name)
+	}
+	if track.Interval == 0 {
+		track.Interval = time.Second
+	}
+	if track.Timeout == 0 {
+		track.Timeout = track.Interval
+	}
+	return &trackState{Track: track}, nil
+}
+
+// effectivePriority return the base priority adjusted by the tracks within [1, 254],
+// the owner and a virtual router of priority 255 are never adjusted
+func (r *VirtualRouter) effectivePriority() byte {
+	if r.owner || r.basePriority == 255 {
+		return r.basePriority
+	}
+	var priority = int(r.basePriority)
+	for _, t := range r.tracks {
+		priority += t.weight()
+	}
+	if priority < 1 {
+		return 1
+	}
+	if priority > 254 {
+		return 254
+	}
+	return byte(priority)
+}
+
+// faulty report whether the virtual router must stay in FAULT
+func (r *VirtualRouter) faulty() bool {
+	return r.syncFault || r.ownFault()
+}
+
+// ownFault report whether a mandatory track fails, the faults followed from the sync group are left out
+func (r *VirtualRouter) ownFault() bool {
+	for _, t := range r.tracks {
+		if t.Weight == 0 && !t.healthy {
+			return true
+		}
+	}
+	return false
+}
+
+// updateHealth apply the tracks, the virtual router enters FAULT when a mandatory track fails
+// and leaves it when all of them recover
+func (r *VirtualRouter) updateHealth() {
+	var faulty = r.faulty()
+	if faulty && (r.state == MASTER || r.state == BACKUP) {
+		r.enterFault()
+	}
+	r.updatePriority()
+	if !faulty && r.state == FAULT {
+		r.activate(Fault2Master, Fault2Backup, ReasonRecovered, ReasonRecovered)
+	}
+	r.reportToSyncGroup()
+}
+
+// updatePriority apply the tracks to the priority, Skew_Time is recomputed and a MASTER advertises
+// the new priority at once
+func (r *VirtualRouter) updatePriority() {
+	var priority = r.effectivePriority()
+	if priority == r.priority {
+		return
+	}
+	logger.GLoger.Printf(logger.INFO, "priority of virtual router %v changes from %v to %v", r.vrID, r.priority, priority)
+	r.reconfigureFilter(func() {
+		r.priority = priority
+	})
+	r.setMasterAdvInterval(r.advertisementIntervalOfMaster)
+	if r.state == MASTER {
+		r.sendAdvertMessage()
+	}
+}
+
+// checkTracks run every tracker once and apply the results, it's called before the virtual router starts
+func (r *VirtualRouter) checkTracks(ctx context.Context) []bool {
+	var results = make([]bool, len(r.tracks))
+	var wg sync.WaitGroup
+	for index, t := range r.tracks {
+		wg.Add(1)
+		go func(index int, t *trackState) {
+			defer wg.Done()
+			var errOfCheck = t.check(ctx)
+			if errOfCheck != nil {
+				logger.GLoger.Printf(logger.INFO, "track %v of virtual router %v fails: %v", t.Name, r.vrID, errOfCheck)
+			}
+			results[index] = errOfCheck == nil
+		}(index, t)
+	}
+	wg.Wait()
+	r.execute(func() {
+		for index, t := range r.tracks {
+			t.healthy = results[index]
+		}
+		r.updateHealth()
+	})
+	return results
+}
+
+// watchTrack check t every interval and apply the result when it differs from healthy, until ctx is done
+func (r *VirtualRouter) watchTrack(ctx context.Context, t *trackState, healthy bool) {
+	var ticker = r.clock.NewTicker(t.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		healthy = r.recheckTrack(ctx, t, healthy)
+		Handled(ticker)
+	}
+}
+
+// recheckTrack check t and apply the result when it differs from healthy, the result is returned.
+// Nothing is applied once ctx is done.
+func (r *VirtualRouter) recheckTrack(ctx context.Context, t *trackState, healthy bool) bool {
+	var errOfCheck = t.check(ctx)
+	if ctx.Err() != nil || (errOfCheck == nil) == healthy {
+		return healthy
+	}
+	healthy = errOfCheck == nil
+	if healthy {
+		logger.GLoger.Printf(logger.INFO, "track %v of virtual router %v recovers", t.Name, r.vrID)
+	} else {
+		logger.GLoger.Printf(logger.INFO, "track %v of virtual router %v fails: %v", t.Name, r.vrID, errOfCheck)
+	}
+	r.execute(func() {
+		t.healthy = healthy
+		r.updateHealth()
+	})
+	return healthy
+}
+
+// trackStatus return the health of the tracks, it's called by the event loop
+func (r *VirtualRouter) trackStatus() []TrackStatus {
+	if len(r.tracks) == 0 {
+		return nil
+	}
+	var tracks = make([]TrackStatus, 0, len(r.tracks))
+	for _, t := range r.tracks {
+		tracks = append(tracks, TrackStatus{Name: t.Name, Weight: t.Weight, Healthy: t.healthy})
+	}
+	return tracks
+}
+
+// LinkTracker is healthy while the interface is up and its operational state is up
+type LinkTracker struct {
+	Interface string
+	// Namespace is the network namespace of the interface, the current one is used if nil
+	Namespace *netns.NsHandle
+}
+
+func (t *LinkTracker) Check(ctx context.Context) error {
+	var handle, errOfHandle = newNetlinkHandle(t.Namespace)
+	if errOfHandle != nil {
+		return fmt.Errorf("LinkTracker.Check: %w", errOfHandle)
+	}
+	defer handle.Close()
+	var link, errOfGetLink = handle.LinkByName(t.Interface)
+	if errOfGetLink != nil {
+		return fmt.Errorf("LinkTracker.Check: %w: %v", ErrInterfaceNotFound, errOfGetLink)
+	}
+	if errOfState := linkState(link.Attrs()); errOfState != nil {
+		return fmt.Errorf("LinkTracker.Check: %v", errOfState)
+	}
+	return nil
+}
+
+// linkState return nil if the link is up and has carrier
+func linkState(attrs *netlink.LinkAttrs) error {
+	if attrs.Flags&net.FlagUp == 0 {
+		return fmt.Errorf("%v is down", attrs.Name)
+	}
+	//virtual interfaces without carrier report unknown
+	if attrs.OperState != netlink.OperUp && attrs.OperState != netlink.OperUnknown {
+		return fmt.Errorf("%v is %v", attrs.Name, attrs.OperState)
+	}
+	return nil
+}
+
+// AddressTracker is healthy while Address is configured on the interface
+type AddressTracker struct {
+	Interface string
+	Address   net.IP
+	// Namespace is the network namespace of the interface, the current one is used if nil
+	Namespace *netns.NsHandle
+}
+
+func (t *AddressTracker) Check(ctx context.Context) error {
+	var handle, errOfHandle = newNetlinkHandle(t.Namespace)
+	if errOfHandle != nil {
+		return fmt.Errorf("AddressTracker.Check: %w", errOfHandle)
+	}
+	defer handle.Close()
+	var link, errOfGetLink = handle.LinkByName(t.Interface)
+	if errOfGetLink != nil {
+		return fmt.Errorf("AddressTracker.Check: %w: %v", ErrInterfaceNotFound, errOfGetLink)
+	}
+	var addrs, errOfList = handle.AddrList(link, netlink.FAMILY_ALL)
+	if errOfList != nil {
+		return fmt.Errorf("AddressTracker.Check: %v", errOfList)
+	}
+	for _, addr := range addrs {
+		if addr.IP.Equal(t.Address) {
+			return nil
+		}
+	}
+	return fmt.Errorf("AddressTracker.Check: %v is not on %v", t.Address, t.Interface)
+}
+
+// RouteTracker is healthy while a route to Destination exists in the main table, 0.0.0.0/0 or ::/0
+// tracks the default route
+type RouteTracker struct {
+	Destination *net.IPNet
+	// Namespace is the network namespace of the routes, the current one is used if nil
+	Namespace *netns.NsHandle
+}
+
+func (t *RouteTracker) Check(ctx context.Context) error {
+	var handle, errOfHandle = newNetlinkHandle(t.Namespace)
+	if errOfHandle != nil {
+		return fmt.Errorf("RouteTracker.Check: %w", errOfHandle)
+	}
+	defer handle.Close()
+	var family = netlink.FAMILY_V6
+	if t.Destination.IP.To4() != nil {
+		family = netlink.FAMILY_V4
+	}
+	var routes, errOfList = handle.RouteList(nil, family)
+	if errOfList != nil {
+		return fmt.Errorf("RouteTracker.Check: %v", errOfList)
+	}
+	var wantOnes, _ = t.Destination.Mask.Size()
+	for _, route := range routes {
+		if route.Dst == nil {
+			if wantOnes == 0 {
+				return nil
+			}
+			continue
+		}
+		var ones, _ = route.Dst.Mask.Size()
+		if ones == wantOnes && route.Dst.IP.Equal(t.Destination.IP.Mask(t.Destination.Mask)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("RouteTracker.Check: no route to %v", t.Destination)
+}
+
+var (
+	processNamespaceOnce   sync.Once
+	processNamespaceHandle netns.NsHandle
+	errOfProcessNamespace  error
+)
+
+// processNamespace return the network namespace of the process whichever thread asks for it, it's opened
+// on the first call and kept open, nil is returned on failure. It's read on the thread of a new goroutine,
+// a thread in another network namespace is locked to its goroutine so the new one doesn't run on it.
+func processNamespace() *netns.NsHandle {
+	processNamespaceOnce.Do(func() {
+		var done = make(chan struct{})
+		go func() {
+			defer close(done)
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			processNamespaceHandle, errOfProcessNamespace = netns.Get()
+		}()
+		<-done
+		if errOfProcessNamespace != nil {
+			logger.GLoger.Printf(logger.ERROR, "processNamespace: %v", errOfProcessNamespace)
+		}
+	})
+	if errOfProcessNamespace != nil {
+		return nil
+	}
+	return &processNamespaceHandle
+}
+
+// callerNamespace return the network namespace of the calling thread if it's not the one of the process,
+// the goroutines of the virtual router may run on other threads
+func callerNamespace() *netns.NsHandle {
+	var process = processNamespace()
+	if process == nil {
+		return nil
+	}
+	var current, errOfGet = netns.Get()
+	if errOfGet != nil {
+		return nil
+	}
+	if current.Equal(*process) {
+		current.Close()
+		return nil
+	}
+	return &current
+}
+
+// newNetlinkHandle open a netlink handle in ns, the current network namespace is used if ns is nil
+func newNetlinkHandle(ns *netns.NsHandle) (*netlink.Handle, error) {
+	var handle *netlink.Handle
+	var errOfHandle error
+	if ns != nil {
+		handle, errOfHandle = netlink.NewHandleAt(*ns)
+	} else {
+		handle, errOfHandle = netlink.NewHandle()
+	}
+	if errOfHandle != nil {
+		return nil, socketError(errOfHandle)
+	}
+	return handle, nil
+}
+
+// TCPTracker is healthy while a TCP connection to Address can be established
+type TCPTracker struct {
+	Address string
+}
+
+func (t *TCPTracker) Check(ctx context.Context) error {
+	var dialer net.Dialer
+	var con, errOfDial = dialer.DialContext(ctx, "tcp", t.Address)
+	if errOfDial != nil {
+		return fmt.Errorf("TCPTracker.Check: %v", errOfDial)
+	}
+	con.Close()
+	return nil
+}
+
+// HTTPTracker is healthy while a GET of URL answers with a 2xx or 3xx status
+type HTTPTracker struct {
+	URL string
+	// Client sends the request, http.DefaultClient is used if nil
+	Client *http.Client
+}
+
+func (t *HTTPTracker) Check(ctx context.Context) error {
+	var request, errOfNew = http.NewRequestWithContext(ctx, http.MethodGet, t.URL, nil)
+	if errOfNew != nil {
+		return fmt.Errorf("HTTPTracker.Check: %v", errOfNew)
+	}
+	var client = t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var response, errOfDo = client.Do(request)
+	if errOfDo != nil {
+		return fmt.Errorf("HTTPTracker.Check: %v", errOfDo)
+	}
+	response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 400 {
+		return fmt.Errorf("HTTPTracker.Check: %v answers %v", t.URL, response.Status)
+	}
+	return nil
+}
+
+// ScriptTracker is healthy while the script exits with 0
+type ScriptTracker struct {
+	Path string
+	Args []string
+}
+
+func (t *ScriptTracker) Check(ctx context.Context) error {
+	if errOfRun := exec.CommandContext(ctx, t.Path, t.Args...).Run(); errOfRun != nil {
+		return fmt.Errorf("ScriptTracker.Check: %v: %v", t.Path, errOfRun)
+	}
+	return nil
+}
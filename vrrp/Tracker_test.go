@@ -0,0 +1,261 @@
+package vrrp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+// switchTracker is healthy until it's failed
+type switchTracker struct {
+	failed atomic.Bool
+}
+
+func (s *switchTracker) Check(ctx context.Context) error {
+	if s.failed.Load() {
+		return errors.New("switched off")
+	}
+	return nil
+}
+
+func awaitPriority(t *testing.T, vr *vrrp.VirtualRouter, want byte) vrrp.Status {
+	t.Helper()
+	var deadline = time.Now().Add(time.Second)
+	for {
+		var status = vr.Status()
+		if status.Priority == want {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("priority = %v, want %v", status.Priority, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTrackWeightsMoveMaster(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var uplink, service = &switchTracker{}, &switchTracker{}
+	var master = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:     1,
+		IPvX:     vrrp.IPv4,
+		Priority: 100,
+		SourceIP: net.ParseIP("10.0.0.2"),
+		Tracks: []vrrp.Track{
+			{Name: "uplink", Tracker: uplink, Weight: -60, Interval: 10 * time.Millisecond},
+			{Name: "service", Tracker: service, Weight: 20, Interval: 10 * time.Millisecond},
+		},
+	})
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.1", 90, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	var status = awaitPriority(t, master.vr, 120)
+	//Skew_Time is computed with the effective priority
+	if status.BasePriority != 100 || len(status.Tracks) != 2 || !status.Tracks[0].Healthy || status.SkewTime != 60*time.Millisecond {
+		t.Fatalf("status = %+v", status)
+	}
+
+	//the master advertises the lowered priority at once and the backup preempts it
+	uplink.failed.Store(true)
+	status = awaitPriority(t, master.vr, 60)
+	if status.Tracks[0].Healthy || status.SkewTime != 80*time.Millisecond {
+		t.Fatalf("status after uplink failure = %+v", status)
+	}
+	backup.rec.await(t, vrrp.Backup2Master, time.Second)
+	master.rec.await(t, vrrp.Master2Backup, time.Second)
+
+	//the weights accumulate and the priority stays within [1, 254]
+	service.failed.Store(true)
+	awaitPriority(t, master.vr, 40)
+	master.vr.SetPriority(10)
+	awaitPriority(t, master.vr, 1)
+	master.vr.SetPriority(250)
+	awaitPriority(t, master.vr, 190)
+
+	uplink.failed.Store(false)
+	service.failed.Store(false)
+	awaitPriority(t, master.vr, 254)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+}
+
+func TestTrackAppliedBeforeStart(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var broken = &switchTracker{}
+	broken.failed.Store(true)
+	var master = startNode(t, seg, "10.0.0.1", 100, false)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var node = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:     1,
+		IPvX:     vrrp.IPv4,
+		Priority: 200,
+		SourceIP: net.ParseIP("10.0.0.2"),
+		Tracks:   []vrrp.Track{{Name: "broken", Tracker: broken, Weight: -150}},
+	})
+	node.rec.await(t, vrrp.Init2Backup, time.Second)
+	node.rec.never(t, 10*testInterval, vrrp.Backup2Master)
+}
+
+func TestBuiltinTrackers(t *testing.T) {
+	var check = func(t *testing.T, tracker vrrp.Tracker, healthy bool) {
+		t.Helper()
+		var ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := tracker.Check(ctx); (err == nil) != healthy {
+			t.Fatalf("%T healthy = %v: %v", tracker, !healthy, err)
+		}
+	}
+	t.Run("TCP", func(t *testing.T) {
+		var listener, err = net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tracker = &vrrp.TCPTracker{Address: listener.Addr().String()}
+		check(t, tracker, true)
+		listener.Close()
+		check(t, tracker, false)
+	})
+	t.Run("HTTP", func(t *testing.T) {
+		var status atomic.Int32
+		status.Store(http.StatusOK)
+		var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(int(status.Load()))
+		}))
+		defer server.Close()
+		var tracker = &vrrp.HTTPTracker{URL: server.URL}
+		check(t, tracker, true)
+		status.Store(http.StatusServiceUnavailable)
+		check(t, tracker, false)
+	})
+	t.Run("Script", func(t *testing.T) {
+		check(t, &vrrp.ScriptTracker{Path: "/bin/sh", Args: []string{"-c", "exit 0"}}, true)
+		check(t, &vrrp.ScriptTracker{Path: "/bin/sh", Args: []string{"-c", "exit 3"}}, false)
+	})
+	t.Run("Link", func(t *testing.T) {
+		var ns, handle = newTestNamespace(t)
+		var tracker = &vrrp.LinkTracker{Interface: "v0", Namespace: &ns}
+		check(t, tracker, true)
+		var peer, _ = handle.LinkByName("v1")
+		if err := handle.LinkSetDown(peer); err != nil {
+			t.Fatal(err)
+		}
+		//v0 loses its carrier
+		check(t, tracker, false)
+		check(t, &vrrp.LinkTracker{Interface: "missing", Namespace: &ns}, false)
+	})
+	t.Run("Route", func(t *testing.T) {
+		var ns, handle = newTestNamespace(t)
+		var link, _ = handle.LinkByName("v0")
+		var addr, _ = netlink.ParseAddr("10.8.0.1/24")
+		if err := handle.AddrAdd(link, addr); err != nil {
+			t.Fatal(err)
+		}
+		var _, defaultRoute, _ = net.ParseCIDR("0.0.0.0/0")
+		var _, connected, _ = net.ParseCIDR("10.8.0.0/24")
+		check(t, &vrrp.RouteTracker{Destination: connected, Namespace: &ns}, true)
+		var tracker = &vrrp.RouteTracker{Destination: defaultRoute, Namespace: &ns}
+		check(t, tracker, false)
+		if err := handle.RouteAdd(&netlink.Route{LinkIndex: link.Attrs().Index, Gw: net.ParseIP("10.8.0.254")}); err != nil {
+			t.Fatal(err)
+		}
+		check(t, tracker, true)
+	})
+}
+
+func TestMandatoryTrackFault(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var mandatory = &switchTracker{}
+	var master = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:      1,
+		IPvX:      vrrp.IPv4,
+		Priority:  200,
+		SourceIP:  net.ParseIP("10.0.0.2"),
+		Addresses: []net.IP{net.ParseIP("192.168.1.254")},
+		Tracks:    []vrrp.Track{{Name: "mandatory", Tracker: mandatory, Interval: 10 * time.Millisecond}},
+	})
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.1", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+
+	//the master resigns, so the backup takes over after Skew_Time
+	mandatory.failed.Store(true)
+	master.rec.await(t, vrrp.Master2Fault, time.Second)
+	backup.rec.await(t, vrrp.Backup2Master, 3*testInterval)
+	if status := master.vr.Status(); status.State != vrrp.FAULT || status.MasterIP != nil {
+		t.Fatalf("status in FAULT = %+v", status)
+	}
+	master.rec.never(t, 5*testInterval, vrrp.Fault2Backup, vrrp.Fault2Master)
+
+	//the recovered router becomes backup and preempts the lower priority master
+	mandatory.failed.Store(false)
+	master.rec.await(t, vrrp.Fault2Backup, time.Second)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	backup.rec.await(t, vrrp.Master2Backup, time.Second)
+}
+
+func TestFaultAtStartup(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var mandatory = &switchTracker{}
+	mandatory.failed.Store(true)
+	var node = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:     1,
+		IPvX:     vrrp.IPv4,
+		Owner:    true,
+		SourceIP: net.ParseIP("10.0.0.1"),
+		Tracks:   []vrrp.Track{{Name: "mandatory", Tracker: mandatory, Interval: 10 * time.Millisecond}},
+	})
+	node.rec.await(t, vrrp.Init2Fault, time.Second)
+	//the owner goes straight back to MASTER
+	mandatory.failed.Store(false)
+	node.rec.await(t, vrrp.Fault2Master, time.Second)
+	mandatory.failed.Store(true)
+	node.rec.await(t, vrrp.Master2Fault, time.Second)
+	node.vr.Stop()
+	node.rec.await(t, vrrp.Fault2Init, time.Second)
+}
+
+func TestInterfaceFault(t *testing.T) {
+	var ns, handle = newTestNamespace(t)
+	var link, _ = handle.LinkByName("v0")
+	var addr, _ = netlink.ParseAddr("10.7.0.1/24")
+	if err := handle.AddrAdd(link, addr); err != nil {
+		t.Fatal(err)
+	}
+	var vr *vrrp.VirtualRouter
+	inNamespace(t, ns, func() {
+		var err error
+		vr, err = vrrp.New(&vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, Interface: "v0", AdvertisementInterval: testInterval})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	var rec = newRecorder(vr)
+	go vr.StartWithEventSelector()
+	t.Cleanup(vr.Stop)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+
+	//v0 loses its carrier with its peer down
+	var peer, _ = handle.LinkByName("v1")
+	if err := handle.LinkSetDown(peer); err != nil {
+		t.Fatal(err)
+	}
+	rec.await(t, vrrp.Master2Fault, 3*time.Second)
+	if err := handle.LinkSetUp(peer); err != nil {
+		t.Fatal(err)
+	}
+	rec.await(t, vrrp.Fault2Backup, 3*time.Second)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+
+	if err := handle.AddrDel(link, addr); err != nil {
+		t.Fatal(err)
+	}
+	rec.await(t, vrrp.Master2Fault, 3*time.Second)
+}
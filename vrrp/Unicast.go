@@ -0,0 +1,136 @@
+package vrrp
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+	"vrrp-go/logger"
+)
+
+// UnicastCon is the IPConnection of a virtual router working on a network without multicast,
+// every advertisement is sent to each peer and the advertisements are received on the local address.
+// Like the multicast connections, the TTL or hop limit is set to 255 and checked on receiving.
+type UnicastCon struct {
+	buffer []byte
+	oob    []byte
+	ipvX   byte
+	local  net.IP
+	zone   string
+	peers  []net.IP
+	Con    *net.IPConn
+}
+
+// DialUnicastConn create the raw IP connection sending advertisements from local to peers
+func DialUnicastConn(local net.IP, peers []net.IP) (*UnicastCon, error) {
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("DialUnicastConn: %w", ErrNoPeer)
+	}
+	var ipvX = byte(IPv6)
+	if local.To4() != nil {
+		ipvX = IPv4
+	}
+	for _, peer := range peers {
+		if (peer.To4() != nil) != (ipvX == IPv4) {
+			return nil, fmt.Errorf("DialUnicastConn: %w: peer %v and source %v", ErrInvalidAddressFamily, peer, local)
+		}
+	}
+	var zone string
+	if local.IsLinkLocalUnicast() {
+		var itf, errOfFind = findInterfacebyIP(local)
+		if errOfFind != nil {
+			return nil, fmt.Errorf("DialUnicastConn: %v", errOfFind)
+		}
+		zone = itf.Name
+	}
+	var con, errOfMakeIPConn = ipConnection(local, peers[0])
+	if errOfMakeIPConn != nil {
+		return nil, fmt.Errorf("DialUnicastConn: %w", errOfMakeIPConn)
+	}
+	if errOfSetTTL := setUnicastTTL(con, ipvX); errOfSetTTL != nil {
+		con.Close()
+		return nil, fmt.Errorf("DialUnicastConn: %v", errOfSetTTL)
+	}
+	logger.GLoger.Printf(logger.INFO, "unicast connection established %v ==> %v", local, peers)
+	return &UnicastCon{
+		buffer: make([]byte, 4096),
+		oob:    make([]byte, 4096),
+		ipvX:   ipvX,
+		local:  local,
+		zone:   zone,
+		peers:  append([]net.IP(nil), peers...),
+		Con:    con,
+	}, nil
+}
+
+// setUnicastTTL make the unicast datagrams leave with TTL or hop limit 255
+func setUnicastTTL(con *net.IPConn, ipvX byte) error {
+	return setsockopt(con, func(fd int) error {
+		if ipvX == IPv4 {
+			return syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_TTL, 255)
+		}
+		return syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, 255)
+	})
+}
+
+// Close close the connection
+func (con *UnicastCon) Close() error {
+	return con.Con.Close()
+}
+
+// Peers return the addresses the advertisements are sent to
+func (con *UnicastCon) Peers() []net.IP {
+	return append([]net.IP(nil), con.peers...)
+}
+
+// WriteMessage send packet to every peer, the check sum of VRRPv3 covers the destination address
+// so it's computed again for each peer. All the peers are tried even if sending to one of them fails.
+func (con *UnicastCon) WriteMessage(packet *VRRPPacket) error {
+	var errOfWrite error
+	for _, peer := range con.peers {
+		var readdressed = readdress(packet, con.local, peer)
+		if _, err := con.Con.WriteToIP(readdressed.ToBytes(), &net.IPAddr{IP: peer, Zone: con.zone}); err != nil && errOfWrite == nil {
+			errOfWrite = fmt.Errorf("UnicastCon.WriteMessage: %v: %v", peer, err)
+		}
+	}
+	return errOfWrite
+}
+
+// SetReadDeadline make the pending and the future ReadMessage fail after deadline, a zero deadline clears it
+func (con *UnicastCon) SetReadDeadline(deadline time.Time) error {
+	return con.Con.SetReadDeadline(deadline)
+}
+
+func (con *UnicastCon) ReadMessage() (*VRRPPacket, error) {
+	if con.ipvX == IPv4 {
+		var n, errOfRead = con.Con.Read(con.buffer)
+		if errOfRead != nil {
+			return nil, fmt.Errorf("UnicastCon.ReadMessage: %w", errOfRead)
+		}
+		var advertisement, errOfParse = parseIPv4Advertisement(con.buffer[:n])
+		if errOfParse != nil {
+			return nil, fmt.Errorf("UnicastCon.ReadMessage: %w", errOfParse)
+		}
+		return advertisement, nil
+	}
+	var advertisement, errOfRead = readIPv6Advertisement(con.Con, con.buffer, con.oob)
+	if errOfRead != nil {
+		return nil, fmt.Errorf("UnicastCon.ReadMessage: %w", errOfRead)
+	}
+	return advertisement, nil
+}
+
+// readdress return a copy of packet with the check sum computed for the source saddr and the destination daddr
+func readdress(packet *VRRPPacket, saddr, daddr net.IP) *VRRPPacket {
+	var readdressed = *packet
+	readdressed.Header[6], readdressed.Header[7] = 0, 0
+	var pshdr = PseudoHeader{
+		Saddr:    saddr.To16(),
+		Daddr:    daddr.To16(),
+		Protocol: VRRPIPProtocolNumber,
+		Len:      uint16(len(packet.ToBytes())),
+	}
+	readdressed.SetCheckSum(&pshdr)
+	readdressed.Pshdr = &pshdr
+	return &readdressed
+}
@@ -0,0 +1,198 @@
+package vrrp_test
+
+import (
+	"errors"
+	"net"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+func TestUnicastPeers(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var backup = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:     1,
+		IPvX:     vrrp.IPv4,
+		Priority: 50,
+		SourceIP: net.ParseIP("10.0.0.1"),
+		Peers:    []net.IP{net.ParseIP("10.0.0.2")},
+	})
+	var master = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:     1,
+		IPvX:     vrrp.IPv4,
+		Priority: 100,
+		SourceIP: net.ParseIP("10.0.0.2"),
+		Peers:    []net.IP{net.ParseIP("10.0.0.1")},
+	})
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	backup.rec.never(t, 5*testInterval, vrrp.Backup2Master)
+
+	//the advertisements of a stranger with higher priority are ignored
+	startNode(t, seg, "10.0.0.3", 200, false)
+	master.rec.never(t, 10*testInterval, vrrp.Master2Backup)
+	if status := backup.vr.Status(); !status.MasterIP.Equal(net.ParseIP("10.0.0.2")) {
+		t.Fatalf("backup follows %v", status.MasterIP)
+	}
+	if errs := backup.vr.Statistics().UnknownPeerErrors; errs == 0 {
+		t.Fatal("advertisements of the stranger aren't counted")
+	}
+}
+
+func TestUnicastPeerFamily(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var endpoint = seg.Attach(net.ParseIP("10.0.0.1"))
+	var _, err = vrrp.New(&vrrp.Config{
+		VRID:       1,
+		IPvX:       vrrp.IPv4,
+		SourceIP:   net.ParseIP("10.0.0.1"),
+		Connection: endpoint,
+		Announcer:  endpoint,
+		Peers:      []net.IP{net.ParseIP("fe80::2")},
+	})
+	if !errors.Is(err, vrrp.ErrInvalidAddressFamily) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+// inNamespace run f with the calling thread in ns, the sockets created by f stay in ns
+func inNamespace(t *testing.T, ns netns.NsHandle, f func()) {
+	t.Helper()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	var origin, errOfGet = netns.Get()
+	if errOfGet != nil {
+		t.Fatal(errOfGet)
+	}
+	defer origin.Close()
+	if err := netns.Set(ns); err != nil {
+		t.Fatal(err)
+	}
+	defer netns.Set(origin)
+	f()
+}
+
+// readWithin read one advertisement from con, IPConnection has no read deadline
+func readWithin(t *testing.T, con vrrp.IPConnection, timeout time.Duration) (*vrrp.VRRPPacket, error) {
+	t.Helper()
+	type result struct {
+		packet *vrrp.VRRPPacket
+		err    error
+	}
+	var read = make(chan result, 1)
+	go func() {
+		var packet, err = con.ReadMessage()
+		read <- result{packet, err}
+	}()
+	select {
+	case r := <-read:
+		return r.packet, r.err
+	case <-time.After(timeout):
+		t.Fatal("no advertisement received")
+		return nil, nil
+	}
+}
+
+// awaitLocalRoute wait until packets to ip are delivered locally, the local route of an IPv6 address is
+// added by the kernel after the address itself
+func awaitLocalRoute(t *testing.T, handle *netlink.Handle, ip net.IP) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; {
+		var routes, err = handle.RouteGet(ip)
+		if err == nil && len(routes) > 0 && routes[0].Type == syscall.RTN_LOCAL {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no local route to %v: %v", ip, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUnicastConn(t *testing.T) {
+	for _, family := range []struct {
+		name          string
+		IPvX          byte
+		prefix        string
+		local, remote string
+		network       string
+	}{
+		{"IPv4", vrrp.IPv4, "/24", "10.9.0.1", "10.9.0.2", "ip4:112"},
+		{"IPv6", vrrp.IPv6, "/64", "fd00:9::1", "fd00:9::2", "ip6:112"},
+	} {
+		t.Run(family.name, func(t *testing.T) {
+			var ns, handle = newTestNamespace(t)
+			//the peers are local to each other, they talk through the loopback interface
+			var lo, _ = handle.LinkByName("lo")
+			if err := handle.LinkSetUp(lo); err != nil {
+				t.Fatal(err)
+			}
+			for nif, addr := range map[string]string{"v0": family.local, "v1": family.remote} {
+				var link, _ = handle.LinkByName(nif)
+				var parsed, _ = netlink.ParseAddr(addr + family.prefix)
+				parsed.Flags = syscall.IFA_F_NODAD
+				if err := handle.AddrAdd(link, parsed); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var local, remote = net.ParseIP(family.local), net.ParseIP(family.remote)
+			awaitLocalRoute(t, handle, local)
+			awaitLocalRoute(t, handle, remote)
+			var sender, receiver *vrrp.UnicastCon
+			var stranger *net.IPConn
+			inNamespace(t, ns, func() {
+				var err error
+				if sender, err = vrrp.DialUnicastConn(local, []net.IP{remote}); err != nil {
+					t.Fatal(err)
+				}
+				if receiver, err = vrrp.DialUnicastConn(remote, []net.IP{local}); err != nil {
+					t.Fatal(err)
+				}
+				if stranger, err = net.ListenIP(family.network, &net.IPAddr{IP: local}); err != nil {
+					t.Fatal(err)
+				}
+			})
+			defer sender.Close()
+			defer receiver.Close()
+			defer stranger.Close()
+
+			var packet vrrp.VRRPPacket
+			packet.SetVersion(vrrp.VRRPv3)
+			packet.SetType()
+			packet.SetVirtualRouterID(7)
+			packet.SetPriority(100)
+			packet.SetAdvertisementInterval(100)
+			if family.IPvX == vrrp.IPv4 {
+				packet.AddIPvXAddr(family.IPvX, net.ParseIP("10.9.0.254"))
+			} else {
+				packet.AddIPvXAddr(family.IPvX, net.ParseIP("fd00:9::fe"))
+			}
+			//the check sum computed for the multicast group doesn't fit the peer
+			packet.SetCheckSum(&vrrp.PseudoHeader{Saddr: local.To16(), Daddr: net.ParseIP("224.0.0.18").To16(), Protocol: vrrp.VRRPIPProtocolNumber, Len: uint16(len(packet.ToBytes()))})
+			if err := sender.WriteMessage(&packet); err != nil {
+				t.Fatal(err)
+			}
+			var got, errOfRead = readWithin(t, receiver, time.Second)
+			if errOfRead != nil {
+				t.Fatal(errOfRead)
+			}
+			if got.GetVirtualRouterID() != 7 || !got.Pshdr.Saddr.Equal(local) || !got.Pshdr.Daddr.Equal(remote) {
+				t.Fatalf("got VRID %v from %v to %v", got.GetVirtualRouterID(), got.Pshdr.Saddr, got.Pshdr.Daddr)
+			}
+
+			//the default TTL of a plain raw socket is rejected
+			if _, err := stranger.WriteToIP(packet.ToBytes(), &net.IPAddr{IP: remote}); err != nil {
+				t.Fatal(err)
+			}
+			if _, errOfRead = readWithin(t, receiver, time.Second); errOfRead == nil || !strings.Contains(errOfRead.Error(), "TTL") && !strings.Contains(errOfRead.Error(), "HOPLIMIT") {
+				t.Fatalf("got %v", errOfRead)
+			}
+		})
+	}
+}
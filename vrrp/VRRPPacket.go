@@ -10,7 +10,11 @@ import (
 type VRRPPacket struct {
 	Header    [8]byte
 	IPAddress [][4]byte
-	Pshdr     *PseudoHeader
+	//AuthData only exists in VRRPv2 advertisement, see RFC 3768 5.3.10
+	AuthData [8]byte
+	//Trailer is appended to the VRRPv3 advertisement in authenticated mode, see Authenticator
+	Trailer *AuthTrailer
+	Pshdr   *PseudoHeader
 }
 
 type PseudoHeader struct {
@@ -31,24 +35,40 @@ func (psh *PseudoHeader) ToBytes() []byte {
 
 func FromBytes(IPvXVersion byte, octets []byte) (*VRRPPacket, error) {
 	if len(octets) < 8 {
-		return nil, errors.New("faulty VRRP packet size")
+		return nil, fmt.Errorf("faulty VRRP packet size %v: %w", len(octets), ErrInvalidLength)
 	}
 	var packet VRRPPacket
 	for index := 0; index < 8; index++ {
 		packet.Header[index] = octets[index]
 	}
+	if packet.GetType() != 1 {
+		return nil, fmt.Errorf("VRRP packet type %v: %w", packet.GetType(), ErrInvalidType)
+	}
 	//todo validate the number of IPvX addresses
 	var countofaddrs = int(packet.GetIPvXAddrCount())
 	switch IPvXVersion {
 	case 4:
 	case 6:
+		if VRRPVersion(packet.GetVersion()) == VRRPv2 {
+			return nil, errors.New("VRRPv2 doesn't support IPv6")
+		}
 		countofaddrs = countofaddrs * 4
 	default:
 		return nil, fmt.Errorf("faulty IPvX version %d", IPvXVersion)
 	}
-	//to compatible with VRRP v2 packet, ignore the auth info
 	if 8+countofaddrs*4 > len(octets) {
-		return nil, fmt.Errorf("The value of filed IPvXAddrCount doesn't match the length of octets")
+		return nil, fmt.Errorf("The value of filed IPvXAddrCount doesn't match the length of octets: %w", ErrInvalidLength)
+	}
+	if VRRPVersion(packet.GetVersion()) == VRRPv2 {
+		if 8+countofaddrs*4+len(packet.AuthData) > len(octets) {
+			return nil, fmt.Errorf("VRRPv2 advertisement without authentication data: %w", ErrInvalidLength)
+		}
+		copy(packet.AuthData[:], octets[8+countofaddrs*4:])
+	}
+	if VRRPVersion(packet.GetVersion()) == VRRPv3 && len(octets)-(8+countofaddrs*4) == AuthTrailerLength {
+		var trailer AuthTrailer
+		trailer.fromBytes(octets[8+countofaddrs*4:])
+		packet.Trailer = &trailer
 	}
 	for index := 0; index < countofaddrs; index++ {
 		var addr [4]byte
@@ -142,25 +162,59 @@ func (packet *VRRPPacket) setIPvXAddrCount(count byte) {
 	packet.Header[3] = count
 }
 
+// GetAdvertisementInterval return the advertisement interval in centiseconds,
+// the interval of VRRPv2 advertisement is carried in seconds and converted here
 func (packet *VRRPPacket) GetAdvertisementInterval() uint16 {
+	if VRRPVersion(packet.GetVersion()) == VRRPv2 {
+		return uint16(packet.Header[5]) * 100
+	}
 	return uint16(packet.Header[4]&15)<<8 | uint16(packet.Header[5])
 }
 
+// SetAdvertisementInterval set the advertisement interval in centiseconds,
+// version of the packet must be set before calling this method
 func (packet *VRRPPacket) SetAdvertisementInterval(interval uint16) {
+	if VRRPVersion(packet.GetVersion()) == VRRPv2 {
+		var seconds = (interval + 99) / 100
+		if seconds > 255 {
+			seconds = 255
+		}
+		packet.Header[5] = byte(seconds)
+		return
+	}
 	packet.Header[4] = (packet.Header[4] & 240) | byte((interval>>8)&15)
 	packet.Header[5] = byte(interval)
 }
 
+// GetAuthType return the authentication type of VRRPv2 advertisement
+func (packet *VRRPPacket) GetAuthType() byte {
+	return packet.Header[4]
+}
+
+// SetAuthType set the authentication type of VRRPv2 advertisement
+func (packet *VRRPPacket) SetAuthType(authType byte) {
+	packet.Header[4] = authType
+}
+
 func (packet *VRRPPacket) GetCheckSum() uint16 {
 	return uint16(packet.Header[6])<<8 | uint16(packet.Header[7])
 }
 
+// checkSumOctets return the octets covered by the checksum, VRRPv2 calculates
+// the checksum over the VRRP message only while VRRPv3 prepends the pseudo header
+func (packet *VRRPPacket) checkSumOctets(pshdr *PseudoHeader) []byte {
+	if VRRPVersion(packet.GetVersion()) == VRRPv2 {
+		return packet.ToBytes()
+	}
+	var octets = pshdr.ToBytes()
+	return append(octets, packet.ToBytes()...)
+}
+
 func (packet *VRRPPacket) SetCheckSum(pshdr *PseudoHeader) {
 	var PointerAdd = func(ptr unsafe.Pointer, bytes int) unsafe.Pointer {
 		return unsafe.Pointer(uintptr(ptr) + uintptr(bytes))
 	}
-	var octets = pshdr.ToBytes()
-	octets = append(octets, packet.ToBytes()...)
+	var octets = packet.checkSumOctets(pshdr)
 	var x = len(octets)
 	var ptr = unsafe.Pointer(&octets[0])
 	var sum uint32
@@ -184,8 +238,7 @@ func (packet *VRRPPacket) ValidateCheckSum(pshdr *PseudoHeader) bool {
 	var PointerAdd = func(ptr unsafe.Pointer, bytes int) unsafe.Pointer {
 		return unsafe.Pointer(uintptr(ptr) + uintptr(bytes))
 	}
-	var octets = pshdr.ToBytes()
-	octets = append(octets, packet.ToBytes()...)
+	var octets = packet.checkSumOctets(pshdr)
 	var x = len(octets)
 	var ptr = unsafe.Pointer(&octets[0])
 	var sum uint32
@@ -213,5 +266,10 @@ func (packet *VRRPPacket) ToBytes() []byte {
 	for index := range packet.IPAddress {
 		copy(payload[8+index*4:], packet.IPAddress[index][:])
 	}
+	if VRRPVersion(packet.GetVersion()) == VRRPv2 {
+		payload = append(payload, packet.AuthData[:]...)
+	} else if packet.Trailer != nil {
+		payload = append(payload, packet.Trailer.toBytes()...)
+	}
 	return payload
 }
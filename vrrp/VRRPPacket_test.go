@@ -0,0 +1,135 @@
+package vrrp_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+
+	"vrrp-go/vrrp"
+)
+
+// onesComplementSum fold octets into the 16 bits one's complement sum of RFC 1071, a valid checksum makes it 0xffff
+func onesComplementSum(octets []byte) uint16 {
+	var sum uint32
+	for index := 0; index+1 < len(octets); index += 2 {
+		sum += uint32(octets[index])<<8 | uint32(octets[index+1])
+	}
+	if len(octets)%2 == 1 {
+		sum += uint32(octets[len(octets)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return uint16(sum)
+}
+
+// v2Packet assemble a VRRPv2 advertisement with simple text authentication
+func v2Packet(interval uint16) *vrrp.VRRPPacket {
+	var packet vrrp.VRRPPacket
+	packet.SetVersion(vrrp.VRRPv2)
+	packet.SetType()
+	packet.SetVirtualRouterID(51)
+	packet.SetPriority(150)
+	packet.SetAuthType(vrrp.AuthTypeSimpleText)
+	copy(packet.AuthData[:], "secret")
+	packet.SetAdvertisementInterval(interval)
+	packet.AddIPvXAddr(vrrp.IPv4, net.ParseIP("192.168.1.254"))
+	packet.AddIPvXAddr(vrrp.IPv4, net.ParseIP("192.168.1.253"))
+	return &packet
+}
+
+func TestVRRPv2RoundTrip(t *testing.T) {
+	var packet = v2Packet(300)
+	var pshdr = &vrrp.PseudoHeader{Saddr: net.ParseIP("10.0.0.1"), Daddr: vrrp.VRRPMultiAddrIPv4, Protocol: vrrp.VRRPIPProtocolNumber}
+	packet.SetCheckSum(pshdr)
+	var octets = packet.ToBytes()
+	//RFC 3768 5.1, the version 2 and the type 1 share the first octet, the authentication data follows the addresses
+	var want = []byte{0x21, 51, 150, 2, vrrp.AuthTypeSimpleText, 3, octets[6], octets[7],
+		192, 168, 1, 254, 192, 168, 1, 253, 's', 'e', 'c', 'r', 'e', 't', 0, 0}
+	if !bytes.Equal(octets, want) {
+		t.Fatalf("encoded % x, want % x", octets, want)
+	}
+	var decoded, err = vrrp.FromBytes(vrrp.IPv4, octets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vrrp.VRRPVersion(decoded.GetVersion()) != vrrp.VRRPv2 || decoded.GetVirtualRouterID() != 51 || decoded.GetPriority() != 150 {
+		t.Fatalf("decoded header % x", decoded.Header)
+	}
+	if decoded.GetAuthType() != vrrp.AuthTypeSimpleText || decoded.AuthData != packet.AuthData {
+		t.Fatalf("decoded authentication %v %q", decoded.GetAuthType(), decoded.AuthData)
+	}
+	if addrs := decoded.GetIPvXAddr(vrrp.IPv4); len(addrs) != 2 || !addrs[1].Equal(net.ParseIP("192.168.1.253")) {
+		t.Fatalf("decoded addresses %v", addrs)
+	}
+	if decoded.Trailer != nil || !bytes.Equal(decoded.ToBytes(), octets) {
+		t.Fatalf("encoded again % x", decoded.ToBytes())
+	}
+
+	if _, err = vrrp.FromBytes(vrrp.IPv4, octets[:len(octets)-1]); !errors.Is(err, vrrp.ErrInvalidLength) {
+		t.Fatalf("advertisement with truncated authentication data: got %v", err)
+	}
+	if _, err = vrrp.FromBytes(vrrp.IPv6, octets); err == nil {
+		t.Fatal("VRRPv2 advertisement over IPv6 is accepted")
+	}
+}
+
+func TestVRRPv2CheckSum(t *testing.T) {
+	var packet = v2Packet(100)
+	var pshdr = &vrrp.PseudoHeader{Saddr: net.ParseIP("10.0.0.1"), Daddr: vrrp.VRRPMultiAddrIPv4, Protocol: vrrp.VRRPIPProtocolNumber}
+	packet.SetCheckSum(pshdr)
+	//RFC 3768 5.3.8, the checksum covers the VRRP message only
+	if sum := onesComplementSum(packet.ToBytes()); sum != 0xffff {
+		t.Fatalf("sum of the message is %#x", sum)
+	}
+	var other = &vrrp.PseudoHeader{Saddr: net.ParseIP("10.0.0.9"), Daddr: vrrp.VRRPMultiAddrIPv4, Protocol: vrrp.VRRPIPProtocolNumber}
+	if !packet.ValidateCheckSum(other) {
+		t.Fatal("checksum of VRRPv2 advertisement depends on the pseudo header")
+	}
+	packet.SetPriority(151)
+	if packet.ValidateCheckSum(pshdr) {
+		t.Fatal("modified advertisement passes the checksum")
+	}
+
+	//RFC 5798 5.2.8, the checksum of VRRPv3 prepends the pseudo header
+	var v3 vrrp.VRRPPacket
+	v3.SetVersion(vrrp.VRRPv3)
+	v3.SetType()
+	v3.SetVirtualRouterID(51)
+	v3.SetPriority(150)
+	v3.SetAdvertisementInterval(100)
+	v3.AddIPvXAddr(vrrp.IPv4, net.ParseIP("192.168.1.254"))
+	pshdr.Len = uint16(len(v3.ToBytes()))
+	v3.SetCheckSum(pshdr)
+	if sum := onesComplementSum(append(pshdr.ToBytes(), v3.ToBytes()...)); sum != 0xffff {
+		t.Fatalf("sum of the pseudo header and the message is %#x", sum)
+	}
+	other.Len = pshdr.Len
+	if v3.ValidateCheckSum(other) {
+		t.Fatal("checksum of VRRPv3 advertisement ignores the pseudo header")
+	}
+}
+
+func TestVRRPv2IntervalInSeconds(t *testing.T) {
+	for _, c := range []struct {
+		centiseconds uint16
+		seconds      byte
+	}{
+		{100, 1},
+		{300, 3},
+		//the interval is rounded up to whole seconds
+		{150, 2},
+		{1, 1},
+		//and capped by the octet carrying it
+		{40950, 255},
+	} {
+		var packet = v2Packet(c.centiseconds)
+		if packet.Header[5] != c.seconds || packet.GetAdvertisementInterval() != uint16(c.seconds)*100 {
+			t.Errorf("interval %v carried as %v seconds, read back %v", c.centiseconds, packet.Header[5], packet.GetAdvertisementInterval())
+		}
+		if packet.GetAuthType() != vrrp.AuthTypeSimpleText {
+			t.Errorf("interval %v overwrote the authentication type with %v", c.centiseconds, packet.GetAuthType())
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package vrrp
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"vrrp-go/logger"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// VirtualMAC return the virtual router MAC address of VRID, 00-00-5E-00-01-{VRID} for IPv4
+// and 00-00-5E-00-02-{VRID} for IPv6, see RFC 5798 7.3
+func VirtualMAC(VRID byte, IPvX byte) net.HardwareAddr {
+	if IPvX == IPv6 {
+		return net.HardwareAddr{0x00, 0x00, 0x5e, 0x00, 0x02, VRID}
+	}
+	return net.HardwareAddr{0x00, 0x00, 0x5e, 0x00, 0x01, VRID}
+}
+
+// defaultMacvlanName return the name of the macvlan interface created for VRID
+func defaultMacvlanName(VRID byte, IPvX byte) string {
+	if IPvX == IPv6 {
+		return fmt.Sprintf("vrrp6.%d", VRID)
+	}
+	return fmt.Sprintf("vrrp.%d", VRID)
+}
+
+// EnsureMacvlan create the macvlan interface name on parent carrying mac and bring it up,
+// an existing macvlan interface of parent with that name is adopted and its address is set to mac.
+// The interface is left in place when the virtual router stops so the next start adopts it.
+func EnsureMacvlan(parent, name string, mac net.HardwareAddr, ns *netns.NsHandle) (*net.Interface, error) {
+	var handle *netlink.Handle
+	var errOfHandle error
+	if ns != nil {
+		handle, errOfHandle = netlink.NewHandleAt(*ns)
+	} else {
+		handle, errOfHandle = netlink.NewHandle()
+	}
+	if errOfHandle != nil {
+		return nil, fmt.Errorf("EnsureMacvlan: %w", socketError(errOfHandle))
+	}
+	defer handle.Close()
+	var parentLink, errOfGetParent = handle.LinkByName(parent)
+	if errOfGetParent != nil {
+		return nil, fmt.Errorf("EnsureMacvlan: %w: %v", ErrInterfaceNotFound, errOfGetParent)
+	}
+	var link, errOfGetLink = handle.LinkByName(name)
+	if errOfGetLink == nil {
+		if _, ok := link.(*netlink.Macvlan); !ok || link.Attrs().ParentIndex != parentLink.Attrs().Index {
+			return nil, fmt.Errorf("EnsureMacvlan: %w: %v is not a macvlan interface of %v", ErrInterfaceConflict, name, parent)
+		}
+		if !bytes.Equal(link.Attrs().HardwareAddr, mac) {
+			if errOfSetMAC := handle.LinkSetHardwareAddr(link, mac); errOfSetMAC != nil {
+				return nil, fmt.Errorf("EnsureMacvlan: %w", socketError(errOfSetMAC))
+			}
+		}
+		logger.GLoger.Printf(logger.INFO, "macvlan interface %v adopted", name)
+	} else {
+		var macvlan = &netlink.Macvlan{
+			LinkAttrs: netlink.LinkAttrs{Name: name, ParentIndex: parentLink.Attrs().Index, HardwareAddr: mac},
+			Mode:      netlink.MACVLAN_MODE_BRIDGE,
+		}
+		if errOfAdd := handle.LinkAdd(macvlan); errOfAdd != nil {
+			return nil, fmt.Errorf("EnsureMacvlan: %w", socketError(errOfAdd))
+		}
+		logger.GLoger.Printf(logger.INFO, "macvlan interface %v created on %v", name, parent)
+		//the backup must not send anything from the virtual MAC, so no IPv6 link local address is generated
+		if ns == nil {
+			if errOfWrite := os.WriteFile(fmt.Sprintf("/proc/sys/net/ipv6/conf/%s/addr_gen_mode", name), []byte("1"), 0644); errOfWrite != nil {
+				logger.GLoger.Printf(logger.DEBUG, "EnsureMacvlan: %v", errOfWrite)
+			}
+		}
+	}
+	if link, errOfGetLink = handle.LinkByName(name); errOfGetLink != nil {
+		return nil, fmt.Errorf("EnsureMacvlan: %w", errOfGetLink)
+	}
+	if errOfSetUp := handle.LinkSetUp(link); errOfSetUp != nil {
+		return nil, fmt.Errorf("EnsureMacvlan: %w", socketError(errOfSetUp))
+	}
+	var attrs = link.Attrs()
+	return &net.Interface{
+		Index:        attrs.Index,
+		MTU:          attrs.MTU,
+		Name:         attrs.Name,
+		HardwareAddr: append(net.HardwareAddr(nil), mac...),
+		Flags:        attrs.Flags | net.FlagUp,
+	}, nil
+}
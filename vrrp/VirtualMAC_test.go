@@ -0,0 +1,152 @@
+package vrrp_test
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+func TestVirtualMAC(t *testing.T) {
+	for _, c := range []struct {
+		VRID byte
+		IPvX byte
+		want string
+	}{
+		{1, vrrp.IPv4, "00:00:5e:00:01:01"},
+		{10, vrrp.IPv4, "00:00:5e:00:01:0a"},
+		{255, vrrp.IPv6, "00:00:5e:00:02:ff"},
+	} {
+		if got := vrrp.VirtualMAC(c.VRID, c.IPvX).String(); got != c.want {
+			t.Errorf("VirtualMAC(%v, %v) = %v, want %v", c.VRID, c.IPvX, got, c.want)
+		}
+	}
+}
+
+func TestVirtualMACAnnounced(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var node = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:       1,
+		IPvX:       vrrp.IPv4,
+		SourceIP:   net.ParseIP("10.0.0.1"),
+		VirtualMAC: true,
+		Addresses:  []net.IP{net.ParseIP("192.168.1.254")},
+	})
+	node.rec.await(t, vrrp.Backup2Master, time.Second)
+	var announcements = seg.Announcements()
+	if len(announcements) == 0 {
+		t.Fatalf("nothing announced")
+	}
+	for _, a := range announcements {
+		if a.HardwareAddr.String() != "00:00:5e:00:01:01" {
+			t.Fatalf("announced %v", a.HardwareAddr)
+		}
+	}
+}
+
+func TestEnsureMacvlan(t *testing.T) {
+	var ns, handle = newTestNamespace(t)
+	var mac = vrrp.VirtualMAC(1, vrrp.IPv4)
+	var created, errOfCreate = vrrp.EnsureMacvlan("v0", "vrrp.1", mac, &ns)
+	if errOfCreate != nil {
+		t.Fatal(errOfCreate)
+	}
+	var link, errOfGetLink = handle.LinkByName("vrrp.1")
+	if errOfGetLink != nil {
+		t.Fatal(errOfGetLink)
+	}
+	var parent, _ = handle.LinkByName("v0")
+	if _, ok := link.(*netlink.Macvlan); !ok || link.Attrs().ParentIndex != parent.Attrs().Index {
+		t.Fatalf("vrrp.1 is %v on %v", link.Type(), link.Attrs().ParentIndex)
+	}
+	if link.Attrs().HardwareAddr.String() != mac.String() || link.Attrs().Flags&net.FlagUp == 0 {
+		t.Fatalf("vrrp.1 has %v, flags %v", link.Attrs().HardwareAddr, link.Attrs().Flags)
+	}
+
+	//someone changed the address, it's put back on adoption
+	if err := handle.LinkSetHardwareAddr(link, net.HardwareAddr{0x02, 0, 0, 0, 0, 1}); err != nil {
+		t.Fatal(err)
+	}
+	var adopted, errOfAdopt = vrrp.EnsureMacvlan("v0", "vrrp.1", mac, &ns)
+	if errOfAdopt != nil {
+		t.Fatal(errOfAdopt)
+	}
+	if adopted.Index != created.Index {
+		t.Fatalf("adopted interface %v, created %v", adopted.Index, created.Index)
+	}
+	link, _ = handle.LinkByName("vrrp.1")
+	if link.Attrs().HardwareAddr.String() != mac.String() {
+		t.Fatalf("address of adopted interface is %v", link.Attrs().HardwareAddr)
+	}
+
+	if _, err := vrrp.EnsureMacvlan("v0", "v1", mac, &ns); !errors.Is(err, vrrp.ErrInterfaceConflict) {
+		t.Fatalf("EnsureMacvlan on a veth returned %v", err)
+	}
+	if _, err := vrrp.EnsureMacvlan("missing", "vrrp.2", mac, &ns); !errors.Is(err, vrrp.ErrInterfaceNotFound) {
+		t.Fatalf("EnsureMacvlan on a missing parent returned %v", err)
+	}
+}
+
+func TestVirtualMACReinstallsAddresses(t *testing.T) {
+	var ns, handle = newTestNamespace(t)
+	var link, _ = handle.LinkByName("v0")
+	var source, _ = netlink.ParseAddr("10.7.0.1/24")
+	source.Flags = syscall.IFA_F_NODAD
+	if err := handle.AddrAdd(link, source); err != nil {
+		t.Fatal(err)
+	}
+	var seg = simnet.NewSegment()
+	var endpoint = seg.Attach(net.ParseIP("10.7.0.1"))
+	var vr *vrrp.VirtualRouter
+	inNamespace(t, ns, func() {
+		var err error
+		vr, err = vrrp.New(&vrrp.Config{
+			VRID:                  1,
+			IPvX:                  vrrp.IPv4,
+			Interface:             "v0",
+			AdvertisementInterval: testInterval,
+			VirtualMAC:            true,
+			Connection:            endpoint,
+			Announcer:             endpoint,
+			Addresses:             []net.IP{net.ParseIP("192.168.1.254")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	var rec = newRecorder(vr)
+	go vr.StartWithEventSelector()
+	t.Cleanup(vr.Stop)
+	rec.await(t, vrrp.Backup2Master, time.Second)
+	var macvlan, _ = handle.LinkByName("vrrp.1")
+	var vip, _ = netlink.ParseAddr("192.168.1.254/32")
+	var installed = func() bool {
+		var addrs, _ = handle.AddrList(macvlan, netlink.FAMILY_V4)
+		for _, addr := range addrs {
+			if addr.IPNet.String() == vip.IPNet.String() {
+				return true
+			}
+		}
+		return false
+	}
+	var awaitInstalled = func() {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); !installed(); time.Sleep(5 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("%v is not installed on vrrp.1", vip)
+			}
+		}
+	}
+	awaitInstalled()
+
+	//someone deletes the address by hand, the virtual router puts it back
+	if err := handle.AddrDel(macvlan, vip); err != nil {
+		t.Fatal(err)
+	}
+	awaitInstalled()
+}
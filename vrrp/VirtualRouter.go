@@ -1,10 +1,19 @@
 package vrrp
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 	"vrrp-go/logger"
+
+	"github.com/vishvananda/netns"
 )
 
 type VirtualRouter struct {
@@ -13,159 +22,962 @@ type VirtualRouter struct {
 	advertisementInterval         uint16
 	advertisementIntervalOfMaster uint16
 	skewTime                      uint16
-	masterDownInterval            uint16
+	masterDownInterval            uint32
 	preempt                       bool
+	preemptDelay                  time.Duration
+	startupDelay                  time.Duration
 	owner                         bool
 	virtualRouterMACAddressIPv4   net.HardwareAddr
 	virtualRouterMACAddressIPv6   net.HardwareAddr
+	version                       VRRPVersion
+	v2Compatible                  bool
+	authType                      byte
+	authData                      [8]byte
+	//accepted is the copy of version, v2Compatible, authType and authData read by the reader
+	accepted atomic.Pointer[versionPolicy]
 	//
+	interfaceMutex      sync.RWMutex
 	netInterface        *net.Interface
+	namespace           *netns.NsHandle
 	ipvX                byte
 	preferredSourceIP   net.IP
+	sourceConfigured    bool
+	addrMutex           sync.RWMutex
 	protectedIPaddrs    map[[16]byte]bool
-	state               int
+	state               State
+	lastTransition      time.Time
+	startupUntil        time.Time
+	preempting          bool
+	handedOff           bool
+	upSince             time.Time
+	created             time.Time
+	counters            counters
+	preemptSince        time.Time
+	masterIP            net.IP
+	masterPriority      byte
 	iplayerInterface    IPConnection
 	ipAddrAnnouncer     AddrAnnouncer
+	addrInstaller       AddrInstaller
+	packetFilter        PacketFilter
+	acceptMode          bool
+	ownInstaller        bool
+	useVirtualMAC       bool
+	macvlanInterface    *net.Interface
+	peers               []net.IP
+	authenticator       *Authenticator
+	basePriority        byte
+	tracks              []*trackState
+	syncGroup           *SyncGroup
+	syncFault           bool
+	linkTrack           *trackState
+	sourceTrack         *trackState
+	ownConnection       bool
+	ownAnnouncer        bool
+	stopReader          func()
+	readerStopped       chan struct{}
+	readerInterruptible bool
 	eventChannel        chan EVENT
 	packetQueue         chan *VRRPPacket
-	advertisementTicker *time.Ticker
-	masterDownTimer     *time.Timer
-	transitionHandler   map[transition]func()
+	clock               Clock
+	advertisementTicker Ticker
+	masterDownTimer     Timer
+	masterResigned      bool
+	handlerMutex        sync.Mutex
+	transitionHandler   map[Transition]func()
+	//handlerQueue calls the enrolled handlers in the order of the transitions, at most HANDLERQUEUESIZE are pending
+	handlerQueue    serialQueue
+	subscriberMutex sync.Mutex
+	subscribers     map[*Subscription]struct{}
+	//advertisementSubscribers are guarded by subscriberMutex as well
+	advertisementSubscribers map[*AdvertisementSubscription]struct{}
+	//runtime reconfiguration is routed through commandChannel while running
+	mutex    sync.Mutex
+	running  bool
+	loopDone chan struct{}
+	//starting is set while Run sets up, stopRequested records a Stop made meanwhile and Run returns instead of entering the event loop
+	starting       bool
+	stopRequested  bool
+	commandChannel chan func()
+	statusMutex    sync.RWMutex
+	status         Status
+}
+
+// Config describes how a virtual router is created
+type Config struct {
+	VRID  byte
+	Owner bool
+	IPvX  byte
+	// Interface is the name of the network interface the virtual router works on,
+	// it can be left empty when Connection, Announcer and SourceIP are all supplied
+	Interface string
+	// SourceIP overrides the preferred source IP address found on Interface
+	SourceIP net.IP
+	// Connection sends and receives advertisements, a raw IP connection on Interface is used if nil.
+	// Run doesn't close it, it should implement SetReadDeadline so that Run can interrupt a pending read.
+	Connection IPConnection
+	// Announcer announces the protected IP addresses, an ARP/NDP client on Interface is used if nil
+	Announcer AddrAnnouncer
+	// Clock drives the timers of the state machine, the wall clock is used if nil
+	Clock Clock
+	// Priority is ignored by the owner, 100 is used if zero
+	Priority byte
+	// AdvertisementInterval must be in [10ms, 40.95s], or in [10ms, 255s] for VRRPv2 which rounds it up to
+	// whole seconds, 1 second is used if zero
+	AdvertisementInterval time.Duration
+	// Version is VRRPv3 if zero, VRRPv2 is only available for IPv4 without Authenticator
+	Version VRRPVersion
+	// V2Compatibility makes a VRRPv3 virtual router coexist with VRRPv2 routers as described in
+	// RFC 5798 8.4, it's only available for IPv4 without Authenticator
+	V2Compatibility bool
+	// V2AuthType is AuthTypeNone or AuthTypeSimpleText, V2AuthKey is the simple text password
+	// truncated to 8 bytes. Both are carried in the VRRPv2 advertisements.
+	V2AuthType byte
+	V2AuthKey  string
+	// Addresses are the IP addresses protected from the start
+	Addresses []net.IP
+	// AddrInstaller configures the protected addresses on the host while the virtual router is MASTER,
+	// the addresses are only announced if nil
+	AddrInstaller AddrInstaller
+	// VirtualMAC makes the virtual router announce the RFC 5798 virtual MAC address instead of the one of
+	// Interface. A macvlan interface carrying the virtual MAC is created on Interface or adopted,
+	// advertisements and gratuitous ARP are sent from it and, unless AddrInstaller is supplied,
+	// the protected addresses are installed on it and put back by Run when someone deletes them.
+	VirtualMAC bool
+	// MacvlanName is the name of the macvlan interface, vrrp.{VRID} or vrrp6.{VRID} is used if empty
+	MacvlanName string
+	// AcceptMode controls whether a non-owner MASTER accepts packets addressed to the protected
+	// addresses, see RFC 5798 6.1. It's enforced by PacketFilter, the owner always accepts.
+	AcceptMode bool
+	// PacketFilter blocks the protected addresses while Accept_Mode is false, nothing is blocked if nil
+	PacketFilter PacketFilter
+	// Peers switches the virtual router to unicast mode, advertisements are sent to each peer
+	// instead of the multicast group and only the advertisements from the peers are accepted
+	Peers []net.IP
+	// Authenticator signs the VRRPv3 advertisements and rejects those not signed with its keys,
+	// the advertisements are neither signed nor checked if nil
+	Authenticator *Authenticator
+	// Tracks adjust the priority with the health of the objects the virtual router depends on,
+	// the priority of the owner is never adjusted
+	Tracks []Track
+	// NoPreempt keeps the virtual router from preempting a lower priority master
+	NoPreempt bool
+	// PreemptDelay is how long a lower priority master is followed before it's preempted,
+	// it doesn't delay the takeover when the master is down
+	PreemptDelay time.Duration
+	// StartupDelay is how long the virtual router stays BACKUP after it starts regardless of its priority
+	StartupDelay time.Duration
 }
 
 // NewVirtualRouter create a new virtual router with designated parameters
 func NewVirtualRouter(VRID byte, nif string, Owner bool, IPvX byte) *VirtualRouter {
+	return NewVirtualRouterWithConfig(&Config{VRID: VRID, Interface: nif, Owner: Owner, IPvX: IPvX})
+}
+
+// NewVirtualRouterWithConfig create a new virtual router described by cfg, it panics on failure
+func NewVirtualRouterWithConfig(cfg *Config) *VirtualRouter {
+	var vr, errOfNew = New(cfg)
+	if errOfNew != nil {
+		logger.GLoger.Printf(logger.FATAL, "NewVirtualRouter: %v", errOfNew)
+	}
+	return vr
+}
+
+// New create a new virtual router described by cfg, caller supplied IPConnection and AddrAnnouncer
+// are used instead of raw sockets on the interface
+func New(cfg *Config) (*VirtualRouter, error) {
+	var VRID, nif, IPvX = cfg.VRID, cfg.Interface, cfg.IPvX
 	if IPvX != IPv4 && IPvX != IPv6 {
-		logger.GLoger.Printf(logger.FATAL, "NewVirtualRouter: parameter IPvx must be IPv4 or IPv6")
+		return nil, fmt.Errorf("New: %w: %v", ErrInvalidAddressFamily, IPvX)
+	}
+	var interval, priority, version = cfg.AdvertisementInterval, cfg.Priority, cfg.Version
+	if interval == 0 {
+		interval = defaultAdvertisementInterval
+	}
+	if priority == 0 {
+		priority = defaultPriority
+	}
+	if version == 0 {
+		version = VRRPv3
+	}
+	if errOfVersion := validateVersion(version, IPvX, cfg.Authenticator != nil); errOfVersion != nil {
+		return nil, fmt.Errorf("New: %w", errOfVersion)
+	}
+	if errOfInterval := validateInterval(interval, version); errOfInterval != nil {
+		return nil, fmt.Errorf("New: %w", errOfInterval)
+	}
+	if cfg.V2Compatibility {
+		if errOfVersion := validateVersion(VRRPv2, IPvX, cfg.Authenticator != nil); errOfVersion != nil {
+			return nil, fmt.Errorf("New: VRRPv2 compatibility: %w", errOfVersion)
+		}
+	}
+	var authData, errOfAuth = v2AuthData(cfg.V2AuthType, cfg.V2AuthKey)
+	if errOfAuth != nil {
+		return nil, fmt.Errorf("New: %w", errOfAuth)
 	}
 	var vr = &VirtualRouter{}
 	vr.vrID = VRID
-	vr.virtualRouterMACAddressIPv4, _ = net.ParseMAC(fmt.Sprintf("00-00-5E-00-01-%X", VRID))
-	vr.virtualRouterMACAddressIPv6, _ = net.ParseMAC(fmt.Sprintf("00-00-5E-00-02-%X", VRID))
-	vr.owner = Owner
+	vr.virtualRouterMACAddressIPv4 = VirtualMAC(VRID, IPv4)
+	vr.virtualRouterMACAddressIPv6 = VirtualMAC(VRID, IPv6)
+	vr.useVirtualMAC = cfg.VirtualMAC
+	vr.owner = cfg.Owner
 	//default values that defined by RFC 5798
-	if Owner {
-		vr.priority = 255
+	if cfg.Owner {
+		vr.priority, vr.basePriority = 255, 255
 	}
 	vr.state = INIT
-	vr.preempt = defaultPreempt
-	vr.SetAdvInterval(defaultAdvertisementInterval)
-	vr.SetPriorityAndMasterAdvInterval(defaultPriority, defaultAdvertisementInterval)
+	vr.ipvX = IPvX
+	vr.version = version
+	vr.v2Compatible = cfg.V2Compatibility
+	vr.authType, vr.authData = cfg.V2AuthType, authData
+	vr.updateAccepted()
+	vr.preempt = defaultPreempt && !cfg.NoPreempt
+	vr.preemptDelay = cfg.PreemptDelay
+	vr.startupDelay = cfg.StartupDelay
+	vr.advertisementInterval = vr.normalizeInterval(uint16(interval / (10 * time.Millisecond)))
+	vr.setPriority(priority)
+	vr.setMasterAdvInterval(vr.advertisementInterval)
 
 	//make
 	vr.protectedIPaddrs = make(map[[16]byte]bool)
 	vr.eventChannel = make(chan EVENT, EVENTCHANNELSIZE)
 	vr.packetQueue = make(chan *VRRPPacket, PACKETQUEUESIZE)
-	vr.transitionHandler = make(map[transition]func())
+	vr.transitionHandler = make(map[Transition]func())
+	vr.subscribers = make(map[*Subscription]struct{})
+	vr.advertisementSubscribers = make(map[*AdvertisementSubscription]struct{})
+	vr.commandChannel = make(chan func())
+	vr.handlerQueue.limit = HANDLERQUEUESIZE
+	defer vr.refreshStatus()
+	for _, ip := range cfg.Addresses {
+		var key [16]byte
+		copy(key[:], ip.To16())
+		vr.protectedIPaddrs[key] = true
+	}
 
-	vr.ipvX = IPvX
+	for _, peer := range cfg.Peers {
+		if (peer.To4() != nil) != (IPvX == IPv4) {
+			return nil, fmt.Errorf("New: %w: peer %v of IPv%v virtual router", ErrInvalidAddressFamily, peer, IPvX)
+		}
+		vr.peers = append(vr.peers, peer)
+	}
+
+	vr.authenticator = cfg.Authenticator
+	for _, track := range cfg.Tracks {
+		var t, errOfTrack = newTrackState(track)
+		if errOfTrack != nil {
+			return nil, fmt.Errorf("New: %w", errOfTrack)
+		}
+		vr.tracks = append(vr.tracks, t)
+	}
+	vr.priority = vr.effectivePriority()
+
+	vr.clock = cfg.Clock
+	if vr.clock == nil {
+		vr.clock = SystemClock
+	}
+	vr.created = vr.clock.Now()
+	vr.iplayerInterface = cfg.Connection
+	vr.ipAddrAnnouncer = cfg.Announcer
+	vr.preferredSourceIP = cfg.SourceIP
+	vr.sourceConfigured = cfg.SourceIP != nil
+	vr.addrInstaller = cfg.AddrInstaller
+	vr.packetFilter = cfg.PacketFilter
+	vr.acceptMode = cfg.AcceptMode
+	if nif == "" {
+		if vr.iplayerInterface == nil || vr.ipAddrAnnouncer == nil || vr.preferredSourceIP == nil {
+			return nil, fmt.Errorf("New: %w: interface must be designated unless connection, announcer and source IP are supplied", ErrInterfaceNotFound)
+		}
+		logger.GLoger.Printf(logger.INFO, "virtual router %v initialized, working on caller supplied transport", VRID)
+		return vr, nil
+	}
 	var NetworkInterface, errOfGetIF = net.InterfaceByName(nif)
 	if errOfGetIF != nil {
-		logger.GLoger.Printf(logger.FATAL, "NewVirtualRouter: %v", errOfGetIF)
+		return nil, fmt.Errorf("New: %w: %v", ErrInterfaceNotFound, errOfGetIF)
 	}
 	vr.netInterface = NetworkInterface
 	//find preferred local IP address
-	if preferred, errOfGetPreferred := findIPbyInterface(NetworkInterface, IPvX); errOfGetPreferred != nil {
-		logger.GLoger.Printf(logger.FATAL, "NewVirtualRouter: %v", errOfGetPreferred)
+	if vr.preferredSourceIP == nil {
+		if preferred, errOfGetPreferred := findIPbyInterface(NetworkInterface, IPvX); errOfGetPreferred != nil {
+			return nil, fmt.Errorf("New: %w: %v", ErrNoSourceAddress, errOfGetPreferred)
+		} else {
+			vr.preferredSourceIP = preferred
+		}
+	}
+	//the virtual router is in FAULT while the interface is down or the source address is gone,
+	//both tracks are kept up to date by watchInterface while the virtual router is running
+	vr.namespace = callerNamespace()
+	vr.linkTrack = &trackState{Track: Track{Name: "interface " + nif, Tracker: &LinkTracker{Interface: nif, Namespace: vr.namespace}, Interval: time.Second, Timeout: time.Second}}
+	vr.sourceTrack = &trackState{Track: Track{Name: "source address", Tracker: &AddressTracker{Interface: nif, Address: vr.preferredSourceIP, Namespace: vr.namespace}, Interval: time.Second, Timeout: time.Second}}
+	vr.tracks = append(vr.tracks, vr.linkTrack, vr.sourceTrack)
+	if cfg.VirtualMAC {
+		var name = cfg.MacvlanName
+		if name == "" {
+			name = defaultMacvlanName(VRID, IPvX)
+		}
+		var macvlan, errOfMacvlan = EnsureMacvlan(nif, name, vr.HardwareAddr(), nil)
+		if errOfMacvlan != nil {
+			return nil, fmt.Errorf("New: %w", errOfMacvlan)
+		}
+		vr.macvlanInterface = macvlan
+	}
+	if errOfDial := vr.dialTransport(); errOfDial != nil {
+		vr.closeTransport()
+		return nil, fmt.Errorf("New: %w", errOfDial)
+	}
+	logger.GLoger.Printf(logger.INFO, "virtual router %v initialized, working on %v", VRID, nif)
+	return vr, nil
+}
+
+// dialTransport set up the raw IP connection and the address announcer on the interface
+// unless they are supplied by the caller
+func (r *VirtualRouter) dialTransport() error {
+	return r.inNamespace(func() error {
+		if errOfDial := r.dialAnnouncer(); errOfDial != nil {
+			return errOfDial
+		}
+		if errOfDial := r.dialConnection(); errOfDial != nil {
+			return errOfDial
+		}
+		if r.addrInstaller == nil && r.macvlanInterface != nil {
+			//move the protected addresses onto the macvlan interface
+			var installer, errOfNew = NewAddressManager(r.macvlanInterface.Name, AddressOptions{Namespace: r.namespace})
+			if errOfNew != nil {
+				return errOfNew
+			}
+			r.addrInstaller = installer
+			r.ownInstaller = true
+		}
+		return nil
+	})
+}
+
+// dialAnnouncer set up the ARP/NDP client on the interface unless the announcer is supplied by the caller
+func (r *VirtualRouter) dialAnnouncer() error {
+	if r.ipAddrAnnouncer != nil {
+		return nil
+	}
+	var announcer AddrAnnouncer
+	var errOfDial error
+	if r.ipvX == IPv4 {
+		//set up ARP client, gratuitous ARP is sent from the virtual MAC so that switches learn it
+		if r.macvlanInterface != nil {
+			announcer, errOfDial = DialIPv4AddrAnnouncer(r.macvlanInterface)
+		} else {
+			announcer, errOfDial = DialIPv4AddrAnnouncer(r.NetInterface())
+		}
 	} else {
-		vr.preferredSourceIP = preferred
+		//set up ND client, the macvlan interface has no link local address to send from
+		announcer, errOfDial = DialIPv6AddrAnnouncer(r.NetInterface())
+	}
+	if errOfDial != nil {
+		return errOfDial
+	}
+	r.ipAddrAnnouncer = announcer
+	r.ownAnnouncer = true
+	return nil
+}
+
+// dialConnection set up the raw IP connection from the source address unless the connection is supplied by the caller
+func (r *VirtualRouter) dialConnection() error {
+	if r.iplayerInterface != nil {
+		return nil
 	}
-	if IPvX == IPv4 {
-		//set up ARP client
-		vr.ipAddrAnnouncer = NewIPv4AddrAnnouncer(NetworkInterface)
+	var con IPConnection
+	var errOfDial error
+	if len(r.peers) != 0 {
+		//unicast mode, the peers are reached through the routing table
+		var conn, errOfDialUnicast = DialUnicastConn(r.preferredSourceIP, r.peers)
+		con, errOfDial = conn, errOfDialUnicast
+	} else if r.ipvX == IPv4 {
 		//set up IPv4 interface
-		vr.iplayerInterface = NewIPv4Conn(vr.preferredSourceIP, VRRPMultiAddrIPv4)
+		var conn, errOfDialIPv4 = DialIPv4Conn(r.preferredSourceIP, VRRPMultiAddrIPv4)
+		if errOfDialIPv4 == nil && r.macvlanInterface != nil {
+			if errOfDialIPv4 = conn.SetMulticastInterface(r.macvlanInterface); errOfDialIPv4 != nil {
+				conn.Close()
+			}
+		}
+		con, errOfDial = conn, errOfDialIPv4
 	} else {
-		//set up ND client
-		vr.ipAddrAnnouncer = NewIPIPv6AddrAnnouncer(NetworkInterface)
 		//set up IPv6 interface
-		vr.iplayerInterface = NewIPv6Con(vr.preferredSourceIP, VRRPMultiAddrIPv6)
+		var conn, errOfDialIPv6 = DialIPv6Con(r.preferredSourceIP, VRRPMultiAddrIPv6)
+		if errOfDialIPv6 == nil && r.macvlanInterface != nil {
+			if errOfDialIPv6 = conn.SetMulticastInterface(r.macvlanInterface); errOfDialIPv6 != nil {
+				conn.Close()
+			}
+		}
+		con, errOfDial = conn, errOfDialIPv6
 	}
-	logger.GLoger.Printf(logger.INFO, "virtual router %v initialized, working on %v", VRID, nif)
-	return vr
+	if errOfDial != nil {
+		return errOfDial
+	}
+	r.iplayerInterface = con
+	r.ownConnection = true
+	return nil
+}
+
+// closeTransport close the connection and the announcer set up by dialTransport,
+// those supplied by the caller are left untouched
+func (r *VirtualRouter) closeTransport() {
+	if r.ownAnnouncer {
+		if closer, ok := r.ipAddrAnnouncer.(io.Closer); ok {
+			if errOfClose := closer.Close(); errOfClose != nil {
+				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.closeTransport: %v", errOfClose)
+			}
+		}
+		r.ipAddrAnnouncer = nil
+		r.ownAnnouncer = false
+	}
+	if r.ownConnection {
+		if closer, ok := r.iplayerInterface.(io.Closer); ok {
+			if errOfClose := closer.Close(); errOfClose != nil {
+				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.closeTransport: %v", errOfClose)
+			}
+		}
+		r.iplayerInterface = nil
+		r.ownConnection = false
+	}
+	if r.ownInstaller {
+		if closer, ok := r.addrInstaller.(io.Closer); ok {
+			if errOfClose := closer.Close(); errOfClose != nil {
+				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.closeTransport: %v", errOfClose)
+			}
+		}
+		r.addrInstaller = nil
+		r.ownInstaller = false
+	}
+}
 
+// VRID return the virtual router identifier
+func (r *VirtualRouter) VRID() byte {
+	return r.vrID
+}
+
+// IPvX return the address family the virtual router works with
+func (r *VirtualRouter) IPvX() byte {
+	return r.ipvX
+}
+
+// HardwareAddr return the MAC address announced for the protected addresses, it's the virtual MAC
+// in virtual MAC mode and the address of the interface otherwise
+func (r *VirtualRouter) HardwareAddr() net.HardwareAddr {
+	if r.useVirtualMAC {
+		if r.ipvX == IPv6 {
+			return r.virtualRouterMACAddressIPv6
+		}
+		return r.virtualRouterMACAddressIPv4
+	}
+	var nif = r.NetInterface()
+	if nif == nil {
+		return nil
+	}
+	return nif.HardwareAddr
+}
+
+// NetInterface return the network interface the virtual router works on, it's refreshed on the link changes
+// while the virtual router is running. nil is returned when the virtual router runs over a caller supplied transport.
+func (r *VirtualRouter) NetInterface() *net.Interface {
+	r.interfaceMutex.RLock()
+	defer r.interfaceMutex.RUnlock()
+	return r.netInterface
+}
+
+// ProtectedIPaddrs return the IP addresses protected by the virtual router,
+// caller supplied AddrAnnouncer uses it to find the addresses to announce
+func (r *VirtualRouter) ProtectedIPaddrs() []net.IP {
+	r.addrMutex.RLock()
+	defer r.addrMutex.RUnlock()
+	var addrs = make([]net.IP, 0, len(r.protectedIPaddrs))
+	for k := range r.protectedIPaddrs {
+		var ip = make(net.IP, net.IPv6len)
+		copy(ip, k[:])
+		addrs = append(addrs, ip)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i], addrs[j]) < 0
+	})
+	return addrs
+}
+
+// execute run f in the event loop if the virtual router is running, otherwise run f directly,
+// it returns after f finishes. It must not be called from the event loop itself.
+func (r *VirtualRouter) execute(f func()) {
+	r.mutex.Lock()
+	if !r.running {
+		f()
+		r.refreshStatus()
+		r.mutex.Unlock()
+		return
+	}
+	var loopDone = r.loopDone
+	r.mutex.Unlock()
+	var finished = make(chan struct{})
+	select {
+	case r.commandChannel <- func() {
+		f()
+		r.refreshStatus()
+		close(finished)
+	}:
+		<-finished
+	case <-loopDone:
+		//the event loop exited in the meantime
+		r.execute(f)
+	}
+}
+
+// SetPriority set the priority, Skew_Time and Master_Down_Interval are re-evaluated
+// with the new priority
+func (r *VirtualRouter) SetPriority(priority byte) *VirtualRouter {
+	r.execute(func() {
+		r.reconfigureFilter(func() {
+			r.setPriority(priority)
+		})
+		r.setMasterAdvInterval(r.advertisementIntervalOfMaster)
+	})
+	return r
+}
+
+// SetAcceptMode set Accept_Mode, the protected addresses are blocked or unblocked at once if the
+// virtual router is MASTER
+func (r *VirtualRouter) SetAcceptMode(flag bool) *VirtualRouter {
+	r.execute(func() {
+		r.reconfigureFilter(func() {
+			r.acceptMode = flag
+		})
+	})
+	return r
 }
 
+// accepting report whether the virtual router accepts packets addressed to the protected addresses
+// when it's MASTER
+func (r *VirtualRouter) accepting() bool {
+	return r.acceptMode || r.owner || r.priority == 255
+}
+
+// reconfigureFilter run f and block or unblock the protected addresses of a MASTER if f changed
+// whether they are accepted
+func (r *VirtualRouter) reconfigureFilter(f func()) {
+	var before = r.accepting()
+	f()
+	if r.state != MASTER || r.packetFilter == nil || before == r.accepting() {
+		return
+	}
+	for _, ip := range r.ProtectedIPaddrs() {
+		if r.accepting() {
+			r.unblockAddr(ip)
+		} else {
+			r.blockAddr(ip)
+		}
+	}
+}
+
+// blockAddr drop the packets addressed to ip
+func (r *VirtualRouter) blockAddr(ip net.IP) {
+	if errOfBlock := r.packetFilter.Block(r, ip); errOfBlock != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.blockAddr: %v", errOfBlock)
+	}
+}
+
+// unblockAddr accept the packets addressed to ip again
+func (r *VirtualRouter) unblockAddr(ip net.IP) {
+	if errOfUnblock := r.packetFilter.Unblock(r, ip); errOfUnblock != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.unblockAddr: %v", errOfUnblock)
+	}
+}
+
+// setPriority set the base priority, the advertised priority is the base priority adjusted by the tracks
 func (r *VirtualRouter) setPriority(Priority byte) *VirtualRouter {
 	if r.owner {
 		return r
 	}
-	r.priority = Priority
+	r.basePriority = Priority
+	r.priority = r.effectivePriority()
 	return r
 }
 
+// SetAdvInterval set the advertisement interval, it panics if the interval is invalid
 func (r *VirtualRouter) SetAdvInterval(Interval time.Duration) *VirtualRouter {
-	if Interval < 10*time.Millisecond {
-		panic("interval can not less than 10 ms")
+	if errOfUpdate := r.UpdateAdvInterval(Interval); errOfUpdate != nil {
+		panic(errOfUpdate)
 	}
-	r.advertisementInterval = uint16(Interval / (10 * time.Millisecond))
 	return r
 }
 
-func (r *VirtualRouter) SetPriorityAndMasterAdvInterval(priority byte, interval time.Duration) *VirtualRouter {
-	r.setPriority(priority)
+// UpdateAdvInterval set the advertisement interval, ErrInvalidInterval is returned if
+// the interval can't be carried by an advertisement
+func (r *VirtualRouter) UpdateAdvInterval(interval time.Duration) error {
+	if errOfInterval := validateInterval(interval, r.accepted.Load().version); errOfInterval != nil {
+		return fmt.Errorf("VirtualRouter.UpdateAdvInterval: %w", errOfInterval)
+	}
+	r.execute(func() {
+		r.advertisementInterval = r.normalizeInterval(uint16(interval / (10 * time.Millisecond)))
+		if r.state == MASTER {
+			//restart the advertisement timer with the new interval
+			r.stopAdvertTicker()
+			r.makeAdvertTicker()
+		}
+	})
+	return nil
+}
+
+// validateInterval check the interval fits in the 12-bit centisecond field of the VRRPv3 advertisement,
+// or in the 8-bit second field of the VRRPv2 advertisement
+func validateInterval(interval time.Duration, version VRRPVersion) error {
+	var longest = 4095 * 10 * time.Millisecond
+	if version == VRRPv2 {
+		longest = 255 * time.Second
+	}
 	if interval < 10*time.Millisecond {
-		panic("interval can not less than 10 ms")
+		return fmt.Errorf("%w: %v is less than 10ms", ErrInvalidInterval, interval)
+	}
+	if interval > longest {
+		return fmt.Errorf("%w: %v is greater than %v for %v", ErrInvalidInterval, interval, longest, version)
+	}
+	return nil
+}
+
+// normalizeInterval round the interval up to whole seconds when VRRPv2 advertisements are sent,
+// since VRRPv2 carries the advertisement interval in seconds
+func (r *VirtualRouter) normalizeInterval(interval uint16) uint16 {
+	if r.version != VRRPv2 && !r.v2Compatible {
+		return interval
+	}
+	if interval%100 != 0 {
+		interval = (interval/100 + 1) * 100
+		logger.GLoger.Printf(logger.INFO, "advertisement interval rounded up to %v seconds for VRRPv2", interval/100)
+	}
+	return interval
+}
+
+// SetVersion set the VRRP version of advertisements sent and accepted by the virtual router,
+// it panics if the version isn't available
+func (r *VirtualRouter) SetVersion(version VRRPVersion) *VirtualRouter {
+	if errOfUpdate := r.UpdateVersion(version); errOfUpdate != nil {
+		panic(errOfUpdate)
+	}
+	return r
+}
+
+// UpdateVersion set the VRRP version of advertisements sent and accepted by the virtual router,
+// ErrInvalidVersion is returned for VRRPv2 (RFC 3768) over IPv6 or in authenticated mode, and
+// ErrInvalidInterval for VRRPv3 if the advertisement interval is longer than 40.95s
+func (r *VirtualRouter) UpdateVersion(version VRRPVersion) error {
+	if errOfVersion := validateVersion(version, r.ipvX, r.authenticator != nil); errOfVersion != nil {
+		return fmt.Errorf("VirtualRouter.UpdateVersion: %w", errOfVersion)
+	}
+	//VRRPv3 can't carry the longest intervals of VRRPv2
+	if errOfInterval := validateInterval(r.Status().AdvertisementInterval, version); errOfInterval != nil {
+		return fmt.Errorf("VirtualRouter.UpdateVersion: %w", errOfInterval)
+	}
+	r.execute(func() {
+		r.version = version
+		r.updateAccepted()
+		r.advertisementInterval = r.normalizeInterval(r.advertisementInterval)
+	})
+	return nil
+}
+
+// validateVersion check the version is available to the virtual router of IPvX
+func validateVersion(version VRRPVersion, IPvX byte, authenticated bool) error {
+	switch {
+	case version != VRRPv3 && version != VRRPv2:
+		return fmt.Errorf("%w: %v", ErrInvalidVersion, version)
+	case version == VRRPv2 && IPvX != IPv4:
+		return fmt.Errorf("%w: %v over IPv%v", ErrInvalidVersion, version, IPvX)
+	case version == VRRPv2 && authenticated:
+		return fmt.Errorf("%w: authenticated mode requires %v", ErrInvalidVersion, VRRPv3)
+	}
+	return nil
+}
+
+// SetV2Compatibility enable the VRRPv2/VRRPv3 coexistence mode described in RFC 5798 8.4,
+// it panics if the mode isn't available
+func (r *VirtualRouter) SetV2Compatibility(flag bool) *VirtualRouter {
+	if errOfUpdate := r.UpdateV2Compatibility(flag); errOfUpdate != nil {
+		panic(errOfUpdate)
+	}
+	return r
+}
+
+// UpdateV2Compatibility enable the VRRPv2/VRRPv3 coexistence mode described in RFC 5798 8.4,
+// a VRRPv3 router in this mode also listens to VRRPv2 advertisements, sends VRRPv2 advertisements
+// along with VRRPv3 ones when MASTER, and never preempts a VRRPv2 master. ErrInvalidVersion is
+// returned over IPv6 or in authenticated mode.
+func (r *VirtualRouter) UpdateV2Compatibility(flag bool) error {
+	if flag {
+		if errOfVersion := validateVersion(VRRPv2, r.ipvX, r.authenticator != nil); errOfVersion != nil {
+			return fmt.Errorf("VirtualRouter.UpdateV2Compatibility: %w", errOfVersion)
+		}
+	}
+	r.execute(func() {
+		r.v2Compatible = flag
+		r.updateAccepted()
+		r.advertisementInterval = r.normalizeInterval(r.advertisementInterval)
+	})
+	return nil
+}
+
+// SetV2Authentication set the authentication type and data carried in VRRPv2 advertisements,
+// it panics if the authentication type isn't supported
+func (r *VirtualRouter) SetV2Authentication(authType byte, key string) *VirtualRouter {
+	if errOfUpdate := r.UpdateV2Authentication(authType, key); errOfUpdate != nil {
+		panic(errOfUpdate)
 	}
-	r.setMasterAdvInterval(uint16(interval / (10 * time.Millisecond)))
 	return r
 }
 
+// UpdateV2Authentication set the authentication type and data carried in VRRPv2 advertisements,
+// the key is truncated to 8 bytes. ErrInvalidAuthType is returned for the types other than
+// AuthTypeNone and AuthTypeSimpleText.
+func (r *VirtualRouter) UpdateV2Authentication(authType byte, key string) error {
+	var authData, errOfAuth = v2AuthData(authType, key)
+	if errOfAuth != nil {
+		return fmt.Errorf("VirtualRouter.UpdateV2Authentication: %w", errOfAuth)
+	}
+	r.execute(func() {
+		r.authType = authType
+		r.authData = authData
+		r.updateAccepted()
+	})
+	return nil
+}
+
+// v2AuthData return the authentication data of VRRPv2 advertisements carrying authType
+func v2AuthData(authType byte, key string) ([8]byte, error) {
+	var authData [8]byte
+	switch authType {
+	case AuthTypeNone:
+	case AuthTypeSimpleText:
+		copy(authData[:], key)
+	default:
+		return authData, fmt.Errorf("%w: %v", ErrInvalidAuthType, authType)
+	}
+	return authData, nil
+}
+
+// SetPriorityAndMasterAdvInterval set the priority and the advertisement interval of master,
+// it panics if the interval is invalid
+func (r *VirtualRouter) SetPriorityAndMasterAdvInterval(priority byte, interval time.Duration) *VirtualRouter {
+	if errOfUpdate := r.UpdatePriorityAndMasterAdvInterval(priority, interval); errOfUpdate != nil {
+		panic(errOfUpdate)
+	}
+	return r
+}
+
+// UpdatePriorityAndMasterAdvInterval set the priority and the advertisement interval of master,
+// ErrInvalidInterval is returned if the interval can't be carried by an advertisement
+func (r *VirtualRouter) UpdatePriorityAndMasterAdvInterval(priority byte, interval time.Duration) error {
+	if errOfInterval := validateInterval(interval, r.accepted.Load().version); errOfInterval != nil {
+		return fmt.Errorf("VirtualRouter.UpdatePriorityAndMasterAdvInterval: %w", errOfInterval)
+	}
+	r.execute(func() {
+		r.setPriority(priority)
+		r.setMasterAdvInterval(uint16(interval / (10 * time.Millisecond)))
+	})
+	return nil
+}
+
 func (r *VirtualRouter) setMasterAdvInterval(Interval uint16) *VirtualRouter {
 	r.advertisementIntervalOfMaster = Interval
 	r.skewTime = r.advertisementIntervalOfMaster - uint16(float32(r.advertisementIntervalOfMaster)*float32(r.priority)/256)
-	r.masterDownInterval = 3*r.advertisementIntervalOfMaster + r.skewTime
+	//the VRRPv2 interval of 255 seconds makes Master_Down_Interval exceed 16 bits
+	r.masterDownInterval = 3*uint32(r.advertisementIntervalOfMaster) + uint32(r.skewTime)
 	//从MasterDownInterval和SkewTime的计算方式来看，同一组VirtualRouter中，Priority越高的Router越快地认为某个Master失效
 	return r
 }
 
 func (r *VirtualRouter) SetPreemptMode(flag bool) *VirtualRouter {
-	r.preempt = flag
+	r.execute(func() {
+		r.preempt = flag
+	})
+	return r
+}
+
+// SetPreemptDelay set how long a lower priority master is followed before it's preempted
+func (r *VirtualRouter) SetPreemptDelay(delay time.Duration) *VirtualRouter {
+	r.execute(func() {
+		r.preemptDelay = delay
+	})
+	return r
+}
+
+// SetStartupDelay set how long the virtual router stays BACKUP after it starts, it takes effect at the next start
+func (r *VirtualRouter) SetStartupDelay(delay time.Duration) *VirtualRouter {
+	r.execute(func() {
+		r.startupDelay = delay
+	})
 	return r
 }
 
+// Handoff resign as MASTER with an advertisement of priority 0 and become BACKUP, the virtual router
+// then follows the master which takes over without preempting it. Preemption applies again once
+// that master resigns or times out, or another master is seen.
+func (r *VirtualRouter) Handoff() error {
+	var errOfHandoff error
+	r.execute(func() {
+		if r.state != MASTER {
+			errOfHandoff = fmt.Errorf("VirtualRouter.Handoff: %w: virtual router %v is %v", ErrNotMaster, r.vrID, r.state)
+			return
+		}
+		r.resign()
+		r.handedOff = true
+		r.masterIP, r.masterPriority = nil, 0
+		//the backups take over after Skew_Time, the master down timer only fires if none is left
+		r.makeMasterDownTimer()
+		r.transit(BACKUP, Master2Backup, ReasonHandoff)
+	})
+	return errOfHandoff
+}
+
+// deferPreempt report whether a lower priority master must still be followed, during the startup delay
+// and until it's seen for the preempt delay
+func (r *VirtualRouter) deferPreempt() bool {
+	var now = r.clock.Now()
+	if now.Before(r.startupUntil) {
+		return true
+	}
+	if r.preemptDelay <= 0 {
+		return false
+	}
+	if r.preemptSince.IsZero() {
+		r.preemptSince = now
+	}
+	return now.Sub(r.preemptSince) < r.preemptDelay
+}
+
+// AddIPvXAddr protect ip with the virtual router, the address is carried by the next advertisement
+// and announced immediately if the virtual router is MASTER
 func (r *VirtualRouter) AddIPvXAddr(ip net.IP) {
 	var key [16]byte
-	copy(key[:], ip)
-	if _, ok := r.protectedIPaddrs[key]; ok {
-		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.AddIPvXAddr: add redundant IP addr %v", ip)
-	} else {
+	copy(key[:], ip.To16())
+	r.execute(func() {
+		r.addrMutex.Lock()
+		var _, ok = r.protectedIPaddrs[key]
 		r.protectedIPaddrs[key] = true
-	}
+		r.addrMutex.Unlock()
+		if ok {
+			logger.GLoger.Printf(logger.ERROR, "VirtualRouter.AddIPvXAddr: add redundant IP addr %v", ip)
+			return
+		}
+		if r.state == MASTER {
+			r.installAddr(net.IP(key[:]))
+			r.announce(net.IP(key[:]))
+		}
+	})
 }
 
+// RemoveIPvXAddr stop protecting ip, the address is left out of the next advertisement
 func (r *VirtualRouter) RemoveIPvXAddr(ip net.IP) {
 	var key [16]byte
-	copy(key[:], ip)
-	if _, ok := r.protectedIPaddrs[key]; ok {
+	copy(key[:], ip.To16())
+	r.execute(func() {
+		r.addrMutex.Lock()
+		var _, ok = r.protectedIPaddrs[key]
 		delete(r.protectedIPaddrs, key)
-		logger.GLoger.Printf(logger.INFO, "IP %v removed", ip)
+		r.addrMutex.Unlock()
+		if ok {
+			logger.GLoger.Printf(logger.INFO, "IP %v removed", ip)
+			if r.state == MASTER {
+				r.uninstallAddr(net.IP(key[:]))
+			}
+		} else {
+			logger.GLoger.Printf(logger.ERROR, "VirtualRouter.RemoveIPvXAddr: remove inexistent IP addr %v", ip)
+		}
+	})
+}
+
+// installAddr configure ip on the host if an AddrInstaller is set,
+// and block it if Accept_Mode is false
+func (r *VirtualRouter) installAddr(ip net.IP) {
+	if r.packetFilter != nil && !r.accepting() {
+		r.blockAddr(ip)
+	}
+	if r.addrInstaller == nil {
+		return
+	}
+	if errOfInstall := r.addrInstaller.InstallAddr(r, ip); errOfInstall != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.installAddr: %v", errOfInstall)
+	}
+}
+
+// uninstallAddr remove ip from the host if an AddrInstaller is set, and unblock it
+func (r *VirtualRouter) uninstallAddr(ip net.IP) {
+	if r.packetFilter != nil && !r.accepting() {
+		r.unblockAddr(ip)
+	}
+	if r.addrInstaller == nil {
+		return
+	}
+	if errOfUninstall := r.addrInstaller.UninstallAddr(r, ip); errOfUninstall != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.uninstallAddr: %v", errOfUninstall)
+	}
+}
+
+// installAddrs configure all the protected addresses before they are announced by the new MASTER
+func (r *VirtualRouter) installAddrs() {
+	for _, ip := range r.ProtectedIPaddrs() {
+		r.installAddr(ip)
+	}
+}
+
+// uninstallAddrs remove all the protected addresses once the virtual router is no longer MASTER
+func (r *VirtualRouter) uninstallAddrs() {
+	for _, ip := range r.ProtectedIPaddrs() {
+		r.uninstallAddr(ip)
+	}
+}
+
+// announce send gratuitous ARP or unsolicited NA for ip, all the protected addresses are announced
+// if the announcer can't announce a single address
+func (r *VirtualRouter) announce(ip net.IP) {
+	var errOfAnnounce error
+	if single, ok := r.ipAddrAnnouncer.(SingleAddrAnnouncer); ok {
+		errOfAnnounce = single.AnnounceAddr(r, ip)
 	} else {
-		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.RemoveIPvXAddr: remove inexistent IP addr %v", ip)
+		errOfAnnounce = r.ipAddrAnnouncer.AnnounceAll(r)
+	}
+	if errOfAnnounce != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.announce: %v", errOfAnnounce)
+	} else if _, ok := r.ipAddrAnnouncer.(SingleAddrAnnouncer); ok {
+		r.counters.announcements.Add(1)
+	} else {
+		r.counters.announcements.Add(uint64(len(r.ProtectedIPaddrs())))
 	}
 }
 
 func (r *VirtualRouter) sendAdvertMessage() {
-	for k := range r.protectedIPaddrs {
-		logger.GLoger.Printf(logger.DEBUG, "send advert message of IP %v", net.IP(k[:]))
+	for _, ip := range r.ProtectedIPaddrs() {
+		logger.GLoger.Printf(logger.DEBUG, "send advert message of IP %v", ip)
 	}
-	var x = r.assembleVRRPPacket()
+	var x = r.assembleVRRPPacket(r.version)
 	if errOfWrite := r.iplayerInterface.WriteMessage(x); errOfWrite != nil {
 		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.WriteMessage: %v", errOfWrite)
+	} else {
+		r.countSent()
+	}
+	if r.version == VRRPv3 && r.v2Compatible {
+		//RFC 5798 8.4.3, send VRRPv2 advertisement as well to keep VRRPv2 routers in BACKUP
+		if errOfWrite := r.iplayerInterface.WriteMessage(r.assembleVRRPPacket(VRRPv2)); errOfWrite != nil {
+			logger.GLoger.Printf(logger.ERROR, "VirtualRouter.WriteMessage: %v", errOfWrite)
+		} else {
+			r.countSent()
+		}
 	}
 }
 
-// assembleVRRPPacket assemble VRRP advert packet
-func (r *VirtualRouter) assembleVRRPPacket() *VRRPPacket {
+// assembleVRRPPacket assemble VRRP advert packet of designated version
+func (r *VirtualRouter) assembleVRRPPacket(version VRRPVersion) *VRRPPacket {
 
 	var packet VRRPPacket
 	packet.SetPriority(r.priority)
-	packet.SetVersion(VRRPv3)
+	packet.SetVersion(version)
 	packet.SetVirtualRouterID(r.vrID)
+	if version == VRRPv2 {
+		packet.SetAuthType(r.authType)
+		packet.AuthData = r.authData
+	}
 	packet.SetAdvertisementInterval(r.advertisementInterval)
 	packet.SetType()
-	for k := range r.protectedIPaddrs {
-		packet.AddIPvXAddr(r.ipvX, net.IP(k[:]))
+	for _, ip := range r.ProtectedIPaddrs() {
+		packet.AddIPvXAddr(r.ipvX, ip)
 	}
 	var pshdr PseudoHeader
 	pshdr.Protocol = VRRPIPProtocolNumber
@@ -174,22 +986,88 @@ func (r *VirtualRouter) assembleVRRPPacket() *VRRPPacket {
 	} else {
 		pshdr.Daddr = VRRPMultiAddrIPv6
 	}
+	if r.authenticator != nil && version == VRRPv3 {
+		r.authenticator.Sign(&packet, r.preferredSourceIP)
+	}
 	pshdr.Len = uint16(len(packet.ToBytes()))
 	pshdr.Saddr = r.preferredSourceIP
 	packet.SetCheckSum(&pshdr)
 	return &packet
 }
 
-// fetchVRRPPacket read VRRP packet from IP layer then push into Packet queue
-func (r *VirtualRouter) fetchVRRPPacket() {
+// readDeadliner is implemented by the connections whose pending ReadMessage can be interrupted,
+// the reader of a connection the virtual router doesn't close is stopped through a read deadline
+type readDeadliner interface {
+	SetReadDeadline(deadline time.Time) error
+}
+
+// startReader read the advertisements from the current connection until stopReader is called
+func (r *VirtualRouter) startReader() {
+	var done, stopped = make(chan struct{}), make(chan struct{})
+	var con = r.iplayerInterface
+	var deadliner, interruptible = con.(readDeadliner)
+	if interruptible {
+		//the deadline set by the last stop is cleared
+		if errOfSet := deadliner.SetReadDeadline(time.Time{}); errOfSet != nil {
+			logger.GLoger.Printf(logger.ERROR, "VirtualRouter.startReader: %v", errOfSet)
+		}
+	}
+	r.stopReader = func() {
+		close(done)
+		if interruptible {
+			if errOfSet := deadliner.SetReadDeadline(time.Now()); errOfSet != nil {
+				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.stopReader: %v", errOfSet)
+			}
+		}
+	}
+	r.readerStopped = stopped
+	r.readerInterruptible = interruptible
+	go func(con IPConnection) {
+		r.fetchVRRPPacket(con, done)
+		close(stopped)
+	}(con)
+}
+
+// fetchVRRPPacket read VRRP packet from IP layer then push into Packet queue until done is closed
+// or the connection is closed
+func (r *VirtualRouter) fetchVRRPPacket(con IPConnection, done <-chan struct{}) {
 	for {
-		if packet, errofFetch := r.iplayerInterface.ReadMessage(); errofFetch != nil {
+		var packet, errofFetch = con.ReadMessage()
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if errofFetch != nil {
+			if errors.Is(errofFetch, net.ErrClosed) {
+				//the connection is closed on every shutdown
+				return
+			}
+			countReceiveError(&r.counters, errofFetch)
 			logger.GLoger.Printf(logger.ERROR, "VirtualRouter.fetchVRRPPacket: %v", errofFetch)
 		} else {
-			if r.vrID == packet.GetVirtualRouterID() {
-				r.packetQueue <- packet
+			if r.vrID != packet.GetVirtualRouterID() {
+				logger.GLoger.Printf(logger.DEBUG, "VirtualRouter.fetchVRRPPacket: received a advertisement with different ID: %v", packet.GetVirtualRouterID())
+			} else if !r.fromPeer(packet) {
+				//strangers on a shared segment send an advertisement every interval, they are counted
+				r.counters.unknownPeerErrors.Add(1)
+				logger.GLoger.Printf(logger.DEBUG, "VirtualRouter.fetchVRRPPacket: received an advertisement from %v which is not a peer", packet.Pshdr.Saddr)
+			} else if errOfAccept := r.acceptVersion(packet); errOfAccept != nil {
+				//the advertisement of another version is neither counted as received nor published
+				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.fetchVRRPPacket: %v", errOfAccept)
+			} else if errOfVerify := r.verify(packet); errOfVerify != nil {
+				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.fetchVRRPPacket: %v", errOfVerify)
 			} else {
-				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.fetchVRRPPacket: received a advertisement with different ID: %v", packet.GetVirtualRouterID())
+				r.countReceived(packet)
+				r.publishAdvertisement(packet)
+				//the clock waits until the event loop handles the advertisement
+				holdClock(r.clock)
+				select {
+				case r.packetQueue <- packet:
+				case <-done:
+					releaseClock(r.clock)
+					return
+				}
 			}
 
 		}
@@ -197,8 +1075,81 @@ func (r *VirtualRouter) fetchVRRPPacket() {
 	}
 }
 
+// fromPeer report whether the advertisement is sent by a peer, any sender is accepted out of unicast mode
+func (r *VirtualRouter) fromPeer(packet *VRRPPacket) bool {
+	if len(r.peers) == 0 {
+		return true
+	}
+	if packet.Pshdr == nil {
+		return false
+	}
+	for _, peer := range r.peers {
+		if peer.Equal(packet.Pshdr.Saddr) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify check the authentication of the advertisement, any advertisement passes out of authenticated mode
+func (r *VirtualRouter) verify(packet *VRRPPacket) error {
+	if r.authenticator == nil {
+		return nil
+	}
+	return r.authenticator.Verify(packet)
+}
+
+// versionPolicy is what the virtual router accepts from the advertisements
+type versionPolicy struct {
+	version      VRRPVersion
+	v2Compatible bool
+	authType     byte
+	authData     [8]byte
+}
+
+// updateAccepted publish the version and VRRPv2 authentication to the reader, it runs in the event loop
+func (r *VirtualRouter) updateAccepted() {
+	r.accepted.Store(&versionPolicy{version: r.version, v2Compatible: r.v2Compatible, authType: r.authType, authData: r.authData})
+}
+
+// acceptVersion check whether the version and VRRPv2 authentication of the advertisement
+// match the configuration of the virtual router, it's called by the reader
+func (r *VirtualRouter) acceptVersion(packet *VRRPPacket) error {
+	var accepted = r.accepted.Load()
+	var version = VRRPVersion(packet.GetVersion())
+	switch {
+	case version == accepted.version:
+	case version == VRRPv2 && accepted.v2Compatible:
+	default:
+		r.counters.versionErrors.Add(1)
+		r.counters.protocolError.Store(int32(VersionError))
+		return fmt.Errorf("received an advertisement with %v", version)
+	}
+	if version == VRRPv2 {
+		if packet.GetAuthType() != accepted.authType {
+			return fmt.Errorf("received a VRRPv2 advertisement with authentication type %v", packet.GetAuthType())
+		}
+		if accepted.authType == AuthTypeSimpleText && packet.AuthData != accepted.authData {
+			return fmt.Errorf("received a VRRPv2 advertisement with mismatched authentication data")
+		}
+	}
+	return nil
+}
+
+// preemptable report whether the sender of the advertisement can be preempted,
+// a VRRPv3 router in coexistence mode never preempts a VRRPv2 master (RFC 5798 8.4)
+func (r *VirtualRouter) preemptable(packet *VRRPPacket) bool {
+	if !r.preempt || r.handedOff {
+		return false
+	}
+	if r.version == VRRPv3 && VRRPVersion(packet.GetVersion()) == VRRPv2 {
+		return false
+	}
+	return true
+}
+
 func (r *VirtualRouter) makeAdvertTicker() {
-	r.advertisementTicker = time.NewTicker(time.Duration(r.advertisementInterval*10) * time.Millisecond)
+	r.advertisementTicker = r.clock.NewTicker(time.Duration(r.advertisementInterval) * 10 * time.Millisecond)
 }
 
 func (r *VirtualRouter) stopAdvertTicker() {
@@ -207,7 +1158,7 @@ func (r *VirtualRouter) stopAdvertTicker() {
 
 func (r *VirtualRouter) makeMasterDownTimer() {
 	if r.masterDownTimer == nil {
-		r.masterDownTimer = time.NewTimer(time.Duration(r.masterDownInterval*10) * time.Millisecond)
+		r.masterDownTimer = r.clock.NewTimer(time.Duration(r.masterDownInterval) * 10 * time.Millisecond)
 	} else {
 		r.resetMasterDownTimer()
 	}
@@ -217,7 +1168,7 @@ func (r *VirtualRouter) stopMasterDownTimer() {
 	logger.GLoger.Printf(logger.DEBUG, "master down timer stopped")
 	if !r.masterDownTimer.Stop() {
 		select {
-		case <-r.masterDownTimer.C:
+		case <-r.masterDownTimer.C():
 		default:
 		}
 		logger.GLoger.Printf(logger.DEBUG, "master down timer expired before we stop it, drain the channel")
@@ -226,15 +1177,21 @@ func (r *VirtualRouter) stopMasterDownTimer() {
 
 func (r *VirtualRouter) resetMasterDownTimer() {
 	r.stopMasterDownTimer()
-	r.masterDownTimer.Reset(time.Duration(r.masterDownInterval*10) * time.Millisecond)
+	r.masterDownTimer.Reset(time.Duration(r.masterDownInterval) * 10 * time.Millisecond)
 }
 
 func (r *VirtualRouter) resetMasterDownTimerToSkewTime() {
 	r.stopMasterDownTimer()
-	r.masterDownTimer.Reset(time.Duration(r.skewTime*10) * time.Millisecond)
+	r.masterDownTimer.Reset(time.Duration(r.skewTime) * 10 * time.Millisecond)
 }
 
-func (r *VirtualRouter) Enroll(transition2 transition, handler func()) bool {
+// Enroll register handler for transition2, it returns true if an earlier handler is overwritten.
+// Handlers are called in order off the event loop, up to HANDLERQUEUESIZE calls wait for a slow handler
+// and the later ones are dropped. Run waits HANDLERWAITTIMEOUT at most for the pending calls before it returns.
+// Use Subscribe or SubscribeFunc for the details of the transition.
+func (r *VirtualRouter) Enroll(transition2 Transition, handler func()) bool {
+	r.handlerMutex.Lock()
+	defer r.handlerMutex.Unlock()
 	if _, ok := r.transitionHandler[transition2]; ok {
 		logger.GLoger.Printf(logger.INFO, fmt.Sprintf("VirtualRouter.Enroll(): handler of transition [%s] overwrited", transition2))
 		r.transitionHandler[transition2] = handler
@@ -245,8 +1202,57 @@ func (r *VirtualRouter) Enroll(transition2 transition, handler func()) bool {
 	return false
 }
 
-func (r *VirtualRouter) transitionDoWork(t transition) {
+// transit move the virtual router into state and publish the transition
+func (r *VirtualRouter) transit(state State, t Transition, reason TransitionReason) {
+	var from = r.state
+	r.state = state
+	r.lastTransition = r.clock.Now()
+	r.masterResigned = false
+	r.preemptSince = time.Time{}
+	r.counters.transitions[t].Add(1)
+	r.countMasterReason(state)
+	r.preempting = false
+	if state != BACKUP {
+		r.handedOff = false
+	}
+	switch {
+	case state == INIT:
+		r.upSince = time.Time{}
+	case from == INIT:
+		r.upSince = r.lastTransition
+	}
+	switch state {
+	case MASTER, INIT, FAULT:
+		r.masterIP, r.masterPriority = nil, 0
+	}
+	r.refreshStatus()
+	var event = TransitionEvent{
+		VRID:       r.vrID,
+		From:       from,
+		To:         state,
+		Transition: t,
+		Reason:     reason,
+		Time:       r.lastTransition,
+	}
+	switch state {
+	case MASTER:
+		event.MasterIP, event.Priority = r.preferredSourceIP, r.priority
+	case BACKUP:
+		event.MasterIP, event.Priority = r.masterIP, r.masterPriority
+	}
+	logger.GLoger.Printf(logger.INFO, "virtual router %v transits from %v to %v: %v", r.vrID, from, state, reason)
+	r.publish(event)
+	if !r.handlerQueue.push(func() { r.transitionDoWork(t) }) {
+		logger.GLoger.Printf(logger.ERROR, "virtual router %v: handlers are too slow, the handler of [%v] is dropped, %v dropped so far",
+			r.vrID, t, r.handlerQueue.droppedCount())
+	}
+	r.reportToSyncGroup()
+}
+
+func (r *VirtualRouter) transitionDoWork(t Transition) {
+	r.handlerMutex.Lock()
 	var work, ok = r.transitionHandler[t]
+	r.handlerMutex.Unlock()
 	if ok == false {
 		//return fmt.Errorf("VirtualRouter.transitionDoWork(): handler of [%s] does not exist", t)
 		return
@@ -258,8 +1264,10 @@ func (r *VirtualRouter) transitionDoWork(t transition) {
 
 // ///////////////////////////////////////
 func largerThan(ip1, ip2 net.IP) bool {
-	if len(ip1) != len(ip2) {
-		logger.GLoger.Printf(logger.FATAL, "largerThan: two compared IP addresses must have the same length")
+	ip1, ip2 = ip1.To16(), ip2.To16()
+	if ip1 == nil || ip2 == nil {
+		logger.GLoger.Printf(logger.ERROR, "largerThan: can't compare invalid IP addresses")
+		return false
 	}
 	for index := range ip1 {
 		if ip1[index] > ip2[index] {
@@ -271,188 +1279,81 @@ func largerThan(ip1, ip2 net.IP) bool {
 	return false
 }
 
-// eventLoop VRRP event loop to handle various triggered events
-func (r *VirtualRouter) eventLoop() {
+// eventSelector VRRP event selector to handle various triggered events,
+// it returns when the virtual router transits into INIT after shutdown or ctx is done
+func (r *VirtualRouter) eventSelector(ctx context.Context) error {
+	//the timer or ticker which fired last and whether an advertisement is taken from the queue,
+	//they are handled once the status is refreshed
+	var fired interface{}
+	var received bool
 	for {
+		r.refreshStatus()
+		if fired != nil {
+			Handled(fired)
+			fired = nil
+		}
+		if received {
+			releaseClock(r.clock)
+			received = false
+		}
 		switch r.state {
 		case INIT:
 			select {
-			case event := <-r.eventChannel:
-				if event == START {
-					logger.GLoger.Printf(logger.INFO, "event %v received", event)
-					if r.priority == 255 || r.owner {
-						logger.GLoger.Printf(logger.INFO, "enter owner mode")
-						r.sendAdvertMessage()
-						if errOfarp := r.ipAddrAnnouncer.AnnounceAll(r); errOfarp != nil {
-							logger.GLoger.Printf(logger.ERROR, "VirtualRouter.EventLoop: %v", errOfarp)
-						}
-						//set up advertisement timer
-						r.makeAdvertTicker()
-						logger.GLoger.Printf(logger.DEBUG, "enter MASTER state")
-						r.state = MASTER
-						r.transitionDoWork(Init2Master)
-					} else {
-						logger.GLoger.Printf(logger.INFO, "VR is not the owner of protected IP addresses")
-						r.setMasterAdvInterval(r.advertisementInterval)
-						//set up master down timer
-						r.makeMasterDownTimer()
-						logger.GLoger.Printf(logger.DEBUG, "enter BACKUP state")
-						r.state = BACKUP
-						r.transitionDoWork(Init2Backup)
-					}
-				}
-			}
-		case MASTER:
-			//check if shutdown event received
-			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case command := <-r.commandChannel:
+				command()
 			case event := <-r.eventChannel:
 				if event == SHUTDOWN {
-					//close advert timer
-					r.stopAdvertTicker()
-					//send advertisement with priority 0
-					var priority = r.priority
-					r.setPriority(0)
-					r.sendAdvertMessage()
-					r.setPriority(priority)
-					//transition into INIT
-					r.state = INIT
-					r.transitionDoWork(Master2Init)
-					logger.GLoger.Printf(logger.INFO, "event %v received", event)
-					//maybe we can break out the event loop
+					logger.GLoger.Printf(logger.INFO, "event %v received before start", event)
+					return nil
 				}
-			case <-r.advertisementTicker.C: //check if advertisement timer fired
-				r.sendAdvertMessage()
-			default:
-				//nothing to do, just break
-			}
-			//process incoming advertisement
-			select {
-			case packet := <-r.packetQueue:
-				if packet.GetPriority() == 0 {
-					//I don't think we should anything here
-				} else {
-					if packet.GetPriority() > r.priority || (packet.GetPriority() == r.priority && largerThan(packet.Pshdr.Saddr, r.preferredSourceIP)) {
-
-						//cancel Advertisement timer
-						r.stopAdvertTicker()
-						//set up master down timer
-						r.setMasterAdvInterval(packet.GetAdvertisementInterval())
-						r.makeMasterDownTimer()
-						r.state = BACKUP
-						r.transitionDoWork(Master2Backup)
+				if event == START {
+					logger.GLoger.Printf(logger.INFO, "event %v received", event)
+					r.startupUntil = r.clock.Now().Add(r.startupDelay)
+					if r.faulty() {
+						r.transit(FAULT, Init2Fault, ReasonFault)
 					} else {
-						//just discard this one
+						r.activate(Init2Master, Init2Backup, ReasonOwnerStartup, ReasonStartup)
 					}
 				}
-			default:
-				//nothing to do
 			}
-		case BACKUP:
+		case FAULT:
 			select {
+			case <-ctx.Done():
+				r.transit(INIT, Fault2Init, ReasonAdminShutdown)
+				return ctx.Err()
 			case event := <-r.eventChannel:
 				if event == SHUTDOWN {
-					//close master down timer
-					r.stopMasterDownTimer()
-					//transition into INIT
-					r.state = INIT
-					r.transitionDoWork(Backup2Init)
-					logger.GLoger.Printf(logger.INFO, "event %s received", event)
-				}
-			default:
-			}
-			//process incoming advertisement
-			select {
-			case packet := <-r.packetQueue:
-				if packet.GetPriority() == 0 {
-					logger.GLoger.Printf(logger.INFO, "received an advertisement with priority 0, transit into MASTER state", r.vrID)
-					//Set the Master_Down_Timer to Skew_Time
-					r.resetMasterDownTimerToSkewTime()
-				} else {
-					if r.preempt == false || packet.GetPriority() > r.priority || (packet.GetPriority() == r.priority && largerThan(packet.Pshdr.Saddr, r.preferredSourceIP)) {
-						//reset master down timer
-						r.setMasterAdvInterval(packet.GetAdvertisementInterval())
-						r.resetMasterDownTimer()
-					} else {
-						//nothing to do, just discard this one
-					}
-				}
-			default:
-				//nothing to do
-			}
-			select {
-			//Master_Down_Timer fired
-			case <-r.masterDownTimer.C:
-				// Send an ADVERTISEMENT
-				r.sendAdvertMessage()
-				if errOfARP := r.ipAddrAnnouncer.AnnounceAll(r); errOfARP != nil {
-					logger.GLoger.Printf(logger.ERROR, "VirtualRouter.EventLoop: %v", errOfARP)
-				}
-				//Set the Advertisement Timer to Advertisement interval
-				r.makeAdvertTicker()
-
-				r.state = MASTER
-				r.transitionDoWork(Backup2Master)
-			default:
-				//nothing to do
-			}
-
-		}
-	}
-}
-
-// eventSelector VRRP event selector to handle various triggered events
-func (r *VirtualRouter) eventSelector() {
-	for {
-		switch r.state {
-		case INIT:
-			select {
-			case event := <-r.eventChannel:
-				if event == START {
 					logger.GLoger.Printf(logger.INFO, "event %v received", event)
-					if r.priority == 255 || r.owner {
-						logger.GLoger.Printf(logger.INFO, "enter owner mode")
-						r.sendAdvertMessage()
-						if errOfarp := r.ipAddrAnnouncer.AnnounceAll(r); errOfarp != nil {
-							logger.GLoger.Printf(logger.ERROR, "VirtualRouter.EventLoop: %v", errOfarp)
-						}
-						//set up advertisement timer
-						r.makeAdvertTicker()
-
-						logger.GLoger.Printf(logger.DEBUG, "enter MASTER state")
-						r.state = MASTER
-						r.transitionDoWork(Init2Master)
-					} else {
-						logger.GLoger.Printf(logger.INFO, "VR is not the owner of protected IP addresses")
-						r.setMasterAdvInterval(r.advertisementInterval)
-						//set up master down timer
-						r.makeMasterDownTimer()
-						logger.GLoger.Printf(logger.DEBUG, "enter BACKUP state")
-						r.state = BACKUP
-						r.transitionDoWork(Init2Backup)
-					}
+					r.transit(INIT, Fault2Init, ReasonAdminShutdown)
+					return nil
 				}
+			case command := <-r.commandChannel:
+				command()
+			case <-r.packetQueue:
+				//advertisements are ignored until the fault is gone
+				received = true
 			}
 		case MASTER:
 			//check if shutdown event received
 			select {
+			case <-ctx.Done():
+				r.shutdownMaster()
+				return ctx.Err()
 			case event := <-r.eventChannel:
 				if event == SHUTDOWN {
-					//close advert timer
-					r.stopAdvertTicker()
-					//send advertisement with priority 0
-					var priority = r.priority
-					r.setPriority(0)
-					r.sendAdvertMessage()
-					r.setPriority(priority)
-					//transition into INIT
-					r.state = INIT
-					r.transitionDoWork(Master2Init)
 					logger.GLoger.Printf(logger.INFO, "event %v received", event)
-					//maybe we can break out the event loop
+					r.shutdownMaster()
+					return nil
 				}
-			case <-r.advertisementTicker.C: //check if advertisement timer fired
+			case <-r.advertisementTicker.C(): //check if advertisement timer fired
+				fired = r.advertisementTicker
 				r.sendAdvertMessage()
+			case command := <-r.commandChannel:
+				command()
 			case packet := <-r.packetQueue: //process incoming advertisement
+				received = true
 				if packet.GetPriority() == 0 {
 					//I don't think we should anything here
 				} else {
@@ -460,11 +1361,12 @@ func (r *VirtualRouter) eventSelector() {
 
 						//cancel Advertisement timer
 						r.stopAdvertTicker()
+						r.uninstallAddrs()
 						//set up master down timer
 						r.setMasterAdvInterval(packet.GetAdvertisementInterval())
 						r.makeMasterDownTimer()
-						r.state = BACKUP
-						r.transitionDoWork(Master2Backup)
+						r.masterIP, r.masterPriority = packet.Pshdr.Saddr, packet.GetPriority()
+						r.transit(BACKUP, Master2Backup, ReasonHigherPriority)
 					} else {
 						//just discard this one
 					}
@@ -473,61 +1375,281 @@ func (r *VirtualRouter) eventSelector() {
 
 		case BACKUP:
 			select {
+			case <-ctx.Done():
+				r.shutdownBackup()
+				return ctx.Err()
 			case event := <-r.eventChannel:
 				if event == SHUTDOWN {
-					//close master down timer
-					r.stopMasterDownTimer()
-					//transition into INIT
-					r.state = INIT
-					r.transitionDoWork(Backup2Init)
 					logger.GLoger.Printf(logger.INFO, "event %s received", event)
+					r.shutdownBackup()
+					return nil
 				}
+			case command := <-r.commandChannel:
+				command()
 			case packet := <-r.packetQueue: //process incoming advertisement
+				received = true
 				if packet.GetPriority() == 0 {
-					logger.GLoger.Printf(logger.INFO, "received an advertisement with priority 0, transit into MASTER state", r.vrID)
+					logger.GLoger.Printf(logger.INFO, "virtual router %v received an advertisement with priority 0, transit into MASTER state", r.vrID)
 					//Set the Master_Down_Timer to Skew_Time
 					r.resetMasterDownTimerToSkewTime()
+					r.masterIP, r.masterPriority = nil, 0
+					r.masterResigned = true
+					//the master which took over from a handoff is gone
+					r.handedOff = false
 				} else {
-					if r.preempt == false || packet.GetPriority() > r.priority || (packet.GetPriority() == r.priority && largerThan(packet.Pshdr.Saddr, r.preferredSourceIP)) {
+					if r.handedOff && r.masterIP != nil && !r.masterIP.Equal(packet.Pshdr.Saddr) {
+						//another master replaced the one which took over from a handoff
+						r.handedOff = false
+					}
+					//the master may be preempted if it has a lower priority, or the same priority and a smaller address
+					var eligible = r.preemptable(packet) && (packet.GetPriority() < r.priority || (packet.GetPriority() == r.priority && !largerThan(packet.Pshdr.Saddr, r.preferredSourceIP)))
+					if !eligible {
+						r.preemptSince = time.Time{}
+					}
+					if !eligible || r.deferPreempt() {
 						//reset master down timer
 						r.setMasterAdvInterval(packet.GetAdvertisementInterval())
 						r.resetMasterDownTimer()
+						r.masterIP, r.masterPriority = packet.Pshdr.Saddr, packet.GetPriority()
+						r.masterResigned = false
 					} else {
 						//nothing to do, just discard this one
+						r.preempting = true
 					}
 				}
-			case <-r.masterDownTimer.C: //Master_Down_Timer fired
+			case <-r.masterDownTimer.C(): //Master_Down_Timer fired
+				fired = r.masterDownTimer
+				if r.authenticator != nil {
+					//the master may come back with a lower sequence number after its clock stepped back
+					r.authenticator.forget(r.vrID)
+				}
+				if remaining := r.startupUntil.Sub(r.clock.Now()); remaining > 0 {
+					//stay BACKUP until the startup delay elapses
+					r.masterDownTimer.Reset(remaining)
+					break
+				}
+				if r.syncGroup != nil && r.syncGroup.holdsBackup(r) {
+					//another member of the sync group follows a live master, wait for it
+					r.masterIP, r.masterPriority = nil, 0
+					r.resetMasterDownTimer()
+					break
+				}
+				r.installAddrs()
 				// Send an ADVERTISEMENT
 				r.sendAdvertMessage()
-				if errOfARP := r.ipAddrAnnouncer.AnnounceAll(r); errOfARP != nil {
-					logger.GLoger.Printf(logger.ERROR, "VirtualRouter.EventLoop: %v", errOfARP)
-				}
+				r.announceAll()
 				//Set the Advertisement Timer to Advertisement interval
 				r.makeAdvertTicker()
-				r.state = MASTER
-				r.transitionDoWork(Backup2Master)
+				if r.masterResigned {
+					r.transit(MASTER, Backup2Master, ReasonPriorityZero)
+				} else {
+					r.transit(MASTER, Backup2Master, ReasonMasterDown)
+				}
 			}
 
 		}
 	}
 }
 
-func (vr *VirtualRouter) StartWithEventLoop() {
-	go vr.fetchVRRPPacket()
-	go func() {
+// activate leave INIT or FAULT, the owner becomes MASTER at once unless the startup delay holds it back,
+// the others become BACKUP
+func (r *VirtualRouter) activate(toMaster, toBackup Transition, masterReason, backupReason TransitionReason) {
+	if (r.priority == 255 || r.owner) && !r.clock.Now().Before(r.startupUntil) {
+		logger.GLoger.Printf(logger.INFO, "enter owner mode")
+		r.installAddrs()
+		r.sendAdvertMessage()
+		r.announceAll()
+		//set up advertisement timer
+		r.makeAdvertTicker()
+
+		logger.GLoger.Printf(logger.DEBUG, "enter MASTER state")
+		r.transit(MASTER, toMaster, masterReason)
+	} else {
+		logger.GLoger.Printf(logger.INFO, "VR is not the owner of protected IP addresses")
+		r.setMasterAdvInterval(r.advertisementInterval)
+		//set up master down timer
+		r.makeMasterDownTimer()
+		logger.GLoger.Printf(logger.DEBUG, "enter BACKUP state")
+		r.transit(BACKUP, toBackup, backupReason)
+	}
+}
+
+// enterFault stop advertising or waiting for the master and transit into FAULT,
+// a MASTER resigns with priority 0 if it still can
+func (r *VirtualRouter) enterFault() {
+	switch r.state {
+	case MASTER:
+		r.resign()
+		r.transit(FAULT, Master2Fault, ReasonFault)
+	case BACKUP:
+		r.stopMasterDownTimer()
+		r.transit(FAULT, Backup2Fault, ReasonFault)
+	}
+}
+
+// resign stop advertising, send an advertisement with priority 0 and release the protected addresses
+func (r *VirtualRouter) resign() {
+	//close advert timer
+	r.stopAdvertTicker()
+	//send advertisement with priority 0
+	var priority = r.priority
+	r.priority = 0
+	r.sendAdvertMessage()
+	r.priority = priority
+	r.uninstallAddrs()
+}
+
+// shutdownMaster resign and transit into INIT
+func (r *VirtualRouter) shutdownMaster() {
+	r.resign()
+	//transition into INIT
+	r.transit(INIT, Master2Init, ReasonAdminShutdown)
+}
+
+// shutdownBackup stop the master down timer and transit into INIT
+func (r *VirtualRouter) shutdownBackup() {
+	//close master down timer
+	r.stopMasterDownTimer()
+	//transition into INIT
+	r.transit(INIT, Backup2Init, ReasonAdminShutdown)
+}
+
+// Run start the virtual router and block until it's stopped by Stop or ctx is done,
+// the priority 0 advertisement is sent before Run returns if the virtual router is MASTER.
+// Connections and announcers set up by the virtual router itself are closed on return and
+// set up again by the next Run, those supplied by the caller are left open.
+// Run returns nil after Stop and ctx.Err() after ctx is done.
+func (vr *VirtualRouter) Run(ctx context.Context) error {
+	vr.mutex.Lock()
+	vr.starting, vr.stopRequested = true, false
+	vr.mutex.Unlock()
+	if errOfDial := vr.dialTransport(); errOfDial != nil {
+		vr.closeTransport()
+		vr.mutex.Lock()
+		vr.starting, vr.stopRequested = false, false
+		vr.mutex.Unlock()
+		return fmt.Errorf("VirtualRouter.Run: %w", errOfDial)
+	}
+	//discard events and advertisements left by the last run
+	for drained := false; !drained; {
+		select {
+		case <-vr.eventChannel:
+		case <-vr.packetQueue:
+			releaseClock(vr.clock)
+		default:
+			drained = true
+		}
+	}
+	vr.startReader()
+	//the priority reflects the tracks before the virtual router starts
+	var health = vr.checkTracks(ctx)
+	var tracksCtx, stopTracks = context.WithCancel(ctx)
+	var tracksStopped sync.WaitGroup
+	//the interface and the source address are watched through netlink, they are polled if the subscription fails
+	var watch, errOfWatch = vr.subscribeInterface()
+	if errOfWatch != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.Run: %v, polling the interface instead", errOfWatch)
+	}
+	if watch != nil {
+		tracksStopped.Add(1)
+		go func() {
+			vr.watchInterface(tracksCtx, watch)
+			tracksStopped.Done()
+		}()
+	}
+	for index, t := range vr.tracks {
+		if watch != nil && (t == vr.linkTrack || t == vr.sourceTrack) {
+			continue
+		}
+		tracksStopped.Add(1)
+		go func(t *trackState, healthy bool) {
+			vr.watchTrack(tracksCtx, t, healthy)
+			tracksStopped.Done()
+		}(t, health[index])
+	}
+	//the addresses the virtual router installs on its macvlan interface are put back when deleted by someone else
+	if installer, ok := vr.addrInstaller.(*AddressManager); ok && vr.ownInstaller {
+		tracksStopped.Add(1)
+		go func() {
+			if errOfWatch := installer.Watch(tracksCtx); tracksCtx.Err() == nil {
+				logger.GLoger.Printf(logger.ERROR, "VirtualRouter.Run: %v, the installed addresses are not watched", errOfWatch)
+			}
+			tracksStopped.Done()
+		}()
+	}
+	var errOfRun error
+	vr.mutex.Lock()
+	var stopped = vr.stopRequested
+	vr.starting, vr.stopRequested = false, false
+	if !stopped {
 		vr.eventChannel <- START
-	}()
-	vr.eventLoop()
+		vr.running = true
+		vr.loopDone = make(chan struct{})
+	}
+	vr.mutex.Unlock()
+	if !stopped {
+		errOfRun = vr.eventSelector(ctx)
+		vr.mutex.Lock()
+		vr.running = false
+		close(vr.loopDone)
+		vr.mutex.Unlock()
+	}
+	stopTracks()
+	tracksStopped.Wait()
+	//handlers of the last transitions are called before Run returns unless a handler is stuck
+	if !vr.handlerQueue.wait(HANDLERWAITTIMEOUT) {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.Run: handlers of virtual router %v still running after %v", vr.vrID, HANDLERWAITTIMEOUT)
+	}
+	vr.stopReader()
+	//the reader is unblocked by its read deadline or by closing the connection, a caller supplied
+	//connection without read deadline may keep it blocked until the next advertisement
+	var readerStopped, waitReader = vr.readerStopped, vr.readerInterruptible || vr.ownConnection
+	vr.closeTransport()
+	if waitReader {
+		<-readerStopped
+	}
+	//the advertisements left don't hold the clock until the next run
+	for drained := false; !drained; {
+		select {
+		case <-vr.packetQueue:
+			releaseClock(vr.clock)
+		default:
+			drained = true
+		}
+	}
+	logger.GLoger.Printf(logger.INFO, "virtual router %v stopped", vr.vrID)
+	return errOfRun
+}
+
+// StartWithEventLoop start the virtual router and block until it's stopped
+//
+// Deprecated: use Run instead.
+func (vr *VirtualRouter) StartWithEventLoop() {
+	vr.StartWithEventSelector()
 }
 
+// StartWithEventSelector start the virtual router and block until it's stopped
 func (vr *VirtualRouter) StartWithEventSelector() {
-	go vr.fetchVRRPPacket()
-	go func() {
-		vr.eventChannel <- START
-	}()
-	vr.eventSelector()
+	if errOfRun := vr.Run(context.Background()); errOfRun != nil {
+		logger.GLoger.Printf(logger.ERROR, "VirtualRouter.StartWithEventSelector: %v", errOfRun)
+	}
 }
 
+// Stop shut the running virtual router down, Run returns after the shutdown completes. A Stop made
+// while Run is starting makes Run return nil without starting the virtual router, a Stop made while
+// no Run is in progress does nothing.
 func (vr *VirtualRouter) Stop() {
-	vr.eventChannel <- SHUTDOWN
+	vr.mutex.Lock()
+	defer vr.mutex.Unlock()
+	if !vr.running {
+		if vr.starting {
+			vr.stopRequested = true
+		}
+		return
+	}
+	select {
+	case vr.eventChannel <- SHUTDOWN:
+	default:
+		//a shutdown is pending already
+	}
 }
@@ -0,0 +1,1000 @@
+package vrrp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+	"vrrp-go/logger"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+const testInterval = 100 * time.Millisecond
+
+func TestMain(m *testing.M) {
+	logger.GLoger.SetLevel(logger.ERROR)
+	os.Exit(m.Run())
+}
+
+// recorder collects the transitions of a virtual router
+type recorder struct {
+	events chan fmt.Stringer
+}
+
+func newRecorder(vr *vrrp.VirtualRouter) *recorder {
+	var rec = &recorder{events: make(chan fmt.Stringer, 100)}
+	enrollAll(rec, vr.Enroll, vrrp.Master2Backup, vrrp.Backup2Master, vrrp.Init2Master, vrrp.Init2Backup, vrrp.Master2Init, vrrp.Backup2Init,
+		vrrp.Init2Fault, vrrp.Master2Fault, vrrp.Backup2Fault, vrrp.Fault2Master, vrrp.Fault2Backup, vrrp.Fault2Init)
+	return rec
+}
+
+func enrollAll[T fmt.Stringer](rec *recorder, enroll func(T, func()) bool, transitions ...T) {
+	for _, t := range transitions {
+		var t = t
+		enroll(t, func() { rec.events <- t })
+	}
+}
+
+// await wait until transition want happens, transitions before it are skipped
+func (rec *recorder) await(t *testing.T, want fmt.Stringer, timeout time.Duration) {
+	t.Helper()
+	var deadline = time.After(timeout)
+	for {
+		select {
+		case got := <-rec.events:
+			if got == want {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("transition [%v] didn't happen in %v", want, timeout)
+		}
+	}
+}
+
+// never make sure none of the transitions happens in duration
+func (rec *recorder) never(t *testing.T, duration time.Duration, unwanted ...fmt.Stringer) {
+	t.Helper()
+	var deadline = time.After(duration)
+	for {
+		select {
+		case got := <-rec.events:
+			for _, u := range unwanted {
+				if got == u {
+					t.Fatalf("unexpected transition [%v]", got)
+				}
+			}
+		case <-deadline:
+			return
+		}
+	}
+}
+
+type node struct {
+	vr       *vrrp.VirtualRouter
+	rec      *recorder
+	endpoint *simnet.Endpoint
+}
+
+func startNode(t *testing.T, seg *simnet.Segment, addr string, priority byte, owner bool, configure ...func(*vrrp.VirtualRouter)) *node {
+	t.Helper()
+	return startNodeWithClock(t, seg, nil, testInterval, addr, priority, owner, configure...)
+}
+
+// startNodeWithConfig start a virtual router described by cfg attached to seg at cfg.SourceIP
+func startNodeWithConfig(t *testing.T, seg *simnet.Segment, cfg *vrrp.Config) *node {
+	t.Helper()
+	var endpoint = seg.Attach(cfg.SourceIP)
+	cfg.Connection, cfg.Announcer = endpoint, endpoint
+	if cfg.AdvertisementInterval == 0 {
+		cfg.AdvertisementInterval = testInterval
+	}
+	var vr, err = vrrp.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n = &node{vr: vr, rec: newRecorder(vr), endpoint: endpoint}
+	go vr.StartWithEventSelector()
+	t.Cleanup(vr.Stop)
+	return n
+}
+
+func startNodeWithClock(t *testing.T, seg *simnet.Segment, clock vrrp.Clock, interval time.Duration, addr string, priority byte, owner bool, configure ...func(*vrrp.VirtualRouter)) *node {
+	t.Helper()
+	var endpoint = seg.Attach(net.ParseIP(addr))
+	var vr = vrrp.NewVirtualRouterWithConfig(&vrrp.Config{
+		VRID:       1,
+		Owner:      owner,
+		IPvX:       vrrp.IPv4,
+		SourceIP:   net.ParseIP(addr),
+		Connection: endpoint,
+		Announcer:  endpoint,
+		Clock:      clock,
+	})
+	vr.SetAdvInterval(interval)
+	vr.SetPriorityAndMasterAdvInterval(priority, interval)
+	vr.AddIPvXAddr(net.ParseIP("192.168.1.254"))
+	for _, f := range configure {
+		f(vr)
+	}
+	var n = &node{vr: vr, rec: newRecorder(vr), endpoint: endpoint}
+	go vr.StartWithEventSelector()
+	t.Cleanup(vr.Stop)
+	return n
+}
+
+func TestElectionByPriority(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var low = startNode(t, seg, "10.0.0.1", 50, false)
+	var high = startNode(t, seg, "10.0.0.2", 250, false)
+	high.rec.await(t, vrrp.Backup2Master, time.Second)
+	low.rec.never(t, 10*testInterval, vrrp.Backup2Master)
+}
+
+func TestTieBreakBySourceAddress(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var smaller = startNode(t, seg, "10.0.0.1", 100, false)
+	var larger = startNode(t, seg, "10.0.0.2", 100, false)
+	larger.rec.await(t, vrrp.Backup2Master, time.Second)
+	//both of them may become MASTER at the same time, the one with smaller address must give up
+	var deadline = time.After(time.Second)
+	for {
+		select {
+		case got := <-smaller.rec.events:
+			if got == vrrp.Master2Backup {
+				larger.rec.never(t, 10*testInterval, vrrp.Master2Backup)
+				return
+			}
+		case <-deadline:
+			//the smaller one never became MASTER
+			larger.rec.never(t, 10*testInterval, vrrp.Master2Backup)
+			return
+		}
+	}
+}
+
+func TestPreemption(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var low = startNode(t, seg, "10.0.0.1", 100, false)
+	low.rec.await(t, vrrp.Backup2Master, time.Second)
+	var high = startNode(t, seg, "10.0.0.2", 200, false)
+	high.rec.await(t, vrrp.Backup2Master, time.Second)
+	low.rec.await(t, vrrp.Master2Backup, time.Second)
+}
+
+func TestPreemptionDisabled(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var low = startNode(t, seg, "10.0.0.1", 100, false)
+	low.rec.await(t, vrrp.Backup2Master, time.Second)
+	var high = startNode(t, seg, "10.0.0.2", 200, false, func(vr *vrrp.VirtualRouter) {
+		vr.SetPreemptMode(false)
+	})
+	high.rec.never(t, 10*testInterval, vrrp.Backup2Master)
+	//the counter covers the whole test, not only the events still queued
+	if count := low.vr.Statistics().Transitions[vrrp.Master2Backup]; count != 0 || low.vr.Status().State != vrrp.MASTER {
+		t.Fatalf("low priority master is %v after %v preemptions", low.vr.Status().State, count)
+	}
+}
+
+func TestPreemptDelay(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var low = startNode(t, seg, "10.0.0.1", 100, false)
+	low.rec.await(t, vrrp.Backup2Master, time.Second)
+	var started = time.Now()
+	var high = startNode(t, seg, "10.0.0.2", 200, false, func(vr *vrrp.VirtualRouter) {
+		vr.SetPreemptDelay(8 * testInterval)
+	})
+	high.rec.await(t, vrrp.Init2Backup, time.Second)
+	high.rec.never(t, 5*testInterval, vrrp.Backup2Master)
+	high.rec.await(t, vrrp.Backup2Master, time.Second)
+	low.rec.await(t, vrrp.Master2Backup, time.Second)
+	if elapsed := time.Since(started); elapsed < 8*testInterval {
+		t.Fatalf("preempted after %v, before the preempt delay", elapsed)
+	}
+}
+
+func TestPreemptDelayDoesNotDelayTakeover(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var low = startNode(t, seg, "10.0.0.1", 100, false)
+	low.rec.await(t, vrrp.Backup2Master, time.Second)
+	var high = startNode(t, seg, "10.0.0.2", 200, false, func(vr *vrrp.VirtualRouter) {
+		vr.SetPreemptDelay(time.Hour)
+	})
+	high.rec.await(t, vrrp.Init2Backup, time.Second)
+	high.rec.never(t, 5*testInterval, vrrp.Backup2Master)
+	if status := high.vr.Status(); !status.MasterIP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Fatalf("status while the preempt delay runs = %+v", status)
+	}
+
+	//the master resigns, so the backup takes over after Skew_Time
+	low.vr.Stop()
+	high.rec.await(t, vrrp.Backup2Master, 3*testInterval)
+}
+
+func TestStartupDelay(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var started = time.Now()
+	var owner = startNode(t, seg, "10.0.0.1", 0, true, func(vr *vrrp.VirtualRouter) {
+		vr.SetStartupDelay(8 * testInterval)
+	})
+	//even the owner stays BACKUP until the startup delay elapses
+	owner.rec.await(t, vrrp.Init2Backup, time.Second)
+	owner.rec.never(t, 5*testInterval, vrrp.Backup2Master, vrrp.Init2Master)
+	owner.rec.await(t, vrrp.Backup2Master, time.Second)
+	if elapsed := time.Since(started); elapsed < 8*testInterval {
+		t.Fatalf("became MASTER after %v, before the startup delay", elapsed)
+	}
+}
+
+func TestPriorityZeroShutdown(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNode(t, seg, "10.0.0.1", 200, false)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.2", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	time.Sleep(2 * testInterval)
+
+	var stopped = time.Now()
+	master.vr.Stop()
+	master.rec.await(t, vrrp.Master2Init, time.Second)
+	backup.rec.await(t, vrrp.Backup2Master, time.Second)
+	//priority 0 advertisement makes the backup take over after Skew_Time instead of Master_Down_Interval
+	if elapsed := time.Since(stopped); elapsed >= 3*testInterval {
+		t.Fatalf("backup took over after %v, longer than 3 advertisement intervals", elapsed)
+	}
+}
+
+func TestHandoff(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNode(t, seg, "10.0.0.1", 200, false)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.2", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	if err := backup.vr.Handoff(); !errors.Is(err, vrrp.ErrNotMaster) {
+		t.Fatalf("Handoff of the backup = %v", err)
+	}
+
+	if err := master.vr.Handoff(); err != nil {
+		t.Fatal(err)
+	}
+	master.rec.await(t, vrrp.Master2Backup, time.Second)
+	backup.rec.await(t, vrrp.Backup2Master, 3*testInterval)
+	//the former master follows the new one despite its higher priority
+	master.rec.never(t, 5*testInterval, vrrp.Backup2Master)
+	if status := master.vr.Status(); status.State != vrrp.BACKUP || !status.MasterIP.Equal(net.ParseIP("10.0.0.2")) {
+		t.Fatalf("status after the handoff = %+v", status)
+	}
+
+	//it takes over again once the new master is gone
+	backup.vr.Stop()
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+}
+
+func TestHandoffEndsWithAnotherMaster(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNode(t, seg, "10.0.0.1", 200, false)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.2", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	if err := master.vr.Handoff(); err != nil {
+		t.Fatal(err)
+	}
+	backup.rec.await(t, vrrp.Backup2Master, 3*testInterval)
+
+	//a third router preempts the new master, the former master preempts it in turn
+	var third = startNode(t, seg, "10.0.0.3", 150, false)
+	third.rec.await(t, vrrp.Backup2Master, time.Second)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	third.rec.await(t, vrrp.Master2Backup, time.Second)
+	if reason := master.vr.Statistics().NewMasterReason; master.vr.Status().State != vrrp.MASTER || reason != vrrp.MasterByPreemption {
+		t.Fatalf("former master is %v, it became MASTER by %v", master.vr.Status().State, reason)
+	}
+}
+
+func TestOwnerTakeover(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var backup = startNode(t, seg, "10.0.0.1", 100, false)
+	backup.rec.await(t, vrrp.Backup2Master, time.Second)
+	var owner = startNode(t, seg, "10.0.0.2", 0, true)
+	owner.rec.await(t, vrrp.Init2Master, time.Second)
+	backup.rec.await(t, vrrp.Master2Backup, time.Second)
+
+	var announced bool
+	for _, a := range seg.Announcements() {
+		if a.Source.Equal(owner.endpoint.Addr()) && a.Addr.Equal(net.ParseIP("192.168.1.254")) {
+			announced = true
+		}
+	}
+	if !announced {
+		t.Fatalf("owner didn't announce the protected address")
+	}
+}
+
+func TestPartitionAndHeal(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNode(t, seg, "10.0.0.1", 200, false)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.2", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+
+	seg.Partition([]*simnet.Endpoint{master.endpoint}, []*simnet.Endpoint{backup.endpoint})
+	backup.rec.await(t, vrrp.Backup2Master, time.Second)
+	seg.Heal()
+	backup.rec.await(t, vrrp.Master2Backup, time.Second)
+	master.rec.never(t, 5*testInterval, vrrp.Master2Backup)
+}
+
+func TestLossyAndDuplicatingSegment(t *testing.T) {
+	var seg = simnet.NewSegment()
+	seg.SetLoss(0.1)
+	seg.SetDuplicate(0.5)
+	seg.SetDelay(5 * time.Millisecond)
+	var master = startNode(t, seg, "10.0.0.1", 200, false)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.2", 100, false)
+	//losing less than 3 advertisements in a row never triggers a failover
+	backup.rec.never(t, 10*testInterval, vrrp.Backup2Master)
+
+	seg.SetLoss(1)
+	backup.rec.await(t, vrrp.Backup2Master, time.Second)
+}
+
+func TestFakeClockWaitsForHandling(t *testing.T) {
+	var clock = vrrp.NewFakeClock(time.Unix(0, 0))
+	var timer = clock.NewTimer(time.Second)
+	var handled = make(chan struct{})
+	go func() {
+		<-timer.C()
+		//Advance would return meanwhile if it didn't wait for the firing to be handled
+		time.Sleep(20 * time.Millisecond)
+		close(handled)
+		vrrp.Handled(timer)
+	}()
+	clock.Advance(time.Second)
+	select {
+	case <-handled:
+	default:
+		t.Fatal("Advance returned before the firing is handled")
+	}
+
+	//a stopped timer doesn't hold the clock back with the value nobody takes
+	var stopped = clock.NewTimer(time.Second)
+	var done = make(chan struct{})
+	go func() {
+		clock.Advance(time.Second)
+		close(done)
+	}()
+	for deadline := time.Now().Add(time.Second); len(stopped.C()) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timer didn't fire")
+		}
+	}
+	stopped.Stop()
+	select {
+	case <-done:
+	case <-time.After(vrrp.FAKEFIRINGTIMEOUT / 2):
+		t.Fatal("Advance still waits for a stopped timer")
+	}
+
+	//the next timer doesn't fire while the clock is held
+	clock.Hold()
+	var next = clock.NewTimer(time.Second)
+	done = make(chan struct{})
+	go func() {
+		clock.Advance(time.Second)
+		close(done)
+	}()
+	select {
+	case <-next.C():
+		t.Fatal("timer fired while the clock is held")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Release()
+	<-next.C()
+	vrrp.Handled(next)
+	<-done
+}
+
+func TestMasterDownIntervalInVirtualTime(t *testing.T) {
+	var clock = vrrp.NewFakeClock(time.Unix(0, 0))
+	var seg = simnet.NewSegment()
+	seg.SetClock(clock)
+	var n = startNodeWithClock(t, seg, clock, time.Second, "10.0.0.1", 100, false)
+	n.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(1)
+
+	//Skew_Time = 100 - 100*100/256 = 61, Master_Down_Interval = 3*100 + 61 = 361 centiseconds
+	clock.Advance(3609 * time.Millisecond)
+	n.rec.never(t, 20*time.Millisecond, vrrp.Backup2Master)
+	clock.Advance(time.Millisecond)
+	n.rec.await(t, vrrp.Backup2Master, time.Second)
+}
+
+func TestSkewTimeInVirtualTime(t *testing.T) {
+	var clock = vrrp.NewFakeClock(time.Unix(0, 0))
+	var seg = simnet.NewSegment()
+	seg.SetClock(clock)
+	var master = startNodeWithClock(t, seg, clock, time.Second, "10.0.0.1", 200, false)
+	master.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(1)
+	clock.Advance(4 * time.Second)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+
+	var backup = startNodeWithClock(t, seg, clock, time.Second, "10.0.0.2", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(2)
+	clock.Advance(10 * time.Second)
+	backup.rec.never(t, 20*time.Millisecond, vrrp.Backup2Master)
+
+	master.vr.Stop()
+	master.rec.await(t, vrrp.Master2Init, time.Second)
+	//Skew_Time = 100 - 100*100/256 = 61 centiseconds
+	clock.Advance(600 * time.Millisecond)
+	backup.rec.never(t, 20*time.Millisecond, vrrp.Backup2Master)
+	clock.Advance(10 * time.Millisecond)
+	backup.rec.await(t, vrrp.Backup2Master, time.Second)
+}
+
+func TestVRRPv2Coexistence(t *testing.T) {
+	var clock = vrrp.NewFakeClock(time.Unix(0, 0))
+	var seg = simnet.NewSegment()
+	seg.SetClock(clock)
+	var v2Master = startNodeWithClock(t, seg, clock, time.Second, "10.0.0.1", 100, false, func(vr *vrrp.VirtualRouter) {
+		vr.SetVersion(vrrp.VRRPv2).SetV2Authentication(vrrp.AuthTypeSimpleText, "password")
+	})
+	v2Master.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(1)
+	clock.Advance(4 * time.Second)
+	v2Master.rec.await(t, vrrp.Backup2Master, time.Second)
+
+	//RFC 5798 8.4, a VRRPv3 router of higher priority never preempts a VRRPv2 master
+	var v3 = startNodeWithConfig(t, seg, &vrrp.Config{
+		VRID:                  1,
+		IPvX:                  vrrp.IPv4,
+		SourceIP:              net.ParseIP("10.0.0.2"),
+		Clock:                 clock,
+		Priority:              200,
+		AdvertisementInterval: time.Second,
+		Addresses:             []net.IP{net.ParseIP("192.168.1.254")},
+		V2Compatibility:       true,
+		V2AuthType:            vrrp.AuthTypeSimpleText,
+		V2AuthKey:             "password",
+	})
+	v3.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(2)
+	for second := 0; second < 10; second++ {
+		clock.Advance(time.Second)
+		v3.rec.never(t, 20*time.Millisecond, vrrp.Backup2Master)
+	}
+	v2Master.rec.never(t, 20*time.Millisecond, vrrp.Master2Backup)
+
+	//the VRRPv3 router takes over when the VRRPv2 master resigns and advertises both versions
+	var listener = seg.Attach(net.ParseIP("10.0.0.9"))
+	v2Master.vr.Stop()
+	v2Master.rec.await(t, vrrp.Master2Init, time.Second)
+	clock.Advance(time.Second)
+	v3.rec.await(t, vrrp.Backup2Master, time.Second)
+	var versions = make(map[vrrp.VRRPVersion]*vrrp.VRRPPacket)
+	for len(versions) < 2 {
+		var packet, err = listener.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if packet.Pshdr.Saddr.Equal(net.ParseIP("10.0.0.2")) {
+			versions[vrrp.VRRPVersion(packet.GetVersion())] = packet
+		}
+	}
+	if v2 := versions[vrrp.VRRPv2]; v2 == nil || v2.GetPriority() != 200 || v2.GetAdvertisementInterval() != 100 ||
+		v2.GetAuthType() != vrrp.AuthTypeSimpleText || string(v2.AuthData[:]) != "password" {
+		t.Fatalf("VRRPv2 advertisements of the VRRPv3 router: %+v", versions)
+	}
+	if v3 := versions[vrrp.VRRPv3]; v3 == nil || v3.GetPriority() != 200 {
+		t.Fatalf("VRRPv3 advertisements of the VRRPv3 router: %+v", versions)
+	}
+}
+
+func TestVRRPv2LongestInterval(t *testing.T) {
+	var clock = vrrp.NewFakeClock(time.Unix(0, 0))
+	var seg = simnet.NewSegment()
+	seg.SetClock(clock)
+	var backup = startNodeWithClock(t, seg, clock, time.Second, "10.0.0.1", 100, false, func(vr *vrrp.VirtualRouter) {
+		vr.SetVersion(vrrp.VRRPv2)
+	})
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(1)
+
+	//a VRRPv2 master advertising every 255 seconds
+	var master = seg.Attach(net.ParseIP("10.0.0.9"))
+	var packet vrrp.VRRPPacket
+	packet.SetVersion(vrrp.VRRPv2)
+	packet.SetType()
+	packet.SetVirtualRouterID(1)
+	packet.SetPriority(200)
+	packet.SetAdvertisementInterval(25500)
+	packet.AddIPvXAddr(vrrp.IPv4, net.ParseIP("192.168.1.254"))
+	packet.SetCheckSum(&vrrp.PseudoHeader{Saddr: master.Addr(), Daddr: vrrp.VRRPMultiAddrIPv4, Protocol: vrrp.VRRPIPProtocolNumber})
+	if err := master.WriteMessage(&packet); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); backup.vr.Status().MasterAdvertisementInterval != 255*time.Second; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("MasterAdvertisementInterval = %v", backup.vr.Status().MasterAdvertisementInterval)
+		}
+	}
+	//Master_Down_Interval = 3*25500 + 25500 - 25500*100/256 = 92040 centiseconds, beyond 16 bits
+	if status := backup.vr.Status(); status.MasterDownInterval != 920400*time.Millisecond {
+		t.Fatalf("MasterDownInterval = %v", status.MasterDownInterval)
+	}
+	clock.Advance(920300 * time.Millisecond)
+	backup.rec.never(t, 20*time.Millisecond, vrrp.Backup2Master)
+	clock.Advance(100 * time.Millisecond)
+	backup.rec.await(t, vrrp.Backup2Master, time.Second)
+
+	//the VRRPv2 router advertises every 255 seconds as well, VRRPv3 can't carry such an interval
+	if err := backup.vr.UpdateAdvInterval(255 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	var listener = seg.Attach(net.ParseIP("10.0.0.8"))
+	clock.Advance(254 * time.Second)
+	listener.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if received, err := listener.ReadMessage(); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("advertisement %+v before the interval elapsed, error %v", received, err)
+	}
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	if received, err := listener.ReadMessage(); err != nil || received.GetAdvertisementInterval() != 25500 {
+		t.Fatalf("advertisement %+v, error %v", received, err)
+	}
+	if err := backup.vr.UpdateVersion(vrrp.VRRPv3); !errors.Is(err, vrrp.ErrInvalidInterval) {
+		t.Fatalf("UpdateVersion to VRRPv3 returned %v", err)
+	}
+}
+
+func TestFlappingForAnHour(t *testing.T) {
+	var clock = vrrp.NewFakeClock(time.Unix(0, 0))
+	var seg = simnet.NewSegment()
+	seg.SetClock(clock)
+	var master = startNodeWithClock(t, seg, clock, time.Second, "10.0.0.1", 200, false)
+	master.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(1)
+	clock.Advance(4 * time.Second)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNodeWithClock(t, seg, clock, time.Second, "10.0.0.2", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(2)
+
+	for minute := 0; minute < 60; minute++ {
+		seg.Partition([]*simnet.Endpoint{master.endpoint}, []*simnet.Endpoint{backup.endpoint})
+		clock.Advance(30 * time.Second)
+		backup.rec.await(t, vrrp.Backup2Master, time.Second)
+		seg.Heal()
+		clock.Advance(30 * time.Second)
+		backup.rec.await(t, vrrp.Master2Backup, time.Second)
+	}
+	//the master kept its role over the hour of virtual time
+	if count := master.vr.Statistics().Transitions[vrrp.Master2Backup]; count != 0 || master.vr.Status().State != vrrp.MASTER {
+		t.Fatalf("master is %v after %v transitions to BACKUP", master.vr.Status().State, count)
+	}
+	if count := backup.vr.Statistics().Transitions[vrrp.Backup2Master]; count != 60 {
+		t.Fatalf("backup became MASTER %v times in 60 partitions", count)
+	}
+}
+
+func TestNewReturnsErrors(t *testing.T) {
+	var endpoint = simnet.NewSegment().Attach(net.ParseIP("10.0.0.1"))
+	var cases = []struct {
+		name string
+		cfg  vrrp.Config
+		want error
+	}{
+		{"address family", vrrp.Config{VRID: 1, IPvX: 5}, vrrp.ErrInvalidAddressFamily},
+		{"short interval", vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, AdvertisementInterval: time.Millisecond}, vrrp.ErrInvalidInterval},
+		{"long interval", vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, AdvertisementInterval: time.Minute}, vrrp.ErrInvalidInterval},
+		{"long VRRPv2 interval", vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, Version: vrrp.VRRPv2, AdvertisementInterval: 256 * time.Second}, vrrp.ErrInvalidInterval},
+		{"VRRPv2 over IPv6", vrrp.Config{VRID: 1, IPvX: vrrp.IPv6, Version: vrrp.VRRPv2}, vrrp.ErrInvalidVersion},
+		{"VRRPv2 compatibility over IPv6", vrrp.Config{VRID: 1, IPvX: vrrp.IPv6, V2Compatibility: true}, vrrp.ErrInvalidVersion},
+		{"VRRPv2 authentication type", vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, Version: vrrp.VRRPv2, V2AuthType: 2}, vrrp.ErrInvalidAuthType},
+		{"missing interface", vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, Interface: "no-such-nif0"}, vrrp.ErrInterfaceNotFound},
+		{"missing transport", vrrp.Config{VRID: 1, IPvX: vrrp.IPv4, Connection: endpoint}, vrrp.ErrInterfaceNotFound},
+	}
+	for _, c := range cases {
+		var c = c
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := vrrp.New(&c.cfg); !errors.Is(err, c.want) {
+				t.Fatalf("got error %v, want %v", err, c.want)
+			}
+		})
+	}
+}
+
+func TestRunReturnsAndRestarts(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var endpoint = seg.Attach(net.ParseIP("10.0.0.1"))
+	var vr = vrrp.NewVirtualRouterWithConfig(&vrrp.Config{
+		VRID:                  1,
+		IPvX:                  vrrp.IPv4,
+		SourceIP:              endpoint.Addr(),
+		Connection:            endpoint,
+		Announcer:             endpoint,
+		AdvertisementInterval: testInterval,
+	})
+	var rec = newRecorder(vr)
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var result = make(chan error)
+	go func() { result <- vr.Run(ctx) }()
+	rec.await(t, vrrp.Backup2Master, time.Second)
+	cancel()
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Run returned %v after cancellation", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Run didn't return after cancellation")
+	}
+	rec.await(t, vrrp.Master2Init, time.Second)
+
+	go func() { result <- vr.Run(context.Background()) }()
+	rec.await(t, vrrp.Init2Backup, time.Second)
+	vr.Stop()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("Run returned %v after Stop", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Run didn't return after Stop")
+	}
+	rec.await(t, vrrp.Backup2Init, time.Second)
+}
+
+func TestStopRightAfterRun(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var endpoint = seg.Attach(net.ParseIP("10.0.0.1"))
+	var vr = vrrp.NewVirtualRouterWithConfig(&vrrp.Config{
+		VRID:                  1,
+		IPvX:                  vrrp.IPv4,
+		SourceIP:              endpoint.Addr(),
+		Connection:            endpoint,
+		Announcer:             endpoint,
+		AdvertisementInterval: testInterval,
+	})
+	var result = make(chan error)
+	//the Stop may come while Run starts or once it runs the event loop, a Stop made before Run
+	//begins does nothing so it's repeated until Run returns
+	for i := 0; i < 50; i++ {
+		go func() { result <- vr.Run(context.Background()) }()
+		if i%2 == 1 {
+			time.Sleep(time.Duration(i) * 10 * time.Microsecond)
+		}
+		var deadline = time.After(time.Second)
+	stopping:
+		for {
+			vr.Stop()
+			select {
+			case err := <-result:
+				if err != nil {
+					t.Fatalf("Run returned %v after Stop", err)
+				}
+				break stopping
+			case <-time.After(time.Millisecond):
+			case <-deadline:
+				t.Fatalf("Run didn't return after Stop %v", i)
+			}
+		}
+	}
+	//a Stop of an idle virtual router isn't left over for the next run
+	vr.Stop()
+	vr.Stop()
+	var rec = newRecorder(vr)
+	go func() { result <- vr.Run(context.Background()) }()
+	rec.await(t, vrrp.Init2Backup, time.Second)
+	vr.Stop()
+	<-result
+}
+
+func TestRuntimeReconfiguration(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNode(t, seg, "10.0.0.2", 200, false)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.1", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+
+	backup.vr.SetPriority(250)
+	backup.rec.await(t, vrrp.Backup2Master, time.Second)
+	master.rec.await(t, vrrp.Master2Backup, time.Second)
+
+	var vip = net.ParseIP("192.168.1.253")
+	backup.vr.AddIPvXAddr(vip)
+	var announced bool
+	for _, a := range seg.Announcements() {
+		if a.Addr.Equal(vip) && a.Source.Equal(backup.endpoint.Addr()) {
+			announced = true
+		}
+	}
+	if !announced {
+		t.Fatalf("address added to MASTER not announced")
+	}
+	if len(backup.vr.ProtectedIPaddrs()) != 2 {
+		t.Fatalf("ProtectedIPaddrs() = %v", backup.vr.ProtectedIPaddrs())
+	}
+	backup.vr.RemoveIPvXAddr(vip)
+	if len(backup.vr.ProtectedIPaddrs()) != 1 {
+		t.Fatalf("ProtectedIPaddrs() = %v", backup.vr.ProtectedIPaddrs())
+	}
+	if err := backup.vr.UpdateAdvInterval(2 * testInterval); err != nil {
+		t.Fatal(err)
+	}
+	master.vr.SetPreemptMode(false)
+	master.rec.never(t, 5*testInterval, vrrp.Backup2Master)
+}
+
+func TestStatus(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNode(t, seg, "10.0.0.2", 200, false)
+	if status := master.vr.Status(); status.State != vrrp.INIT && status.State != vrrp.BACKUP {
+		t.Fatalf("state right after start = %v", status.State)
+	}
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.1", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	time.Sleep(2 * testInterval)
+
+	var status = master.vr.Status()
+	if status.State != vrrp.MASTER || status.State.String() != "MASTER" {
+		t.Fatalf("master state = %v", status.State)
+	}
+	if !status.MasterIP.Equal(net.ParseIP("10.0.0.2")) || status.MasterPriority != 200 || status.Priority != 200 {
+		t.Fatalf("master status = %+v", status)
+	}
+	status = backup.vr.Status()
+	if status.State != vrrp.BACKUP {
+		t.Fatalf("backup state = %v", status.State)
+	}
+	if !status.MasterIP.Equal(net.ParseIP("10.0.0.2")) || status.MasterPriority != 200 || status.Priority != 100 {
+		t.Fatalf("backup status = %+v", status)
+	}
+	if status.MasterAdvertisementInterval != testInterval {
+		t.Fatalf("MasterAdvertisementInterval = %v", status.MasterAdvertisementInterval)
+	}
+	//Skew_Time = Master_Adver_Interval - Master_Adver_Interval * Priority / 256 in centiseconds
+	if status.SkewTime != 70*time.Millisecond || status.MasterDownInterval != 370*time.Millisecond {
+		t.Fatalf("SkewTime = %v, MasterDownInterval = %v", status.SkewTime, status.MasterDownInterval)
+	}
+	if status.LastTransition.IsZero() {
+		t.Fatalf("LastTransition not recorded")
+	}
+	if len(status.Addresses) != 1 || !status.Addresses[0].Equal(net.ParseIP("192.168.1.254")) {
+		t.Fatalf("Addresses = %v", status.Addresses)
+	}
+	backup.vr.SetPriority(150)
+	if status = backup.vr.Status(); status.Priority != 150 {
+		t.Fatalf("priority after SetPriority = %v", status.Priority)
+	}
+}
+
+// next wait for the next event of s
+func next(t *testing.T, s *vrrp.Subscription) vrrp.TransitionEvent {
+	t.Helper()
+	select {
+	case event := <-s.C:
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("no transition event in time")
+	}
+	return vrrp.TransitionEvent{}
+}
+
+func TestTransitionEvents(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var subscription *vrrp.Subscription
+	var low = startNode(t, seg, "10.0.0.1", 100, false, func(vr *vrrp.VirtualRouter) {
+		subscription = vr.Subscribe(0, vrrp.DropOldest)
+	})
+	defer subscription.Close()
+	var event = next(t, subscription)
+	if event.Transition != vrrp.Init2Backup || event.From != vrrp.INIT || event.To != vrrp.BACKUP || event.Reason != vrrp.ReasonStartup {
+		t.Fatalf("first event = %+v", event)
+	}
+	event = next(t, subscription)
+	if event.Transition != vrrp.Backup2Master || event.Reason != vrrp.ReasonMasterDown || event.VRID != 1 {
+		t.Fatalf("second event = %+v", event)
+	}
+	if !event.MasterIP.Equal(net.ParseIP("10.0.0.1")) || event.Priority != 100 || event.Time.IsZero() {
+		t.Fatalf("second event = %+v", event)
+	}
+
+	var high = startNode(t, seg, "10.0.0.2", 200, false)
+	event = next(t, subscription)
+	if event.Transition != vrrp.Master2Backup || event.Reason != vrrp.ReasonHigherPriority {
+		t.Fatalf("third event = %+v", event)
+	}
+	if !event.MasterIP.Equal(net.ParseIP("10.0.0.2")) || event.Priority != 200 {
+		t.Fatalf("third event = %+v", event)
+	}
+
+	high.vr.Stop()
+	event = next(t, subscription)
+	if event.Transition != vrrp.Backup2Master || event.Reason != vrrp.ReasonPriorityZero {
+		t.Fatalf("fourth event = %+v", event)
+	}
+	low.vr.Stop()
+	event = next(t, subscription)
+	if event.Transition != vrrp.Master2Init || event.To != vrrp.INIT || event.Reason != vrrp.ReasonAdminShutdown {
+		t.Fatalf("fifth event = %+v", event)
+	}
+}
+
+func TestSlowSubscriberDoesNotStallAdvertisements(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var newest, oldest *vrrp.Subscription
+	var blocked = make(chan struct{})
+	defer close(blocked)
+	var master = startNode(t, seg, "10.0.0.2", 200, false, func(vr *vrrp.VirtualRouter) {
+		newest = vr.Subscribe(1, vrrp.DropNewest)
+		oldest = vr.Subscribe(1, vrrp.DropOldest)
+		vr.SubscribeFunc(1, vrrp.DropOldest, func(vrrp.TransitionEvent) { <-blocked })
+	})
+	defer newest.Close()
+	defer oldest.Close()
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNode(t, seg, "10.0.0.1", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	backup.rec.never(t, 10*testInterval, vrrp.Backup2Master)
+
+	if newest.Dropped() != 1 || oldest.Dropped() != 1 {
+		t.Fatalf("dropped %v and %v events", newest.Dropped(), oldest.Dropped())
+	}
+	for _, s := range []*vrrp.Subscription{newest, oldest} {
+		select {
+		case <-s.Overflow():
+		default:
+			t.Fatal("overflow not reported")
+		}
+	}
+	if event := <-newest.C; event.Transition != vrrp.Init2Backup {
+		t.Fatalf("DropNewest kept %v", event.Transition)
+	}
+	if event := <-oldest.C; event.Transition != vrrp.Backup2Master {
+		t.Fatalf("DropOldest kept %v", event.Transition)
+	}
+}
+
+func TestAdvertisementEvents(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNode(t, seg, "10.0.0.2", 200, false)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var subscription *vrrp.AdvertisementSubscription
+	startNode(t, seg, "10.0.0.1", 100, false, func(vr *vrrp.VirtualRouter) {
+		subscription = vr.SubscribeAdvertisements(1, vrrp.DropOldest)
+	})
+	defer subscription.Close()
+	var event vrrp.AdvertisementEvent
+	select {
+	case event = <-subscription.C:
+	case <-time.After(time.Second):
+		t.Fatal("no advertisement event in time")
+	}
+	if event.VRID != 1 || !event.Source.Equal(net.ParseIP("10.0.0.2")) || event.Priority != 200 || event.Version != vrrp.VRRPv3 ||
+		event.Interval != testInterval || len(event.Addresses) != 1 || event.Time.IsZero() {
+		t.Fatalf("advertisement event = %+v", event)
+	}
+	//the queue holds one advertisement, the others are dropped rather than stalling the reader
+	time.Sleep(5 * testInterval)
+	if subscription.Dropped() == 0 {
+		t.Fatal("no advertisement dropped")
+	}
+}
+
+func TestHandlerReconfiguresRouter(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var master = startNode(t, seg, "10.0.0.2", 200, false, func(vr *vrrp.VirtualRouter) {
+		var s = vr.SubscribeFunc(0, vrrp.DropOldest, func(event vrrp.TransitionEvent) {
+			if event.To == vrrp.MASTER {
+				vr.AddIPvXAddr(net.ParseIP("192.168.1.253"))
+			}
+		})
+		t.Cleanup(s.Close)
+	})
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var deadline = time.Now().Add(time.Second)
+	for len(master.vr.ProtectedIPaddrs()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("handler didn't reconfigure the virtual router")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSlowHandlerMissesNoTransition(t *testing.T) {
+	var clock = vrrp.NewFakeClock(time.Unix(0, 0))
+	var seg = simnet.NewSegment()
+	seg.SetClock(clock)
+	var master = startNodeWithClock(t, seg, clock, time.Second, "10.0.0.1", 200, false)
+	master.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(1)
+	clock.Advance(4 * time.Second)
+	master.rec.await(t, vrrp.Backup2Master, time.Second)
+	var backup = startNodeWithClock(t, seg, clock, time.Second, "10.0.0.2", 100, false)
+	backup.rec.await(t, vrrp.Init2Backup, time.Second)
+	clock.BlockUntil(2)
+
+	//the first handler blocks while the backup flaps many more times than a subscription queues
+	var gate, called = make(chan struct{}), make(chan vrrp.Transition, 100)
+	backup.vr.Enroll(vrrp.Backup2Master, func() {
+		<-gate
+		called <- vrrp.Backup2Master
+	})
+	backup.vr.Enroll(vrrp.Master2Backup, func() { called <- vrrp.Master2Backup })
+	var events = backup.vr.Subscribe(100, vrrp.DropNewest)
+	defer events.Close()
+	var await = func(want vrrp.Transition) {
+		t.Helper()
+		for {
+			select {
+			case event := <-events.C:
+				if event.Transition == want {
+					return
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("transition [%v] didn't happen", want)
+			}
+		}
+	}
+	const flaps = 3 * vrrp.TRANSITIONQUEUESIZE
+	for i := 0; i < flaps; i++ {
+		seg.Partition([]*simnet.Endpoint{master.endpoint}, []*simnet.Endpoint{backup.endpoint})
+		clock.Advance(30 * time.Second)
+		await(vrrp.Backup2Master)
+		seg.Heal()
+		clock.Advance(30 * time.Second)
+		await(vrrp.Master2Backup)
+	}
+	close(gate)
+	for i := 0; i < 2*flaps; i++ {
+		var want = vrrp.Backup2Master
+		if i%2 == 1 {
+			want = vrrp.Master2Backup
+		}
+		select {
+		case got := <-called:
+			if got != want {
+				t.Fatalf("handler %v called for [%v], want [%v]", i, got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%v handlers called for %v transitions", i, 2*flaps)
+		}
+	}
+}
+
+func TestStuckHandlerDoesNotHangStop(t *testing.T) {
+	var seg = simnet.NewSegment()
+	var endpoint = seg.Attach(net.ParseIP("10.0.0.1"))
+	var vr = vrrp.NewVirtualRouterWithConfig(&vrrp.Config{
+		VRID:                  1,
+		IPvX:                  vrrp.IPv4,
+		SourceIP:              endpoint.Addr(),
+		Connection:            endpoint,
+		Announcer:             endpoint,
+		AdvertisementInterval: testInterval,
+	})
+	var gate, entered = make(chan struct{}), make(chan struct{})
+	defer close(gate)
+	vr.Enroll(vrrp.Backup2Master, func() {
+		close(entered)
+		<-gate
+	})
+	var result = make(chan error)
+	go func() { result <- vr.Run(context.Background()) }()
+	<-entered
+	vr.Stop()
+	select {
+	case <-result:
+	case <-time.After(vrrp.HANDLERWAITTIMEOUT + time.Second):
+		t.Fatal("Run didn't return while a handler is stuck")
+	}
+}
@@ -26,17 +26,44 @@ func (v VRRPVersion) String() string {
 	}
 }
 
+// authentication types of VRRPv2 advertisement, see RFC 3768 5.3.6
+const (
+	AuthTypeNone       byte = 0
+	AuthTypeSimpleText byte = 1
+	AuthTypeIPAH       byte = 2
+)
+
 const (
 	IPv4 = 4
 	IPv6 = 6
 )
 
+// State is the state of the virtual router
+type State int
+
 const (
-	INIT = iota
+	INIT State = iota
 	MASTER
 	BACKUP
+	// FAULT the virtual router neither advertises nor listens until the fault is gone
+	FAULT
 )
 
+func (s State) String() string {
+	switch s {
+	case INIT:
+		return "INIT"
+	case MASTER:
+		return "MASTER"
+	case BACKUP:
+		return "BACKUP"
+	case FAULT:
+		return "FAULT"
+	default:
+		return "unknown state"
+	}
+}
+
 const (
 	VRRPMultiTTL         = 255
 	VRRPIPProtocolNumber = 112
@@ -66,11 +93,15 @@ func (e EVENT) String() string {
 }
 
 const PACKETQUEUESIZE = 1000
-const EVENTCHANNELSIZE = 1
+const EVENTCHANNELSIZE = 2
+
+// WATCHQUEUESIZE is the number of netlink updates of the interface buffered before they are applied
+const WATCHQUEUESIZE = 16
 
-type transition int
+// Transition identifies a transition between two states
+type Transition int
 
-func (t transition) String() string {
+func (t Transition) String() string {
 	switch t {
 	case Master2Backup:
 		return "master to backup"
@@ -84,20 +115,104 @@ func (t transition) String() string {
 		return "backup to init"
 	case Master2Init:
 		return "master to init"
+	case Init2Fault:
+		return "init to fault"
+	case Master2Fault:
+		return "master to fault"
+	case Backup2Fault:
+		return "backup to fault"
+	case Fault2Master:
+		return "fault to master"
+	case Fault2Backup:
+		return "fault to backup"
+	case Fault2Init:
+		return "fault to init"
 	default:
 		return "unknown transition"
 	}
 }
 
 const (
-	Master2Backup transition = iota
+	Master2Backup Transition = iota
 	Backup2Master
 	Init2Master
 	Init2Backup
 	Master2Init
 	Backup2Init
+	Init2Fault
+	Master2Fault
+	Backup2Fault
+	Fault2Master
+	Fault2Backup
+	Fault2Init
+)
+
+// TransitionReason tells why a transition happened
+type TransitionReason int
+
+const (
+	// ReasonStartup the virtual router started as a backup
+	ReasonStartup TransitionReason = iota
+	// ReasonOwnerStartup the virtual router started as the address owner
+	ReasonOwnerStartup
+	// ReasonMasterDown the master down timer expired
+	ReasonMasterDown
+	// ReasonHigherPriority an advertisement with higher priority, or the same priority
+	// from a larger address, was received
+	ReasonHigherPriority
+	// ReasonPriorityZero the master resigned by sending priority 0
+	ReasonPriorityZero
+	// ReasonAdminShutdown the virtual router was stopped
+	ReasonAdminShutdown
+	// ReasonFault the interface is down, the source address is gone or a mandatory track fails
+	ReasonFault
+	// ReasonRecovered the fault is gone
+	ReasonRecovered
+	// ReasonSyncGroup another member of the sync group transited from MASTER to BACKUP
+	ReasonSyncGroup
+	// ReasonHandoff the master was asked to hand off to a backup
+	ReasonHandoff
 )
 
+func (reason TransitionReason) String() string {
+	switch reason {
+	case ReasonStartup:
+		return "startup"
+	case ReasonOwnerStartup:
+		return "owner startup"
+	case ReasonMasterDown:
+		return "master down timer expired"
+	case ReasonHigherPriority:
+		return "higher priority seen"
+	case ReasonPriorityZero:
+		return "priority 0 received"
+	case ReasonAdminShutdown:
+		return "admin shutdown"
+	case ReasonFault:
+		return "fault detected"
+	case ReasonRecovered:
+		return "fault recovered"
+	case ReasonSyncGroup:
+		return "sync group member became backup"
+	case ReasonHandoff:
+		return "handoff"
+	default:
+		return "unknown reason"
+	}
+}
+
+const TRANSITIONQUEUESIZE = 16
+
+// HANDLERQUEUESIZE is how many calls of enrolled handlers wait behind a slow handler before the
+// next ones are dropped, HANDLERWAITTIMEOUT is how long Run waits for the pending calls to end
+const (
+	HANDLERQUEUESIZE   = 1000
+	HANDLERWAITTIMEOUT = time.Second
+)
+
+// FAKEFIRINGTIMEOUT is how long FakeClock.Advance waits for a firing to be handled or for the holds to be released
+const FAKEFIRINGTIMEOUT = time.Second
+
 var (
 	defaultPreempt                    = true
 	defaultPriority              byte = 100
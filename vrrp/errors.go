@@ -0,0 +1,50 @@
+package vrrp
+
+import "errors"
+
+// errors returned by the constructors, test them with errors.Is
+var (
+	ErrInterfaceNotFound    = errors.New("interface not found")
+	ErrNoSourceAddress      = errors.New("no usable source address")
+	ErrPermissionDenied     = errors.New("permission denied for raw socket")
+	ErrInvalidInterval      = errors.New("invalid interval")
+	ErrInvalidAddressFamily = errors.New("address family must be IPv4 or IPv6")
+	ErrInvalidVersion       = errors.New("unsupported VRRP version")
+	ErrInvalidAuthType      = errors.New("unsupported VRRPv2 authentication type")
+	ErrInterfaceConflict    = errors.New("interface exists with another type or parent")
+	ErrNoPeer               = errors.New("no unicast peer")
+)
+
+// errors returned by Manager
+var (
+	ErrDuplicateVRID         = errors.New("VRID already in use")
+	ErrUnknownRouter         = errors.New("virtual router not managed")
+	ErrManagerClosed         = errors.New("manager closed")
+	ErrSourceAddressConflict = errors.New("source address conflicts with the shared transport")
+	ErrRouterRunning         = errors.New("virtual router already running")
+)
+
+// errors returned by Authenticator
+var (
+	ErrInvalidKey      = errors.New("invalid authentication key")
+	ErrUnauthenticated = errors.New("advertisement not authenticated")
+	ErrReplayed        = errors.New("advertisement replayed")
+)
+
+// errors returned by NewSyncGroup and SyncGroup
+var (
+	ErrEmptySyncGroup = errors.New("sync group without member")
+	ErrInSyncGroup    = errors.New("virtual router already in a sync group")
+	ErrNotInSyncGroup = errors.New("virtual router not in the sync group")
+)
+
+// ErrNotMaster is returned by Handoff if the virtual router isn't MASTER
+var ErrNotMaster = errors.New("virtual router is not MASTER")
+
+// errors wrapped by ReadMessage for invalid advertisements, ErrInvalidVersion is wrapped for the unsupported versions
+var (
+	ErrInvalidChecksum = errors.New("invalid checksum")
+	ErrInvalidTTL      = errors.New("TTL or hop limit not 255")
+	ErrInvalidType     = errors.New("not an advertisement")
+	ErrInvalidLength   = errors.New("invalid packet length")
+)
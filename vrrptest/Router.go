@@ -0,0 +1,55 @@
+// Package vrrptest provides the fixtures shared by the tests of the packages built on vrrp,
+// the virtual routers of the fixtures exchange their advertisements over a simnet segment
+package vrrptest
+
+import (
+	"net"
+	"testing"
+	"time"
+	"vrrp-go/simnet"
+	"vrrp-go/vrrp"
+)
+
+// Interval is the advertisement interval of the virtual routers started by StartRouter
+const Interval = 100 * time.Millisecond
+
+// StartRouter run a virtual router attached to seg at addr which protects 192.168.1.254,
+// it's stopped when the test ends
+func StartRouter(t *testing.T, seg *simnet.Segment, VRID byte, addr string, priority byte) *vrrp.VirtualRouter {
+	t.Helper()
+	var endpoint = seg.Attach(net.ParseIP(addr))
+	var vr, err = vrrp.New(&vrrp.Config{
+		VRID:                  VRID,
+		IPvX:                  vrrp.IPv4,
+		Priority:              priority,
+		SourceIP:              endpoint.Addr(),
+		Addresses:             []net.IP{net.ParseIP("192.168.1.254")},
+		AdvertisementInterval: Interval,
+		Connection:            endpoint,
+		Announcer:             endpoint,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go vr.StartWithEventSelector()
+	t.Cleanup(vr.Stop)
+	return vr
+}
+
+// Transport return a TransportFactory attaching one endpoint of seg per call
+func Transport(seg *simnet.Segment) vrrp.TransportFactory {
+	return func(nif string, IPvX byte, source net.IP) (vrrp.IPConnection, vrrp.AddrAnnouncer, error) {
+		var endpoint = seg.Attach(source)
+		return endpoint, endpoint, nil
+	}
+}
+
+// AwaitState wait until vr is in state, the test fails after 2 seconds
+func AwaitState(t *testing.T, vr *vrrp.VirtualRouter, state vrrp.State) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); vr.Status().State != state; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("virtual router %v is %v, want %v", vr.VRID(), vr.Status().State, state)
+		}
+	}
+}